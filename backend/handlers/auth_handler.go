@@ -18,7 +18,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	jwtv5 "github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // AuthHandler handles the OIDC authentication flow on behalf of the frontend.
@@ -26,7 +25,7 @@ import (
 // OAuth2 Authorization Code + PKCE flow and, after a successful exchange, hands
 // back a signed session JWT to the frontend via a query-parameter redirect.
 type AuthHandler struct {
-	logoutHandlesColl *mongo.Collection
+	logoutHandles     services.LogoutHandleStore
 	backchannelEvents *backchannelLogoutEventStore
 }
 
@@ -48,9 +47,9 @@ const (
 )
 
 // NewAuthHandler returns a new AuthHandler.
-func NewAuthHandler(logoutHandlesColl *mongo.Collection) *AuthHandler {
+func NewAuthHandler(logoutHandles services.LogoutHandleStore) *AuthHandler {
 	return &AuthHandler{
-		logoutHandlesColl: logoutHandlesColl,
+		logoutHandles:     logoutHandles,
 		backchannelEvents: newBackchannelLogoutEventStore(7 * 24 * time.Hour),
 	}
 }
@@ -119,8 +118,8 @@ func authError(c *gin.Context, status int, code, message, reason string, retryab
 	c.JSON(status, payload)
 }
 
-func sessionLogoutHandleActive(ctx context.Context, logoutHandlesColl *mongo.Collection, claims *services.SessionClaims) error {
-	if logoutHandlesColl == nil || claims == nil {
+func sessionLogoutHandleActive(ctx context.Context, logoutHandles services.LogoutHandleStore, claims *services.SessionClaims) error {
+	if logoutHandles == nil || claims == nil {
 		return nil
 	}
 
@@ -129,7 +128,7 @@ func sessionLogoutHandleActive(ctx context.Context, logoutHandlesColl *mongo.Col
 		return nil
 	}
 
-	handle, err := services.GetLogoutHandleByID(ctx, logoutHandlesColl, handleID)
+	handle, err := services.GetLogoutHandleByID(ctx, logoutHandles, handleID)
 	if err != nil {
 		return err
 	}
@@ -367,8 +366,8 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	if expiresIn <= 0 {
 		expiresIn = 8 * 3600
 	}
-	if h.logoutHandlesColl == nil {
-		log.Printf("logout handle collection is not configured")
+	if h.logoutHandles == nil {
+		log.Printf("logout handle store is not configured")
 		fail("config_error")
 		return
 	}
@@ -376,14 +375,14 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	logoutHandle, err := services.CreateLogoutHandle(ctx, h.logoutHandlesColl, accountID, accountID, tokens.IDToken, clientID, services.DefaultSessionExpiry(time.Now()))
+	logoutHandle, err := services.CreateLogoutHandle(ctx, h.logoutHandles, accountID, accountID, tokens.IDToken, clientID, services.DefaultSessionExpiry(time.Now()))
 	if err != nil {
 		log.Printf("create logout handle failed: %v", err)
 		fail("logout_handle_failed")
 		return
 	}
 
-	sessionJWT, err := services.IssueSessionJWTWithLogoutHandle(secret, accountID, username, accountID, logoutHandle.ID.Hex(), expiresIn)
+	sessionJWT, err := services.IssueSessionJWTWithVerifiedEmail(secret, accountID, username, accountID, verifiedEmailFromProfile(profile), logoutHandle.ID.Hex(), expiresIn)
 	if err != nil {
 		log.Printf("issue session jwt: %v", err)
 		fail("internal_error")
@@ -426,7 +425,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 			return
 		}
 	}
-	if err := sessionLogoutHandleActive(c.Request.Context(), h.logoutHandlesColl, claims); err != nil {
+	if err := sessionLogoutHandleActive(c.Request.Context(), h.logoutHandles, claims); err != nil {
 		log.Printf("refresh blocked by revoked logout handle: %v", err)
 		authError(c, http.StatusUnauthorized, "invalid_grant", "Session has been revoked", "session_revoked", false)
 		return
@@ -481,7 +480,7 @@ func (h *AuthHandler) Session(c *gin.Context) {
 
 	claims, err := services.VerifySessionJWT(secret, bearerToken)
 	if err == nil {
-		if revocationErr := sessionLogoutHandleActive(c.Request.Context(), h.logoutHandlesColl, claims); revocationErr != nil {
+		if revocationErr := sessionLogoutHandleActive(c.Request.Context(), h.logoutHandles, claims); revocationErr != nil {
 			log.Printf("session rejected by revoked logout handle: %v", revocationErr)
 			setNoStoreHeaders(c)
 			c.JSON(http.StatusOK, gin.H{"authenticated": false})
@@ -571,7 +570,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	bearerToken := extractBearerToken(c.GetHeader("Authorization"))
 	secret := authSecret()
-	if bearerToken != "" && secret != "" && h.logoutHandlesColl != nil {
+	if bearerToken != "" && secret != "" && h.logoutHandles != nil {
 		claims, claimsErr := services.VerifySessionJWT(secret, bearerToken)
 		if claimsErr != nil {
 			claims, claimsErr = services.VerifySessionJWTAllowExpired(secret, bearerToken)
@@ -580,7 +579,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 			ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 			defer cancel()
 
-			handle, handleErr := services.GetLogoutHandleByID(ctx, h.logoutHandlesColl, claims.LogoutHandleID)
+			handle, handleErr := services.GetLogoutHandleByID(ctx, h.logoutHandles, claims.LogoutHandleID)
 			if handleErr == nil && strings.TrimSpace(handle.IDToken) != "" {
 				endpoints, discoveryErr := services.DiscoverOIDCEndpoints(
 					os.Getenv("OIDC_BASE_URL"),
@@ -765,6 +764,22 @@ func stringFromMap(m map[string]any, keys ...string) string {
 	return ""
 }
 
+// verifiedEmailFromProfile returns the profile's email when the provider marked it
+// verified with email_verified, and "" otherwise.
+func verifiedEmailFromProfile(profile map[string]any) string {
+	verified := false
+	switch v := profile["email_verified"].(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = strings.EqualFold(strings.TrimSpace(v), "true")
+	}
+	if !verified {
+		return ""
+	}
+	return stringFromMap(profile, "email")
+}
+
 // verifyIDTokenNonce decodes the id_token payload (without signature verification)
 // and checks that the nonce claim matches the expected value.
 func verifyIDTokenNonce(idToken, expectedNonce string) error {
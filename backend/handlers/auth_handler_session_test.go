@@ -68,3 +68,20 @@ func TestSessionReturnsCanonicalAccountContext(t *testing.T) {
 		t.Fatalf("expected 3 scopes, got %#v", user["scopes"])
 	}
 }
+
+func TestVerifiedEmailFromProfileRequiresEmailVerified(t *testing.T) {
+	cases := []struct {
+		profile map[string]any
+		want    string
+	}{
+		{map[string]any{"email": "registrar@school.test", "email_verified": true}, "registrar@school.test"},
+		{map[string]any{"email": "registrar@school.test", "email_verified": "true"}, "registrar@school.test"},
+		{map[string]any{"email": "registrar@school.test", "email_verified": false}, ""},
+		{map[string]any{"email": "registrar@school.test"}, ""},
+	}
+	for _, tc := range cases {
+		if got := verifiedEmailFromProfile(tc.profile); got != tc.want {
+			t.Fatalf("verifiedEmailFromProfile(%v) = %q, want %q", tc.profile, got, tc.want)
+		}
+	}
+}
@@ -8,13 +8,13 @@ import (
 	"backend/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const (
 	authClaimsContextKey    = "auth.claims"
 	authAccountIDContextKey = "auth.account_id"
 	authUsernameContextKey  = "auth.username"
+	authEmailContextKey     = "auth.email"
 )
 
 type Claims map[string]any
@@ -48,41 +48,69 @@ func usernameFromContext(c *gin.Context) string {
 	return strings.TrimSpace(s)
 }
 
-func RequireSessionAuth(authSecret string, logoutHandlesColl *mongo.Collection) gin.HandlerFunc {
+// officialEmailsFromContext returns the session's verified email address, which
+// sign links are addressed to. Account ids and usernames are never used: they
+// are not verified and may look like someone else's email.
+func officialEmailsFromContext(c *gin.Context) []string {
+	v, _ := c.Get(authEmailContextKey)
+	email, _ := v.(string)
+	if email = strings.TrimSpace(email); email == "" {
+		return nil
+	}
+	return []string{email}
+}
+
+// authenticateSession verifies the request's bearer session and stores its
+// identity on c. On failure it returns the status and message to respond with.
+func authenticateSession(c *gin.Context, authSecret string, logoutHandles services.LogoutHandleStore) (int, string, bool) {
+	bearerToken := extractBearerToken(c.GetHeader("Authorization"))
+	if bearerToken == "" {
+		return http.StatusUnauthorized, "missing bearer token", false
+	}
+	claims, err := services.VerifySessionJWT(authSecret, bearerToken)
+	if err != nil {
+		log.Printf("session auth failed: %v", err)
+		return http.StatusUnauthorized, "invalid or expired token", false
+	}
+	if revocationErr := sessionLogoutHandleActive(c.Request.Context(), logoutHandles, claims); revocationErr != nil {
+		log.Printf("session auth rejected by revoked logout handle: %v", revocationErr)
+		return http.StatusUnauthorized, "invalid or expired token", false
+	}
+	accountID := strings.TrimSpace(claims.AccountID)
+	if accountID == "" {
+		return http.StatusForbidden, "token missing account scope", false
+	}
+	c.Set(authClaimsContextKey, Claims{
+		"sub":       claims.Sub,
+		"accountId": claims.AccountID,
+		"username":  claims.Username,
+		"exp":       claims.Exp,
+	})
+	c.Set(authAccountIDContextKey, accountID)
+	c.Set(authUsernameContextKey, strings.TrimSpace(claims.Username))
+	c.Set(authEmailContextKey, claims.Email)
+	return 0, "", true
+}
+
+func RequireSessionAuth(authSecret string, logoutHandles services.LogoutHandleStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		bearerToken := extractBearerToken(c.GetHeader("Authorization"))
-		if bearerToken == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
-			c.Abort()
-			return
-		}
-		claims, err := services.VerifySessionJWT(authSecret, bearerToken)
-		if err != nil {
-			log.Printf("session auth failed: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-			c.Abort()
-			return
-		}
-		if revocationErr := sessionLogoutHandleActive(c.Request.Context(), logoutHandlesColl, claims); revocationErr != nil {
-			log.Printf("session auth rejected by revoked logout handle: %v", revocationErr)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		if status, msg, ok := authenticateSession(c, authSecret, logoutHandles); !ok {
+			c.JSON(status, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
-		accountID := strings.TrimSpace(claims.AccountID)
-		if accountID == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "token missing account scope"})
-			c.Abort()
-			return
+		c.Next()
+	}
+}
+
+// OptionalSessionAuth reads the session when a bearer token is sent, for public
+// routes that unlock more for signed-in users. Requests without a valid session
+// continue anonymously.
+func OptionalSessionAuth(authSecret string, logoutHandles services.LogoutHandleStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			authenticateSession(c, authSecret, logoutHandles)
 		}
-		c.Set(authClaimsContextKey, Claims{
-			"sub":       claims.Sub,
-			"accountId": claims.AccountID,
-			"username":  claims.Username,
-			"exp":       claims.Exp,
-		})
-		c.Set(authAccountIDContextKey, accountID)
-		c.Set(authUsernameContextKey, strings.TrimSpace(claims.Username))
 		c.Next()
 	}
 }
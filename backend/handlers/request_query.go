@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRequestFilter reads the request listing filters shared by list and export endpoints.
+// Dates accept YYYY-MM-DD (local time) or RFC3339; a date-only created_to covers the whole day.
+func parseRequestFilter(c *gin.Context) (services.RequestFilter, error) {
+	filter := services.RequestFilter{
+		Search:       strings.TrimSpace(c.Query("q")),
+		Name:         strings.TrimSpace(c.Query("name")),
+		IDCard:       strings.TrimSpace(c.Query("id_card")),
+		StudentID:    strings.TrimSpace(c.Query("student_id")),
+		DocumentType: strings.TrimSpace(c.Query("document_type")),
+		Status:       strings.TrimSpace(c.Query("status")),
+		AcademicYear: strings.TrimSpace(c.Query("academic_year")),
+	}
+
+	if raw := strings.TrimSpace(c.Query("created_from")); raw != "" {
+		from, _, err := parseRequestDate(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_from: %s", raw)
+		}
+		filter.CreatedFrom = &from
+	}
+	if raw := strings.TrimSpace(c.Query("created_to")); raw != "" {
+		to, dateOnly, err := parseRequestDate(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_to: %s", raw)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = &to
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return filter, fmt.Errorf("created_from must be before created_to")
+	}
+	return filter, nil
+}
+
+// parseRequestSort reads sort and order query params. Default is newest first.
+func parseRequestSort(c *gin.Context) (field string, asc bool, err error) {
+	field = strings.TrimSpace(c.DefaultQuery("sort", "created_at"))
+	if !services.IsValidRequestSortField(field) {
+		return "", false, fmt.Errorf("unsupported sort field: %s", field)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.DefaultQuery("order", "desc"))) {
+	case "asc":
+		asc = true
+	case "desc":
+		asc = false
+	default:
+		return "", false, fmt.Errorf("order must be asc or desc")
+	}
+	return field, asc, nil
+}
+
+// parseRequestFields reads the comma-separated fields and include query params
+// into the fields a request listing returns.
+func parseRequestFields(c *gin.Context) ([]string, error) {
+	return services.ResolveRequestFields(splitQueryList(c.Query("fields")), splitQueryList(c.Query("include")))
+}
+
+func splitQueryList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func parseRequestDate(raw string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
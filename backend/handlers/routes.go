@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
@@ -18,31 +17,43 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const maxSignatureDataLength = 450000
 
+const maxDecisionTextLength = 1000
+
+var errInvalidSignedVia = errors.New("invalid signed_via")
+
 type signatureUpdatePayload struct {
 	DataBase64 string `json:"data_base64" binding:"required"`
 	Method     string `json:"method" binding:"required,oneof=draw upload"`
 	SignedVia  string `json:"signed_via"`
 }
 
+// Officials may send use_saved_signature instead of an image to apply their saved
+// signature; confirm_saved_signature must then be true.
 type officialSignatureUpdatePayload struct {
-	DataBase64 string `json:"data_base64" binding:"required"`
-	Method     string `json:"method" binding:"required,oneof=draw upload"`
-	SignedVia  string `json:"signed_via"`
-	Decision   string `json:"decision" binding:"required,oneof=approve reject"`
+	DataBase64            string `json:"data_base64" binding:"required_without=UseSavedSignature"`
+	Method                string `json:"method" binding:"required_without=UseSavedSignature,omitempty,oneof=draw upload"`
+	SignedVia             string `json:"signed_via"`
+	Decision              string `json:"decision" binding:"required,oneof=approve reject"`
+	Reason                string `json:"reason"` // required when decision is reject
+	Comment               string `json:"comment"`
+	UseSavedSignature     bool   `json:"use_saved_signature"`
+	ConfirmSavedSignature bool   `json:"confirm_saved_signature"`
 }
 
 type signSessionCompletePayload struct {
-	DataBase64 string `json:"data_base64" binding:"required"`
-	Method     string `json:"method" binding:"required,oneof=draw upload"`
-	SignedVia  string `json:"signed_via"`
-	Decision   string `json:"decision"`
+	DataBase64            string `json:"data_base64" binding:"required_without=UseSavedSignature"`
+	Method                string `json:"method" binding:"required_without=UseSavedSignature,omitempty,oneof=draw upload"`
+	SignedVia             string `json:"signed_via"`
+	Decision              string `json:"decision"`
+	Reason                string `json:"reason"` // required when decision is reject
+	Comment               string `json:"comment"`
+	UseSavedSignature     bool   `json:"use_saved_signature"`
+	ConfirmSavedSignature bool   `json:"confirm_saved_signature"`
 }
 
 type publicSubmitPayload struct {
@@ -58,62 +69,67 @@ type publicSubmitPayload struct {
 	AcademicYear string `json:"academic_year"`
 	FatherName   string `json:"father_name"`
 	MotherName   string `json:"mother_name"`
+	// RequesterEmail is optional; when set the requester is emailed on status changes.
+	RequesterEmail string `json:"requester_email" binding:"omitempty,email,max=254"`
 }
 
-func decodeAndValidateDataURL(input string) error {
+// normalizeSignatureDataURL checks the payload size and returns the signature
+// decoded, validated and re-encoded as a canonical PNG data URL.
+func normalizeSignatureDataURL(input string) (string, error) {
 	trimmed := strings.TrimSpace(input)
 	if trimmed == "" {
-		return fmt.Errorf("signature data is required")
+		return "", fmt.Errorf("signature data is required")
 	}
 	if len(trimmed) > maxSignatureDataLength {
-		return fmt.Errorf("signature payload is too large")
-	}
-
-	encoded := trimmed
-	if strings.HasPrefix(trimmed, "data:") {
-		comma := strings.Index(trimmed, ",")
-		if comma < 0 {
-			return fmt.Errorf("invalid data url")
-		}
-		header := strings.ToLower(trimmed[:comma])
-		if !strings.Contains(header, ";base64") {
-			return fmt.Errorf("signature must be base64 encoded")
-		}
-		if !strings.Contains(header, "image/png") && !strings.Contains(header, "image/jpeg") && !strings.Contains(header, "image/webp") {
-			return fmt.Errorf("unsupported signature image format")
-		}
-		encoded = trimmed[comma+1:]
-	}
-
-	if _, err := base64.StdEncoding.DecodeString(encoded); err != nil {
-		if _, rawErr := base64.RawStdEncoding.DecodeString(encoded); rawErr != nil {
-			return fmt.Errorf("invalid base64 data")
-		}
+		return "", fmt.Errorf("signature payload is too large")
 	}
-	return nil
+	return services.NormalizeSignatureImage(trimmed)
 }
 
 func toSignatureBlock(payload signatureUpdatePayload) (models.SignatureBlock, error) {
-	if err := decodeAndValidateDataURL(payload.DataBase64); err != nil {
+	dataURL, err := normalizeSignatureDataURL(payload.DataBase64)
+	if err != nil {
 		return models.SignatureBlock{}, err
 	}
 
-	signedVia := strings.TrimSpace(payload.SignedVia)
-	if signedVia == "" {
-		signedVia = "web"
-	}
-	if signedVia != "web" && signedVia != "mobile" && signedVia != "qr-mobile" {
-		return models.SignatureBlock{}, fmt.Errorf("invalid signed_via")
+	signedVia, err := toSignedVia(payload.SignedVia)
+	if err != nil {
+		return models.SignatureBlock{}, err
 	}
 
 	return models.SignatureBlock{
-		DataBase64: payload.DataBase64,
+		DataBase64: dataURL,
 		Method:     payload.Method,
 		SignedVia:  signedVia,
 		SignedAt:   time.Now(),
 	}, nil
 }
 
+func toSignedVia(value string) (string, error) {
+	signedVia := strings.TrimSpace(value)
+	if signedVia == "" {
+		signedVia = "web"
+	}
+	if signedVia != "web" && signedVia != "mobile" && signedVia != "qr-mobile" {
+		return "", errInvalidSignedVia
+	}
+	return signedVia, nil
+}
+
+// savedSignatureForLink returns the saved signature of a sign link's recipient for
+// the request's account, once the official confirmed applying it. officialEmails
+// are the caller's verified session emails, which must include the recipient.
+func savedSignatureForLink(ctx context.Context, store services.SavedSignatureStore, link *models.SignLink, officialEmails []string, accountID, signedVia string, confirmed bool) (models.SignatureBlock, error) {
+	via, err := toSignedVia(signedVia)
+	if err != nil {
+		return models.SignatureBlock{}, err
+	}
+	if err := services.AuthorizeSavedSignatureLink(link, officialEmails); err != nil {
+		return models.SignatureBlock{}, err
+	}
+	return services.SavedSignatureBlock(ctx, store, accountID, link.RecipientEmail, link.Role, via, confirmed)
+}
+
 func toOfficialDecision(value string) (models.OfficialDecisionValue, error) {
 	decision := models.OfficialDecisionValue(strings.TrimSpace(value))
 	if !models.IsValidOfficialDecision(decision) {
@@ -122,6 +138,22 @@ func toOfficialDecision(value string) (models.OfficialDecisionValue, error) {
 	return decision, nil
 }
 
+// toOfficialDecisionRecord pairs a decision with its reason and optional comment; a reject must carry a reason.
+func toOfficialDecisionRecord(decision models.OfficialDecisionValue, reason, comment string) (models.OfficialDecision, error) {
+	reason = strings.TrimSpace(reason)
+	comment = strings.TrimSpace(comment)
+	if decision == models.OfficialDecisionReject && reason == "" {
+		return models.OfficialDecision{}, services.ErrRejectionReasonRequired
+	}
+	if len([]rune(reason)) > maxDecisionTextLength {
+		return models.OfficialDecision{}, fmt.Errorf("reason is too long")
+	}
+	if len([]rune(comment)) > maxDecisionTextLength {
+		return models.OfficialDecision{}, fmt.Errorf("comment is too long")
+	}
+	return models.OfficialDecision{Decision: decision, Reason: reason, Comment: comment}, nil
+}
+
 func buildPublicBaseURL(c *gin.Context) string {
 	if configured := strings.TrimSpace(os.Getenv("FRONTEND_URL")); configured != "" {
 		return strings.TrimRight(configured, "/")
@@ -164,6 +196,25 @@ func mapSignLinkError(err error) (int, string) {
 	}
 }
 
+func mapSavedSignatureError(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrSavedSignatureNotFound):
+		return http.StatusNotFound, "saved signature not found"
+	case errors.Is(err, services.ErrSavedSignatureUnconfirmed), errors.Is(err, services.ErrSavedSignatureNoRecipient), errors.Is(err, services.ErrSavedSignatureRole):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrSavedSignatureSignIn):
+		return http.StatusUnauthorized, err.Error()
+	case errors.Is(err, services.ErrSavedSignatureNotEmailed):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, services.ErrSavedSignatureUnreadable):
+		return http.StatusConflict, "saved signature cannot be read; please save it again"
+	case errors.Is(err, errInvalidSignedVia):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, "failed to load saved signature"
+	}
+}
+
 func mapFormLinkError(err error) (int, string) {
 	switch {
 	case errors.Is(err, services.ErrFormLinkNotFound):
@@ -175,34 +226,63 @@ func mapFormLinkError(err error) (int, string) {
 	}
 }
 
-func hasStudentSignature(record *services.RequestRecord) bool {
-	if record == nil || record.Signatures.Student == nil {
-		return false
+func mapRequestStatusError(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRequestStatus):
+		return http.StatusBadRequest, "invalid status value"
+	case errors.Is(err, services.ErrRejectionReasonRequired):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrIllegalStatusTransition):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound, "request not found"
+	default:
+		return http.StatusInternalServerError, "failed to update status"
+	}
+}
+
+func mapTrackingError(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrTrackingTokenNotFound):
+		return http.StatusNotFound, err.Error()
+	default:
+		return http.StatusInternalServerError, "failed to load request"
+	}
+}
+
+func mapOfficialDecisionError(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotInChain), errors.Is(err, services.ErrRejectionReasonRequired):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrApprovalStepNotOpen), errors.Is(err, services.ErrRequestClosed):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, "failed to save signature"
 	}
-	return strings.TrimSpace(record.Signatures.Student.DataBase64) != ""
 }
 
-func notifyAdminSubmissionAsync(record *services.RequestRecord) {
+func hasStudentSignature(record *services.RequestRecord) bool {
+	return record != nil && record.Signatures.Student.HasImage()
+}
+
+// notifyAdminSubmission queues the submission notice to the account owner. The
+// mailer only enqueues it in the outbox, so it is sent within the request.
+func notifyAdminSubmission(ctx context.Context, officials services.OfficialStore, record *services.RequestRecord) {
 	if record == nil {
 		return
 	}
-
-	snapshot := *record
-	go func(req services.RequestRecord) {
-		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
-		defer cancel()
-		if err := services.SendSubmissionNotificationByRequest(ctx, &req); err != nil {
-			log.Printf("email notification error for id %v: %v", req.ID, err)
-		}
-	}(snapshot)
+	branding := services.LoadEmailBranding(ctx, officials, record.AccountID)
+	if err := services.SendSubmissionNotificationByRequest(ctx, branding, record); err != nil {
+		log.Printf("email notification error for id %v: %v", record.ID, err)
+	}
 }
 
-func notifyOfficialsSigningLinksAsync(requestID primitive.ObjectID, signLinksColl *mongo.Collection, officialsColl *mongo.Collection, publicBaseURL string, accountID string) {
+func notifyOfficialsSigningLinksAsync(stores services.Stores, requestID primitive.ObjectID, publicBaseURL string, accountID string) {
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 
-		results, err := services.CreateAndSendOfficialSignLinks(ctx, signLinksColl, officialsColl, requestID, publicBaseURL, 7, accountID)
+		results, err := services.CreateAndSendOfficialSignLinks(ctx, stores.Requests, stores.SignLinks, stores.Officials, requestID, publicBaseURL, accountID)
 		if err != nil {
 			log.Printf("official sign link dispatch failed for request %s: %v", requestID.Hex(), err)
 			return
@@ -219,8 +299,145 @@ func notifyOfficialsSigningLinksAsync(requestID primitive.ObjectID, signLinksCol
 	}()
 }
 
+// notifyRequesterAsync emails the requester a status update when the request has a requester email.
+func notifyRequesterAsync(stores services.Stores, requestID primitive.ObjectID, accountID string, notice services.RequesterNotice, role models.SignRole, publicBaseURL string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		record, err := services.GetRequestByID(ctx, stores.Requests, requestID, accountID)
+		if err != nil {
+			log.Printf("requester notification skipped for request %s: %v", requestID.Hex(), err)
+			return
+		}
+		branding := services.LoadEmailBranding(ctx, stores.Officials, accountID)
+		if err := services.SendRequesterNotification(ctx, stores.Requests, branding, record, notice, role, publicBaseURL); err != nil {
+			log.Printf("requester notification error for request %s: %v", requestID.Hex(), err)
+		}
+	}()
+}
+
+// notifyRequesterOfDecisionAsync sends the completion notice once the last step approves,
+// otherwise a notice about the decision of role.
+func notifyRequesterOfDecisionAsync(stores services.Stores, requestID primitive.ObjectID, accountID string, role models.SignRole, status models.RequestStatus, publicBaseURL string) {
+	notice := services.RequesterNoticeDecision
+	if status == models.RequestStatusApproved {
+		notice = services.RequesterNoticeCompleted
+	}
+	notifyRequesterAsync(stores, requestID, accountID, notice, role, publicBaseURL)
+}
+
+// renderRequestPDF generates the request PDF with the owning account's officials and school info.
+func renderRequestPDF(ctx context.Context, stores services.Stores, request *services.RequestRecord, publicBaseURL string) ([]byte, error) {
+	return newRequestPDFRenderer(ctx, stores, request.AccountID, publicBaseURL)(ctx, request)
+}
+
+// newRequestPDFRenderer loads the account's officials and school info once and
+// returns a renderer for any of its requests; it is safe for concurrent use.
+func newRequestPDFRenderer(ctx context.Context, stores services.Stores, accountID, publicBaseURL string) services.RequestPDFRenderer {
+	opts := loadPDFOptions(ctx, stores, accountID, publicBaseURL)
+	return func(ctx context.Context, request *services.RequestRecord) ([]byte, error) {
+		services.LoadSignatureImages(ctx, stores.Blobs, request)
+
+		// Generate PDF via service (pass official names and base URL for verification QR)
+		return services.GeneratePDF(request, opts.RegistrarName, opts.DirectorName, opts.SchoolName, opts.SchoolAddress, opts.BaseURL)
+	}
+}
+
+// loadPDFOptions reads the official names and school info printed on the account's PDFs.
+func loadPDFOptions(ctx context.Context, stores services.Stores, accountID, publicBaseURL string) services.BatchPDFOptions {
+	// Try to load official names from DB, fall back to dummy defaults
+	registrarName, directorName, offErr := services.GetOfficialsFromDB(ctx, stores.Officials, accountID)
+	schoolName, schoolAddress, _ := services.GetSchoolInfoFromDB(ctx, stores.Officials, accountID)
+	if offErr != nil || registrarName == "" || directorName == "" {
+		// fallback to env/defaults
+		registrarName, directorName = services.GetOfficials()
+	}
+	return services.BatchPDFOptions{
+		RegistrarName: registrarName,
+		DirectorName:  directorName,
+		SchoolName:    schoolName,
+		SchoolAddress: schoolAddress,
+		BaseURL:       publicBaseURL,
+	}
+}
+
+// pdfSelectionPayload picks the requests of a bulk or batch PDF by ID.
+type pdfSelectionPayload struct {
+	IDs   []string `json:"ids"`
+	Cover bool     `json:"cover"`
+}
+
+// parsePDFSelection reads the optional JSON body and the list filters of a bulk
+// or batch PDF request. It writes a 400 response and returns false on bad input.
+func parsePDFSelection(c *gin.Context) (pdfSelectionPayload, []primitive.ObjectID, services.RequestFilter, bool) {
+	var payload pdfSelectionPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return payload, nil, services.RequestFilter{}, false
+		}
+	}
+	ids := make([]primitive.ObjectID, 0, len(payload.IDs))
+	for _, raw := range payload.IDs {
+		id, err := primitive.ObjectIDFromHex(strings.TrimSpace(raw))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID: " + raw})
+			return payload, nil, services.RequestFilter{}, false
+		}
+		ids = append(ids, id)
+	}
+	filter, err := parseRequestFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return payload, nil, services.RequestFilter{}, false
+	}
+	return payload, ids, filter, true
+}
+
+// respondPDFSelectionError maps a CollectBulkPDFRequests error to a response.
+func respondPDFSelectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTooManyBulkPDFRequests):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoBulkPDFRequests):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch requests"})
+	}
+}
+
+// advanceApprovalChainAsync reacts to an official decision: a rejection revokes the
+// remaining sign links and notifies the owner, otherwise the next open steps get links.
+func advanceApprovalChainAsync(stores services.Stores, requestID primitive.ObjectID, status models.RequestStatus, publicBaseURL string, accountID string) {
+	if status != models.RequestStatusRejected {
+		notifyOfficialsSigningLinksAsync(stores, requestID, publicBaseURL, accountID)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		if _, err := services.RevokeActiveSignLinks(ctx, stores.SignLinks, requestID); err != nil {
+			log.Printf("failed to revoke sign links for rejected request %s: %v", requestID.Hex(), err)
+		}
+
+		record, err := services.GetRequestByID(ctx, stores.Requests, requestID, accountID)
+		if err != nil || record.Rejection == nil {
+			log.Printf("rejection notification skipped for request %s: %v", requestID.Hex(), err)
+			return
+		}
+		roleLabel := services.ApprovalStepLabel(record, record.Rejection.Role)
+		branding := services.LoadEmailBranding(ctx, stores.Officials, accountID)
+		if err := services.SendRequestRejectedNotification(ctx, branding, record, roleLabel); err != nil {
+			log.Printf("rejection notification error for request %s: %v", requestID.Hex(), err)
+		}
+	}()
+}
+
 // RegisterRoutes registers all HTTP routes on the provided gin Engine.
-func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *mongo.Collection, adminColl *mongo.Collection, signLinksColl *mongo.Collection, signSessionsColl *mongo.Collection, formLinksColl *mongo.Collection, auditColl *mongo.Collection, logoutHandlesColl *mongo.Collection) {
+func RegisterRoutes(r *gin.Engine, stores services.Stores, adminService *services.AdminService) {
 	// CORS: allow all origins (no credentials)
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -239,11 +456,12 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 	})
 
 	authSecret := os.Getenv("AUTH_SECRET")
-	requireAuth := RequireSessionAuth(authSecret, logoutHandlesColl)
+	requireAuth := RequireSessionAuth(authSecret, stores.LogoutHandles)
+	optionalAuth := OptionalSessionAuth(authSecret, stores.LogoutHandles)
 	authRateLimiter := newAuthRateLimitMiddleware(120, time.Minute)
 
 	// ─── OIDC Auth routes (no auth middleware required) ───────────────────────
-	authHandler := NewAuthHandler(logoutHandlesColl)
+	authHandler := NewAuthHandler(stores.LogoutHandles)
 	r.GET("/auth/login", authHandler.Login)
 	r.GET("/auth/callback", authHandler.Callback)
 	r.POST("/auth/refresh", authRateLimiter, authHandler.Refresh)
@@ -262,7 +480,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		record, rawToken, err := services.GetOrCreateActiveFormLink(ctx, formLinksColl, accountID)
+		record, rawToken, err := services.GetOrCreateActiveFormLink(ctx, stores.FormLinks, accountID)
 		if err != nil {
 			status, msg := mapFormLinkError(err)
 			c.JSON(status, gin.H{"error": msg})
@@ -304,7 +522,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		record, rawToken, err := services.RotateFormLink(ctx, formLinksColl, accountID)
+		record, rawToken, err := services.RotateFormLink(ctx, stores.FormLinks, accountID)
 		if err != nil {
 			status, msg := mapFormLinkError(err)
 			c.JSON(status, gin.H{"error": msg})
@@ -349,7 +567,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		formLink, err := services.GetFormLinkByRawToken(ctx, formLinksColl, rawToken)
+		formLink, err := services.GetFormLinkByRawToken(ctx, stores.FormLinks, rawToken)
 		if err != nil {
 			status, msg := mapFormLinkError(err)
 			c.JSON(status, gin.H{"error": msg})
@@ -357,32 +575,98 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		}
 
 		studentPayload := models.StudentData{
-			Name:         payload.Name,
-			Prefix:       payload.Prefix,
-			DocumentType: payload.DocumentType,
-			IDCard:       payload.IDCard,
-			StudentID:    payload.StudentID,
-			DateOfBirth:  payload.DateOfBirth,
-			Purpose:      payload.Purpose,
-			AccountID:    formLink.AccountID,
-			Class:        payload.Class,
-			Room:         payload.Room,
-			AcademicYear: payload.AcademicYear,
-			FatherName:   payload.FatherName,
-			MotherName:   payload.MotherName,
-		}
-
-		id, err := services.SaveStudent(ctx, mongoColl, studentPayload)
+			Name:           payload.Name,
+			Prefix:         payload.Prefix,
+			DocumentType:   payload.DocumentType,
+			IDCard:         payload.IDCard,
+			StudentID:      payload.StudentID,
+			DateOfBirth:    payload.DateOfBirth,
+			Purpose:        payload.Purpose,
+			AccountID:      formLink.AccountID,
+			Class:          payload.Class,
+			Room:           payload.Room,
+			AcademicYear:   payload.AcademicYear,
+			FatherName:     payload.FatherName,
+			MotherName:     payload.MotherName,
+			RequesterEmail: strings.TrimSpace(payload.RequesterEmail),
+		}
+
+		steps, err := services.GetApprovalChainSteps(ctx, stores.ApprovalChains, stores.Officials, formLink.AccountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load approval chain"})
+			return
+		}
+
+		id, err := services.SaveStudent(ctx, stores.Requests, studentPayload, steps)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save data"})
 			return
 		}
 
-		if err := services.TouchFormLinkUsed(ctx, formLinksColl, formLink.ID); err != nil {
+		if _, err := services.RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, c.ClientIP(), c.GetHeader("User-Agent"), formLink.AccountID); err != nil {
+			log.Printf("failed to advance status for request %s: %v", id.Hex(), err)
+		}
+
+		if err := services.TouchFormLinkUsed(ctx, stores.FormLinks, formLink.ID); err != nil {
 			log.Printf("failed to update form link last used timestamp: %v", err)
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "data saved", "id": id})
+		response := gin.H{"message": "data saved", "id": id}
+		trackingToken, err := services.CreateTrackingToken(ctx, stores.Requests, id, formLink.AccountID)
+		if err != nil {
+			log.Printf("failed to issue tracking token for request %s: %v", id.Hex(), err)
+		} else {
+			response["tracking_token"] = trackingToken
+			response["tracking_url"] = services.TrackingURL(buildPublicBaseURL(c), trackingToken)
+			notifyRequesterAsync(stores, id, formLink.AccountID, services.RequesterNoticeSubmitted, models.SignRoleStudent, buildPublicBaseURL(c))
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	// GET /api/track/:token - public requester status page; never exposes id_card
+	r.GET("/api/track/:token", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, err := services.GetRequestByTrackingToken(ctx, stores.Requests, strings.TrimSpace(c.Param("token")))
+		if err != nil {
+			status, msg := mapTrackingError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		c.JSON(http.StatusOK, services.BuildTrackingView(record))
+	})
+
+	// GET /api/track/:token/pdf - download the final PDF once the request is approved
+	r.GET("/api/track/:token/pdf", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		record, err := services.GetRequestByTrackingToken(ctx, stores.Requests, strings.TrimSpace(c.Param("token")))
+		if err != nil {
+			status, msg := mapTrackingError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if !services.IsFinalPDFAvailable(services.CurrentRequestStatus(record)) {
+			c.JSON(http.StatusConflict, gin.H{"error": "document is not approved yet"})
+			return
+		}
+
+		pdfBytes, err := renderRequestPDF(ctx, stores, record, buildPublicBaseURL(c))
+		if err != nil {
+			log.Printf("pdf generation error for tracked request %v: %v", record.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", "attachment; filename=document.pdf")
+		if _, werr := c.Writer.Write(pdfBytes); werr != nil {
+			log.Printf("failed to write tracked PDF response: %v", werr)
+		}
 	})
 
 	// GET /api/stats - return total count, counts by year and by month
@@ -396,7 +680,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 		defer cancel()
 
-		stats, err := services.GetStats(ctx, mongoColl, accountID)
+		stats, err := services.GetStats(ctx, stores.Requests, accountID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to aggregate stats"})
 			return
@@ -428,8 +712,8 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		var rawRequest models.StudentData
-		if err := mongoColl.FindOne(ctx, bson.M{"_id": objectID}).Decode(&rawRequest); err != nil {
+		rawRequest, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, objectID)
+		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 			return
 		}
@@ -440,131 +724,383 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			return
 		}
 
-		requestBefore, err := services.GetRequestByID(ctx, mongoColl, objectID, accountID)
+		requestBefore, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 			return
 		}
 		hadStudentSignature := hasStudentSignature(requestBefore)
 
-		if err := services.UpsertSignature(ctx, mongoColl, auditColl, objectID, models.SignRoleStudent, sig, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
+		if err := services.UpsertSignature(ctx, stores.Requests, stores.Audit, objectID, models.SignRoleStudent, sig, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save signature"})
 			return
 		}
+		if _, err := services.RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, objectID, models.SignRoleStudent, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
+			status, msg := mapRequestStatusError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
 
 		if !hadStudentSignature {
-			notifyAdminSubmissionAsync(requestBefore)
-			notifyOfficialsSigningLinksAsync(objectID, signLinksColl, officialsColl, buildPublicBaseURL(c), accountID)
+			notifyAdminSubmission(ctx, stores.Officials, requestBefore)
+			notifyOfficialsSigningLinksAsync(stores, objectID, buildPublicBaseURL(c), accountID)
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "student signature saved"})
 	})
 
-	// POST /api/requests/:id/sign-links - create official sign link from admin workflow
-	r.POST("/api/requests/:id/sign-links", requireAuth, func(c *gin.Context) {
+	// issueSignLink creates an official sign link from the admin workflow. With reissue
+	// set, the active links of the role are revoked before the new one is issued.
+	issueSignLink := func(reissue bool) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			accountID := accountIDFromContext(c)
+			if accountID == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+				return
+			}
+
+			idStr := c.Param("id")
+			objectID, err := primitive.ObjectIDFromHex(idStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+				return
+			}
+
+			var payload struct {
+				Role           string `json:"role" binding:"required"`
+				Channel        string `json:"channel" binding:"required,oneof=email copy"`
+				RecipientEmail string `json:"recipient_email"`
+			}
+			if err := c.ShouldBindJSON(&payload); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign link payload"})
+				return
+			}
+
+			role := models.SignRole(strings.TrimSpace(payload.Role))
+			recipientEmail := strings.TrimSpace(payload.RecipientEmail)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+			defer cancel()
+
+			request, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+				return
+			}
+			step, ok := services.FindApprovalStep(services.RequestApprovalSteps(request), role)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "role is not part of this request's approval chain"})
+				return
+			}
+
+			if payload.Channel == "email" && recipientEmail == "" {
+				recipientEmail = services.ApprovalStepSignerEmail(ctx, stores.Officials, step, accountID)
+				if recipientEmail == "" {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "recipient email is required for email channel"})
+					return
+				}
+			}
+
+			settings := services.LoadSigningSettings(ctx, stores.Officials, accountID)
+			record, rawToken, err := services.CreateSignLink(ctx, stores.SignLinks, objectID, role, payload.Channel, recipientEmail, buildPublicBaseURL(c), settings)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create sign link"})
+				return
+			}
+
+			// the new link exists before the old ones go, so the role is never left without one
+			var warnings []string
+			revoked := 0
+			if reissue {
+				revoked, err = services.RevokeActiveRoleSignLinks(ctx, stores.SignLinks, objectID, role, record.ID)
+				if err != nil {
+					log.Printf("failed to revoke sign links for request %s role %s: %v", objectID.Hex(), role, err)
+					warnings = append(warnings, "failed to revoke previous sign links")
+				}
+			}
+
+			signURL := services.SignLinkURL(buildPublicBaseURL(c), rawToken)
+			emailSent := false
+			if payload.Channel == "email" {
+				branding := services.LoadEmailBranding(ctx, stores.Officials, accountID)
+				err = services.SendOfficialSignLink(ctx, branding, request, step.Label, recipientEmail, signURL, record.ExpiresAt)
+				if err != nil {
+					warnings = append(warnings, err.Error())
+				} else {
+					emailSent = true
+					_ = services.TouchSignLinkSent(ctx, stores.SignLinks, record.ID)
+				}
+			}
+
+			message := "sign link created"
+			if reissue {
+				message = "sign link reissued"
+			}
+			response := gin.H{
+				"message":         message,
+				"revoked":         revoked,
+				"role":            role,
+				"channel":         payload.Channel,
+				"recipient_email": recipientEmail,
+				"expires_at":      record.ExpiresAt,
+				"email_sent":      emailSent,
+				"warning":         strings.Join(warnings, "; "),
+			}
+			// an emailed link reaches only its recipient; the account never sees it
+			if payload.Channel != "email" {
+				response["sign_url"] = signURL
+				response["token"] = rawToken
+			}
+			c.JSON(http.StatusOK, response)
+		}
+	}
+
+	// POST /api/requests/:id/sign-links - create an official sign link; existing links stay active
+	r.POST("/api/requests/:id/sign-links", requireAuth, issueSignLink(false))
+
+	// POST /api/requests/:id/sign-links/reissue - revoke the role's active links and issue a fresh one
+	r.POST("/api/requests/:id/sign-links/reissue", requireAuth, issueSignLink(true))
+
+	// GET /api/requests/:id/sign-links - every sign link of a request with its state
+	r.GET("/api/requests/:id/sign-links", requireAuth, func(c *gin.Context) {
 		accountID := accountIDFromContext(c)
 		if accountID == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
 			return
 		}
 
-		idStr := c.Param("id")
-		objectID, err := primitive.ObjectIDFromHex(idStr)
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
 			return
 		}
 
-		var payload struct {
-			Role           string `json:"role" binding:"required,oneof=registrar director"`
-			Channel        string `json:"channel" binding:"required,oneof=email copy"`
-			RecipientEmail string `json:"recipient_email"`
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
 		}
-		if err := c.ShouldBindJSON(&payload); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign link payload"})
+		links, err := services.SummarizeSignLinks(ctx, stores.SignLinks, objectID)
+		if err != nil {
+			log.Printf("failed to list sign links for request %s: %v", objectID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sign links"})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"sign_links": links})
+	})
 
-		role := models.SignRole(payload.Role)
-		recipientEmail := strings.TrimSpace(payload.RecipientEmail)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
-		defer cancel()
-
-		if payload.Channel == "email" && recipientEmail == "" {
-			registrarEmail, directorEmail, _ := services.GetOfficialEmailsFromDB(ctx, officialsColl, accountID)
-			if role == models.SignRoleRegistrar {
-				recipientEmail = strings.TrimSpace(registrarEmail)
-			} else {
-				recipientEmail = strings.TrimSpace(directorEmail)
-			}
-			if recipientEmail == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "recipient email is required for email channel"})
-				return
-			}
+	// POST /api/requests/:id/sign-links/:linkId/revoke - revoke one sign link of a request
+	r.POST("/api/requests/:id/sign-links/:linkId/revoke", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
 		}
 
-		record, rawToken, err := services.CreateSignLink(ctx, signLinksColl, objectID, role, payload.Channel, recipientEmail, 7)
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create sign link"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
 			return
 		}
-
-		signURL := fmt.Sprintf("%s/sign/%s", buildPublicBaseURL(c), rawToken)
-		emailSent := false
-		var warning string
-		if payload.Channel == "email" {
-			err = services.SendOfficialSignLink(ctx, payload.Role, recipientEmail, signURL, objectID.Hex())
-			if err != nil {
-				warning = err.Error()
-			} else {
-				emailSent = true
-				_ = services.TouchSignLinkSent(ctx, signLinksColl, record.ID)
-			}
+		linkID, err := primitive.ObjectIDFromHex(c.Param("linkId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign link ID"})
+			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message":         "sign link created",
-			"sign_url":        signURL,
-			"token":           rawToken,
-			"role":            role,
-			"channel":         payload.Channel,
-			"recipient_email": recipientEmail,
-			"expires_at":      record.ExpiresAt,
-			"email_sent":      emailSent,
-			"warning":         warning,
-		})
-	})
-
-	// GET /api/sign-links/:token - verify token and return signing metadata
-	r.GET("/api/sign-links/:token", func(c *gin.Context) {
-		rawToken := c.Param("token")
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		record, err := services.GetSignLinkByRawToken(ctx, signLinksColl, rawToken)
+		if _, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
+		}
+		link, err := services.RevokeSignLink(ctx, stores.SignLinks, objectID, linkID)
 		if err != nil {
 			status, msg := mapSignLinkError(err)
 			c.JSON(status, gin.H{"error": msg})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"message": "sign link revoked", "sign_link": link})
+	})
 
-		validationErr := services.ValidateSignLink(record)
-		active := validationErr == nil
-
-		// For sign-links verifying, we must fetch the request.
-		// Since we don't have accountID here, and GetRequestByID requires it,
-		// we should fetch the request without it here, or use the sign link's relation.
-		// Let's create a GetRequestByIDWithoutAccountID or use a direct query.
-		var request models.StudentData // We'll just read it directly since it's a public read context
-		err = mongoColl.FindOne(ctx, bson.M{"_id": record.RequestID}).Decode(&request)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+	// GET /api/saved-signatures - list the officials' saved signatures of the account, without images
+	r.GET("/api/saved-signatures", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
 			return
 		}
 
-		response := gin.H{
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		saved, err := services.ListSavedSignatures(ctx, stores.Signatures, accountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load saved signatures"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"saved_signatures": saved})
+	})
+
+	// DELETE /api/saved-signatures/:id - remove an official's saved signature from the account
+	r.DELETE("/api/saved-signatures/:id", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid saved signature ID"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := services.DeleteSavedSignature(ctx, stores.Signatures, objectID, accountID); err != nil {
+			status, msg := mapSavedSignatureError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "saved signature deleted"})
+	})
+
+	// GET /api/official/inbox - requests awaiting the signed-in official's decision
+	r.GET("/api/official/inbox", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		items, err := services.ListOfficialInbox(ctx, stores.Requests, stores.SignLinks, officialEmailsFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load inbox"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"requests": items})
+	})
+
+	// POST /api/official/bulk-sign - apply one signature and decision to several inbox requests
+	r.POST("/api/official/bulk-sign", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		var payload struct {
+			RequestIDs []string `json:"request_ids" binding:"required"`
+			officialSignatureUpdatePayload
+		}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signature payload"})
+			return
+		}
+		if payload.UseSavedSignature {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "saved signatures cannot be used for bulk signing"})
+			return
+		}
+		if len(payload.RequestIDs) == 0 || len(payload.RequestIDs) > services.MaxBulkSignRequests {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("request_ids must list 1 to %d requests", services.MaxBulkSignRequests)})
+			return
+		}
+		requestIDs := make([]primitive.ObjectID, 0, len(payload.RequestIDs))
+		for _, raw := range payload.RequestIDs {
+			objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(raw))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+				return
+			}
+			requestIDs = append(requestIDs, objectID)
+		}
+
+		sig, err := toSignatureBlock(signatureUpdatePayload{
+			DataBase64: payload.DataBase64,
+			Method:     payload.Method,
+			SignedVia:  payload.SignedVia,
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		decisionValue, err := toOfficialDecision(payload.Decision)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		decision, err := toOfficialDecisionRecord(decisionValue, payload.Reason, payload.Comment)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		results, err := services.BulkSignAsOfficial(ctx, stores.Requests, stores.Audit, stores.SignLinks, officialEmailsFromContext(c), requestIDs, sig, decision, c.ClientIP(), c.GetHeader("User-Agent"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign requests"})
+			return
+		}
+
+		publicBaseURL := buildPublicBaseURL(c)
+		items := make([]gin.H, 0, len(results))
+		signed := 0
+		for _, result := range results {
+			item := gin.H{"request_id": result.RequestID.Hex()}
+			if result.Err != nil {
+				msg := result.Err.Error()
+				if !errors.Is(result.Err, services.ErrNoPendingSignLink) {
+					_, msg = mapOfficialDecisionError(result.Err)
+				}
+				item["error"] = msg
+				items = append(items, item)
+				continue
+			}
+			signed++
+			item["status"] = result.Status
+			items = append(items, item)
+			advanceApprovalChainAsync(stores, result.RequestID, result.Status, publicBaseURL, result.AccountID)
+			notifyRequesterOfDecisionAsync(stores, result.RequestID, result.AccountID, result.Role, result.Status, publicBaseURL)
+		}
+		c.JSON(http.StatusOK, gin.H{"signed": signed, "failed": len(results) - signed, "results": items})
+	})
+
+	// GET /api/sign-links/:token - verify token and return signing metadata
+	r.GET("/api/sign-links/:token", optionalAuth, func(c *gin.Context) {
+		rawToken := c.Param("token")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, err := services.GetSignLinkByRawToken(ctx, stores.SignLinks, rawToken)
+		if err != nil {
+			status, msg := mapSignLinkError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		validationErr := services.ValidateSignLink(record)
+		active := validationErr == nil
+
+		// The sign link token is the authority here, so read the request without account scoping.
+		request, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, record.RequestID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
+		}
+
+		response := gin.H{
 			"role":       record.Role,
+			"role_label": services.ApprovalStepLabel(request, record.Role),
 			"request_id": record.RequestID.Hex(),
 			"expires_at": record.ExpiresAt,
 			"used_at":    record.UsedAt,
@@ -590,12 +1126,111 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			_, msg := mapSignLinkError(validationErr)
 			response["status_message"] = msg
 		}
+		if active && services.AuthorizeSavedSignatureLink(record, officialEmailsFromContext(c)) == nil {
+			saved, err := services.FindSavedSignature(ctx, stores.Signatures, request.AccountID, record.RecipientEmail, record.Role)
+			if err == nil {
+				response["saved_signature"] = saved
+			} else if !errors.Is(err, services.ErrSavedSignatureNotFound) {
+				log.Printf("failed to look up saved signature: %v", err)
+			}
+		}
 
 		c.JSON(http.StatusOK, response)
 	})
 
+	// activeSignLinkSigner resolves an active official sign link and the account of
+	// its request for the saved signature endpoints, writing the error response on
+	// failure. The caller must be signed in as the link's recipient.
+	activeSignLinkSigner := func(ctx context.Context, c *gin.Context) (*models.SignLink, string, bool) {
+		record, err := services.GetSignLinkByRawToken(ctx, stores.SignLinks, c.Param("token"))
+		if err == nil {
+			err = services.ValidateSignLink(record)
+		}
+		if err != nil {
+			status, msg := mapSignLinkError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return nil, "", false
+		}
+		if err := services.AuthorizeSavedSignatureLink(record, officialEmailsFromContext(c)); err != nil {
+			status, msg := mapSavedSignatureError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return nil, "", false
+		}
+		request, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, record.RequestID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return nil, "", false
+		}
+		return record, request.AccountID, true
+	}
+
+	// GET /api/sign-links/:token/saved-signature - show the official's saved signature for confirmation
+	r.GET("/api/sign-links/:token/saved-signature", requireAuth, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, accountID, ok := activeSignLinkSigner(ctx, c)
+		if !ok {
+			return
+		}
+		saved, dataURL, err := services.OpenSavedSignature(ctx, stores.Signatures, accountID, record.RecipientEmail, record.Role)
+		if err != nil {
+			status, msg := mapSavedSignatureError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		setNoStoreHeaders(c)
+		c.JSON(http.StatusOK, gin.H{"saved_signature": saved, "data_base64": dataURL})
+	})
+
+	// PUT /api/sign-links/:token/saved-signature - save the official's signature for reuse
+	r.PUT("/api/sign-links/:token/saved-signature", requireAuth, func(c *gin.Context) {
+		var payload signatureUpdatePayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signature payload"})
+			return
+		}
+		sig, err := toSignatureBlock(payload)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, accountID, ok := activeSignLinkSigner(ctx, c)
+		if !ok {
+			return
+		}
+		saved, err := services.SaveOfficialSignature(ctx, stores.Signatures, accountID, record.RecipientEmail, record.Role, sig)
+		if err != nil {
+			status, msg := mapSavedSignatureError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "signature saved for reuse", "saved_signature": saved})
+	})
+
+	// DELETE /api/sign-links/:token/saved-signature - remove the official's saved signature
+	r.DELETE("/api/sign-links/:token/saved-signature", requireAuth, func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		record, accountID, ok := activeSignLinkSigner(ctx, c)
+		if !ok {
+			return
+		}
+		if err := services.DeleteOfficialSavedSignature(ctx, stores.Signatures, accountID, record.RecipientEmail, record.Role); err != nil {
+			status, msg := mapSavedSignatureError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "saved signature deleted"})
+	})
+
 	// POST /api/sign-links/:token/sign - submit official signature via tokenized link
-	r.POST("/api/sign-links/:token/sign", func(c *gin.Context) {
+	r.POST("/api/sign-links/:token/sign", optionalAuth, func(c *gin.Context) {
 		rawToken := c.Param("token")
 
 		var payload officialSignatureUpdatePayload
@@ -604,17 +1239,26 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			return
 		}
 
-		sig, err := toSignatureBlock(signatureUpdatePayload{
-			DataBase64: payload.DataBase64,
-			Method:     payload.Method,
-			SignedVia:  payload.SignedVia,
-		})
+		var sig models.SignatureBlock
+		var err error
+		if !payload.UseSavedSignature {
+			sig, err = toSignatureBlock(signatureUpdatePayload{
+				DataBase64: payload.DataBase64,
+				Method:     payload.Method,
+				SignedVia:  payload.SignedVia,
+			})
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		decisionValue, err := toOfficialDecision(payload.Decision)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		decision, err := toOfficialDecision(payload.Decision)
+		decision, err := toOfficialDecisionRecord(decisionValue, payload.Reason, payload.Comment)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -623,7 +1267,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 		defer cancel()
 
-		record, err := services.GetSignLinkByRawToken(ctx, signLinksColl, rawToken)
+		record, err := services.GetSignLinkByRawToken(ctx, stores.SignLinks, rawToken)
 		if err != nil {
 			status, msg := mapSignLinkError(err)
 			c.JSON(status, gin.H{"error": msg})
@@ -636,28 +1280,42 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			return
 		}
 
-		if record.Role != models.SignRoleRegistrar && record.Role != models.SignRoleDirector {
+		if !models.IsValidApprovalRole(record.Role) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sign link role"})
 			return
 		}
 
-		var req models.StudentData
-		if err := mongoColl.FindOne(ctx, bson.M{"_id": record.RequestID}).Decode(&req); err != nil {
+		req, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, record.RequestID)
+		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 			return
 		}
 
-		if err := services.UpsertOfficialDecisionAndSignature(ctx, mongoColl, auditColl, record.RequestID, record.Role, sig, decision, c.ClientIP(), c.GetHeader("User-Agent"), req.AccountID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save signature"})
+		if payload.UseSavedSignature {
+			sig, err = savedSignatureForLink(ctx, stores.Signatures, record, officialEmailsFromContext(c), req.AccountID, payload.SignedVia, payload.ConfirmSavedSignature)
+			if err != nil {
+				status, msg := mapSavedSignatureError(err)
+				c.JSON(status, gin.H{"error": msg})
+				return
+			}
+		}
+
+		if err := services.UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, record.RequestID, record.Role, sig, decision, c.ClientIP(), c.GetHeader("User-Agent"), req.AccountID); err != nil {
+			status, msg := mapOfficialDecisionError(err)
+			c.JSON(status, gin.H{"error": msg})
 			return
 		}
-		if _, err := services.RecomputeStatusFromOfficialDecisions(ctx, mongoColl, record.RequestID, req.AccountID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+		nextStatus, err := services.RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, record.RequestID, record.Role, c.ClientIP(), c.GetHeader("User-Agent"), req.AccountID)
+		if err != nil {
+			status, msg := mapRequestStatusError(err)
+			c.JSON(status, gin.H{"error": msg})
 			return
 		}
-		if err := services.MarkSignLinkUsed(ctx, signLinksColl, record.ID); err != nil {
+		if err := services.MarkSignLinkUsed(ctx, stores.SignLinks, record.ID); err != nil {
 			log.Printf("failed to mark sign link used: %v", err)
 		}
+		advanceApprovalChainAsync(stores, record.RequestID, nextStatus, buildPublicBaseURL(c), req.AccountID)
+		notifyRequesterOfDecisionAsync(stores, record.RequestID, req.AccountID, record.Role, nextStatus, buildPublicBaseURL(c))
 
 		c.JSON(http.StatusOK, gin.H{"message": "signature saved"})
 	})
@@ -685,7 +1343,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		var err error
 
 		if strings.TrimSpace(payload.Token) != "" {
-			record, err := services.GetSignLinkByRawToken(ctx, signLinksColl, payload.Token)
+			record, err := services.GetSignLinkByRawToken(ctx, stores.SignLinks, payload.Token)
 			if err != nil {
 				status, msg := mapSignLinkError(err)
 				c.JSON(status, gin.H{"error": msg})
@@ -699,7 +1357,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			requestID = record.RequestID
 			role = record.Role
 			signLinkID = &record.ID
-			if role == models.SignRoleRegistrar || role == models.SignRoleDirector {
+			if role != models.SignRoleStudent {
 				decision, err = toOfficialDecision(payload.Decision)
 				if err != nil {
 					c.JSON(http.StatusBadRequest, gin.H{"error": "decision is required for official QR signing"})
@@ -724,11 +1382,12 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			role = models.SignRoleStudent
 		}
 
+		settings := services.SigningSettingsForRequest(ctx, stores.Requests, stores.Officials, requestID)
 		var session *models.SignSession
 		if decision != "" {
-			session, err = services.CreateDecisionSignSession(ctx, signSessionsColl, requestID, role, decision, signLinkID, 10*time.Minute)
+			session, err = services.CreateDecisionSignSession(ctx, stores.SignSessions, requestID, role, decision, signLinkID, settings)
 		} else {
-			session, err = services.CreateSignSession(ctx, signSessionsColl, requestID, role, signLinkID, 10*time.Minute)
+			session, err = services.CreateSignSession(ctx, stores.SignSessions, requestID, role, signLinkID, settings)
 		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create sign session"})
@@ -752,7 +1411,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		session, err := services.GetSignSessionByID(ctx, signSessionsColl, sessionID)
+		session, err := services.GetSignSessionByID(ctx, stores.SignSessions, sessionID)
 		if err != nil {
 			if errors.Is(err, services.ErrSignSessionNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "sign session not found"})
@@ -773,7 +1432,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 	})
 
 	// POST /api/sign-sessions/:id/complete - mobile client completes signature for one session
-	r.POST("/api/sign-sessions/:id/complete", func(c *gin.Context) {
+	r.POST("/api/sign-sessions/:id/complete", optionalAuth, func(c *gin.Context) {
 		sessionID := c.Param("id")
 
 		var payload signSessionCompletePayload
@@ -782,21 +1441,25 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			return
 		}
 
-		sig, err := toSignatureBlock(signatureUpdatePayload{
-			DataBase64: payload.DataBase64,
-			Method:     payload.Method,
-			SignedVia:  payload.SignedVia,
-		})
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+		var sig models.SignatureBlock
+		var err error
+		if !payload.UseSavedSignature {
+			sig, err = toSignatureBlock(signatureUpdatePayload{
+				DataBase64: payload.DataBase64,
+				Method:     payload.Method,
+				SignedVia:  payload.SignedVia,
+			})
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			sig.SignedVia = "qr-mobile"
 		}
-		sig.SignedVia = "qr-mobile"
 
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 		defer cancel()
 
-		session, err := services.GetSignSessionByID(ctx, signSessionsColl, sessionID)
+		session, err := services.GetSignSessionByID(ctx, stores.SignSessions, sessionID)
 		if err != nil {
 			if errors.Is(err, services.ErrSignSessionNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "sign session not found"})
@@ -820,17 +1483,20 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		var accountID string
 
 		// To use GetRequestByID we need accountID. But for session complete, we only have sessionID.
-		// So we query the request directly without accountID, or use the signLink.
-		// Actually, let's just fetch the raw request to get its account_id.
-		var rawRequest models.StudentData
-		if err := mongoColl.FindOne(ctx, bson.M{"_id": session.RequestID}).Decode(&rawRequest); err != nil {
+		// The session is the authority here, so read the request unscoped to get its account_id.
+		rawRequest, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, session.RequestID)
+		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 			return
 		}
 		accountID = rawRequest.AccountID
 
 		if session.Role == models.SignRoleStudent {
-			requestBefore, err = services.GetRequestByID(ctx, mongoColl, session.RequestID, accountID)
+			if payload.UseSavedSignature {
+				c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrSavedSignatureRole.Error()})
+				return
+			}
+			requestBefore, err = services.GetRequestByID(ctx, stores.Requests, session.RequestID, accountID)
 			if err != nil {
 				c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 				return
@@ -838,50 +1504,79 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			hadStudentSignature = hasStudentSignature(requestBefore)
 		}
 
-		if session.Role == models.SignRoleRegistrar || session.Role == models.SignRoleDirector {
+		if session.Role != models.SignRoleStudent {
 			decisionInput := strings.TrimSpace(payload.Decision)
 			if decisionInput == "" {
 				decisionInput = string(session.Decision)
 			}
-			decision, decisionErr := toOfficialDecision(decisionInput)
+			decisionValue, decisionErr := toOfficialDecision(decisionInput)
 			if decisionErr != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "decision is required for official signing"})
 				return
 			}
-
-			if err := services.UpsertOfficialDecisionAndSignature(ctx, mongoColl, auditColl, session.RequestID, session.Role, sig, decision, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save signature"})
+			decision, decisionErr := toOfficialDecisionRecord(decisionValue, payload.Reason, payload.Comment)
+			if decisionErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": decisionErr.Error()})
 				return
 			}
-			if _, err := services.RecomputeStatusFromOfficialDecisions(ctx, mongoColl, session.RequestID, accountID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+			if payload.UseSavedSignature {
+				var link *models.SignLink
+				if session.SignLinkID != nil {
+					link, err = stores.SignLinks.FindByID(ctx, *session.SignLinkID)
+					if err != nil && !errors.Is(err, services.ErrNotFound) {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read sign link"})
+						return
+					}
+				}
+				sig, err = savedSignatureForLink(ctx, stores.Signatures, link, officialEmailsFromContext(c), accountID, "qr-mobile", payload.ConfirmSavedSignature)
+				if err != nil {
+					status, msg := mapSavedSignatureError(err)
+					c.JSON(status, gin.H{"error": msg})
+					return
+				}
+			}
+
+			if err := services.UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, session.RequestID, session.Role, sig, decision, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
+				status, msg := mapOfficialDecisionError(err)
+				c.JSON(status, gin.H{"error": msg})
 				return
 			}
 		} else {
-			if err := services.UpsertSignature(ctx, mongoColl, auditColl, session.RequestID, session.Role, sig, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
+			if err := services.UpsertSignature(ctx, stores.Requests, stores.Audit, session.RequestID, session.Role, sig, c.ClientIP(), c.GetHeader("User-Agent"), accountID); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save signature"})
 				return
 			}
 		}
+		nextStatus, err := services.RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, session.RequestID, session.Role, c.ClientIP(), c.GetHeader("User-Agent"), accountID)
+		if err != nil {
+			status, msg := mapRequestStatusError(err)
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
 		if session.SignLinkID != nil {
-			if err := services.MarkSignLinkUsed(ctx, signLinksColl, *session.SignLinkID); err != nil {
+			if err := services.MarkSignLinkUsed(ctx, stores.SignLinks, *session.SignLinkID); err != nil {
 				log.Printf("failed to mark sign link used from session: %v", err)
 			}
 		}
-		if err := services.CompleteSignSession(ctx, signSessionsColl, session.ID); err != nil {
+		if err := services.CompleteSignSession(ctx, stores.SignSessions, session.ID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to complete sign session"})
 			return
 		}
 
 		if session.Role == models.SignRoleStudent && !hadStudentSignature {
-			notifyAdminSubmissionAsync(requestBefore)
-			notifyOfficialsSigningLinksAsync(session.RequestID, signLinksColl, officialsColl, buildPublicBaseURL(c), accountID)
+			notifyAdminSubmission(ctx, stores.Officials, requestBefore)
+			notifyOfficialsSigningLinksAsync(stores, session.RequestID, buildPublicBaseURL(c), accountID)
+		} else if session.Role != models.SignRoleStudent {
+			advanceApprovalChainAsync(stores, session.RequestID, nextStatus, buildPublicBaseURL(c), accountID)
+			notifyRequesterOfDecisionAsync(stores, session.RequestID, accountID, session.Role, nextStatus, buildPublicBaseURL(c))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "signature saved", "session_id": session.ID})
 	})
 
-	// GET /api/requests - return paginated list of student requests
+	// GET /api/requests - paginated request summaries with optional filters and sorting;
+	// fields= replaces the summary columns and include= adds to them (e.g. include=signatures);
+	// page/limit paginates with a total count, cursor= paginates by keyset instead
 	r.GET("/api/requests", requireAuth, func(c *gin.Context) {
 		accountID := accountIDFromContext(c)
 		if accountID == "" {
@@ -903,17 +1598,63 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 			limit = 20
 		}
 
+		filter, err := parseRequestFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sortField, sortAsc, err := parseRequestSort(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fields, err := parseRequestFields(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 		defer cancel()
 
-		requests, total, err := services.GetRequests(ctx, mongoColl, page, limit, accountID)
+		query := services.RequestListQuery{
+			AccountID: accountID,
+			Filter:    filter,
+			SortField: sortField,
+			SortAsc:   sortAsc,
+			Page:      page,
+			Limit:     limit,
+			Fields:    fields,
+		}
+
+		// cursor= (empty for the first page) switches to keyset pagination on
+		// (created_at, _id): no skipping and no total count
+		if cursor, ok := c.GetQuery("cursor"); ok {
+			requests, next, err := services.GetRequestsByCursor(ctx, stores.Requests, query, cursor)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrCursorSort) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch requests"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"requests":    services.ProjectRequests(requests, fields),
+				"limit":       limit,
+				"next_cursor": next,
+			})
+			return
+		}
+
+		requests, total, err := services.GetRequests(ctx, stores.Requests, query)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch requests"})
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"requests": requests,
+			"requests": services.ProjectRequests(requests, fields),
 			"total":    total,
 			"page":     page,
 			"limit":    limit,
@@ -921,44 +1662,204 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		})
 	})
 
-	// GET /api/pdf/:id - generate PDF for a specific request
-	r.GET("/api/pdf/:id", requireAuth, func(c *gin.Context) {
+	// GET /api/requests/export - stream the filtered requests as CSV or XLSX; takes the
+	// list filters plus format=csv|xlsx, columns=a,b,c and order=asc|desc (by created_at)
+	r.GET("/api/requests/export", requireAuth, func(c *gin.Context) {
 		accountID := accountIDFromContext(c)
 		if accountID == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
 			return
 		}
 
-		idStr := c.Param("id")
-
-		// Convert string ID to ObjectID
-		objectID, err := primitive.ObjectIDFromHex(idStr)
+		format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", services.ExportFormatCSV)))
+		contentType := "text/csv; charset=utf-8"
+		switch format {
+		case services.ExportFormatCSV:
+		case services.ExportFormatXLSX:
+			contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnsupportedExportFormat.Error()})
+			return
+		}
+		columns, err := services.ResolveExportColumns(splitQueryList(c.Query("columns")))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		filter, err := parseRequestFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sortField, sortAsc, err := parseRequestSort(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if sortField != "created_at" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "export only supports sort=created_at"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 
-		// Get the specific request
-		request, err := services.GetRequestByID(ctx, mongoColl, objectID, accountID)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=requests-%s.%s", time.Now().Format("20060102"), format))
+		written, err := services.ExportRequests(ctx, stores.Requests, services.RequestListQuery{
+			AccountID: accountID,
+			Filter:    filter,
+			SortAsc:   sortAsc,
+		}, columns, format, c.Writer)
+		if err != nil {
+			log.Printf("request export failed for account %s after %d rows: %v", accountID, written, err)
+			if !c.Writer.Written() {
+				c.Writer.Header().Del("Content-Type")
+				c.Writer.Header().Del("Content-Disposition")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export requests"})
+			}
+		}
+	})
+
+	// GET /api/requests/:id - full request record, signature images included
+	r.GET("/api/requests/:id", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		request, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch request"})
+			return
+		}
+		services.LoadSignatureImages(ctx, stores.Blobs, request)
+
+		c.JSON(http.StatusOK, request)
+	})
+
+	// GET /api/requests/:id/audit-logs - audit trail of a request, oldest first, paginated by cursor
+	r.GET("/api/requests/:id/audit-logs", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			limit = 50
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+		defer cancel()
+
+		logs, next, err := services.ListRequestAuditLogs(ctx, stores.Requests, stores.Audit, objectID, accountID, c.Query("cursor"), limit)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit logs"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"audit_logs": logs, "limit": limit, "next_cursor": next})
+	})
+
+	// GET /api/requests/:id/signatures/:role - serve one signature image of a request
+	r.GET("/api/requests/:id/signatures/:role", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		request, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID)
 		if err != nil {
-			log.Printf("request not found for id %s: %v", idStr, err)
 			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
 			return
 		}
+		sig := request.Signatures.ForRole(models.SignRole(c.Param("role")))
+		data, contentType, err := services.SignatureImage(ctx, stores.Blobs, sig)
+		if err != nil {
+			if errors.Is(err, services.ErrSignatureImageNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "signature not found"})
+				return
+			}
+			log.Printf("failed to read signature image for request %s: %v", objectID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read signature"})
+			return
+		}
+		if sig.ContentHash != "" {
+			c.Header("ETag", `"`+sig.ContentHash+`"`)
+		}
+		c.Header("Cache-Control", "private, max-age=86400")
+		c.Data(http.StatusOK, contentType, data)
+	})
 
-		// Try to load official names from DB, fall back to dummy defaults
-		registrarName, directorName, offErr := services.GetOfficialsFromDB(ctx, officialsColl, accountID)
-		schoolName, schoolAddress, _ := services.GetSchoolInfoFromDB(ctx, officialsColl, accountID)
-		if offErr != nil || registrarName == "" || directorName == "" {
-			// fallback to env/defaults
-			registrarName, directorName = services.GetOfficials()
+	// GET /api/pdf/:id - generate PDF for a specific request
+	r.GET("/api/pdf/:id", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
 		}
 
-		// Generate PDF via service (pass official names and base URL for verification QR)
-		pdfBytes, err := services.GeneratePDF(request, registrarName, directorName, schoolName, schoolAddress, buildPublicBaseURL(c))
+		idStr := c.Param("id")
+
+		// Convert string ID to ObjectID
+		objectID, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Get the specific request
+		request, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID)
+		if err != nil {
+			log.Printf("request not found for id %s: %v", idStr, err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
+		}
+
+		pdfBytes, err := renderRequestPDF(ctx, stores, request, buildPublicBaseURL(c))
 		if err != nil {
 			log.Printf("pdf generation error for id %s: %v", idStr, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
@@ -973,7 +1874,90 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		}
 	})
 
-	// PUT /api/requests/:id/status - update request status
+	// POST /api/pdf/bulk - download many request PDFs as one ZIP archive with a manifest.json
+	// of the requests that could not be rendered. Body {"ids": [...]} picks requests by ID;
+	// without ids the list filters in the query string select them.
+	r.POST("/api/pdf/bulk", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		_, ids, filter, ok := parsePDFSelection(c)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		requests, failures, err := services.CollectBulkPDFRequests(ctx, stores.Requests, accountID, ids, filter)
+		if err != nil {
+			respondPDFSelectionError(c, err)
+			return
+		}
+
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=requests-%s.zip", time.Now().Format("20060102-150405")))
+		render := newRequestPDFRenderer(ctx, stores, accountID, buildPublicBaseURL(c))
+		manifest, err := services.WriteBulkPDFZip(ctx, c.Writer, requests, failures, render, services.BulkPDFWorkersFromEnv())
+		if err != nil {
+			// the archive is already partly sent; the client sees a truncated ZIP
+			log.Printf("bulk pdf download failed for account %s after %d files: %v", accountID, manifest.Rendered, err)
+		}
+	})
+
+	// POST /api/pdf/batch - render many requests back to back into one print-ready PDF.
+	// Takes the same selection as /api/pdf/bulk; "cover": true adds a cover page listing
+	// the requests and totals by document type. Unknown IDs fail the whole batch.
+	r.POST("/api/pdf/batch", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		payload, ids, filter, ok := parsePDFSelection(c)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		requests, failures, err := services.CollectBulkPDFRequests(ctx, stores.Requests, accountID, ids, filter)
+		if len(failures) > 0 {
+			// a print job should not silently drop forms
+			missing := make([]string, 0, len(failures))
+			for _, failure := range failures {
+				missing = append(missing, failure.RequestID)
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found", "missing": missing})
+			return
+		}
+		if err != nil {
+			respondPDFSelectionError(c, err)
+			return
+		}
+
+		for i := range requests {
+			services.LoadSignatureImages(ctx, stores.Blobs, &requests[i])
+		}
+		opts := loadPDFOptions(ctx, stores, accountID, buildPublicBaseURL(c))
+		opts.CoverPage = payload.Cover
+		pdfBytes, err := services.GenerateBatchPDF(requests, opts)
+		if err != nil {
+			log.Printf("batch pdf generation error for account %s: %v", accountID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=requests-%s.pdf", time.Now().Format("20060102-150405")))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+	})
+
+	// PUT /api/requests/:id/status - move a request along its lifecycle; illegal transitions return 409
 	r.PUT("/api/requests/:id/status", requireAuth, func(c *gin.Context) {
 		accountID := accountIDFromContext(c)
 		if accountID == "" {
@@ -991,7 +1975,8 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		}
 
 		var payload struct {
-			Status string `json:"status" binding:"required,oneof=pending completed cancelled"`
+			Status string `json:"status" binding:"required"`
+			Reason string `json:"reason"` // required when status is rejected
 		}
 
 		if err := c.ShouldBindJSON(&payload); err != nil {
@@ -1002,15 +1987,81 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		err = services.UpdateRequestStatusWithAudit(ctx, mongoColl, auditColl, objectID, payload.Status, c.ClientIP(), c.GetHeader("User-Agent"), accountID)
+		nextStatus, err := services.SetRequestStatusByAdmin(ctx, stores.Requests, stores.Audit, objectID, models.RequestStatus(strings.TrimSpace(payload.Status)), payload.Reason, c.ClientIP(), c.GetHeader("User-Agent"), accountID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
+			status, msg := mapRequestStatusError(err)
+			c.JSON(status, gin.H{"error": msg})
 			return
 		}
+		if nextStatus == models.RequestStatusRejected || nextStatus == models.RequestStatusCancelled {
+			if _, err := services.RevokeActiveSignLinks(ctx, stores.SignLinks, objectID); err != nil {
+				log.Printf("failed to revoke sign links for request %s: %v", objectID.Hex(), err)
+			}
+		}
+		switch nextStatus {
+		case models.RequestStatusRejected:
+			notifyRequesterAsync(stores, objectID, accountID, services.RequesterNoticeDecision, models.SignRoleAdmin, buildPublicBaseURL(c))
+		case models.RequestStatusCancelled:
+			notifyRequesterAsync(stores, objectID, accountID, services.RequesterNoticeCancelled, models.SignRoleAdmin, buildPublicBaseURL(c))
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
 	})
 
+	// GET /api/requests/:id/emails - delivery state of every email queued for a request
+	r.GET("/api/requests/:id/emails", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := services.GetRequestByID(ctx, stores.Requests, objectID, accountID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+			return
+		}
+		emails, err := services.ListRequestEmails(ctx, stores.EmailOutbox, objectID, accountID)
+		if err != nil {
+			log.Printf("failed to list emails for request %s: %v", objectID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list emails"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"emails": emails})
+	})
+
+	// POST /api/emails/:id/retry - re-queue a failed or dead-lettered email
+	r.POST("/api/emails/:id/retry", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		email, err := services.RetryEmail(ctx, stores.EmailOutbox, c.Param("id"), accountID)
+		if err != nil {
+			if errors.Is(err, services.ErrEmailNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "no failed email with this id"})
+				return
+			}
+			log.Printf("failed to retry email %s: %v", c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retry email"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"email": email})
+	})
+
 	// GET /api/officials - get current officials data
 	r.GET("/api/officials", requireAuth, func(c *gin.Context) {
 		accountID := accountIDFromContext(c)
@@ -1022,9 +2073,9 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		registrarName, directorName, err := services.GetOfficialsFromDB(ctx, officialsColl, accountID)
-		registrarEmail, directorEmail, _ := services.GetOfficialEmailsFromDB(ctx, officialsColl, accountID)
-		schoolName, schoolAddress, _ := services.GetSchoolInfoFromDB(ctx, officialsColl, accountID)
+		registrarName, directorName, err := services.GetOfficialsFromDB(ctx, stores.Officials, accountID)
+		registrarEmail, directorEmail, _ := services.GetOfficialEmailsFromDB(ctx, stores.Officials, accountID)
+		schoolName, schoolAddress, _ := services.GetSchoolInfoFromDB(ctx, stores.Officials, accountID)
 		if err != nil || (registrarName == "" && directorName == "") {
 			// Return default values if no data found
 			registrarName, directorName = services.GetOfficials()
@@ -1062,7 +2113,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		err := services.SaveOfficialsToDB(ctx, officialsColl, accountID, payload.RegistrarName, payload.DirectorName, payload.RegistrarEmail, payload.DirectorEmail, payload.SchoolName, payload.SchoolAddress)
+		err := services.SaveOfficialsToDB(ctx, stores.Officials, accountID, payload.RegistrarName, payload.DirectorName, payload.RegistrarEmail, payload.DirectorEmail, payload.SchoolName, payload.SchoolAddress)
 		if err != nil {
 			log.Printf("Error saving officials: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save officials data"})
@@ -1072,8 +2123,189 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		c.JSON(http.StatusOK, gin.H{"message": "officials data saved successfully"})
 	})
 
-	// Initialize admin service
-	adminService := services.NewAdminService(adminColl)
+	// GET /api/approval-chain - current approval steps (the default chain if none is configured)
+	r.GET("/api/approval-chain", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		steps, err := services.GetApprovalChainSteps(ctx, stores.ApprovalChains, stores.Officials, accountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load approval chain"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"steps": steps})
+	})
+
+	// PUT /api/approval-chain - replace approval steps; applies to requests submitted afterwards
+	r.PUT("/api/approval-chain", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		var payload struct {
+			Steps []models.ApprovalStep `json:"steps" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid data format"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		steps, err := services.SaveApprovalChain(ctx, stores.ApprovalChains, accountID, payload.Steps)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidApprovalChain) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error saving approval chain: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save approval chain"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "approval chain saved successfully", "steps": steps})
+	})
+
+	// GET /api/signing-settings - sign link expiry and QR sign session TTL with their allowed ranges
+	r.GET("/api/signing-settings", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		c.JSON(http.StatusOK, gin.H{
+			"signing_settings": services.LoadSigningSettings(ctx, stores.Officials, accountID),
+			"limits": gin.H{
+				"sign_link_expiry_days":    gin.H{"min": services.MinSignLinkExpiryDays, "max": services.MaxSignLinkExpiryDays, "default": services.DefaultSignLinkExpiryDays},
+				"sign_session_ttl_minutes": gin.H{"min": services.MinSignSessionTTLMinutes, "max": services.MaxSignSessionTTLMinutes, "default": services.DefaultSignSessionTTLMinutes},
+			},
+		})
+	})
+
+	// PUT /api/signing-settings - replace signing settings; applies to links and sessions created afterwards
+	r.PUT("/api/signing-settings", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		var payload models.SigningSettings
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid data format"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		settings, err := services.SaveSigningSettings(ctx, stores.Officials, accountID, payload)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSigningSettings) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error saving signing settings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save signing settings"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "signing settings saved successfully", "signing_settings": settings})
+	})
+
+	// GET /api/email-branding - email header/footer overrides (school name falls back to officials)
+	r.GET("/api/email-branding", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		c.JSON(http.StatusOK, services.LoadEmailBranding(ctx, stores.Officials, accountID))
+	})
+
+	// PUT /api/email-branding - replace email branding
+	r.PUT("/api/email-branding", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		var payload models.EmailBranding
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid data format"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		branding, err := services.SaveEmailBranding(ctx, stores.Officials, accountID, payload)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidEmailBranding) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error saving email branding: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save email branding"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "email branding saved successfully", "email_branding": branding})
+	})
+
+	// GET /api/email-templates - names of templates that can be previewed
+	r.GET("/api/email-templates", requireAuth, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"templates": services.EmailTemplateNames()})
+	})
+
+	// GET /api/email-templates/:name/preview - render a template with sample data and the account's branding.
+	// ?format=html or ?format=text returns the body directly; the default is JSON with subject, text and html.
+	r.GET("/api/email-templates/:name/preview", requireAuth, func(c *gin.Context) {
+		accountID := accountIDFromContext(c)
+		if accountID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing account id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		branding := services.LoadEmailBranding(ctx, stores.Officials, accountID)
+		rendered, err := services.RenderEmail(c.Param("name"), services.SampleEmailTemplateData(branding, buildPublicBaseURL(c)))
+		if err != nil {
+			if errors.Is(err, services.ErrEmailTemplateNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "email template not found"})
+				return
+			}
+			log.Printf("email template preview error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render email template"})
+			return
+		}
+
+		switch c.Query("format") {
+		case "html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTML))
+		case "text":
+			c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(rendered.Text))
+		default:
+			c.JSON(http.StatusOK, rendered)
+		}
+	})
 
 	// POST /api/admin/verify - verify admin credentials for login
 	r.POST("/api/admin/verify", func(c *gin.Context) {
@@ -1169,7 +2401,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		logs, err := services.GetAuditLogsByHash(ctx, auditColl, hash)
+		logs, err := services.GetAuditLogsByHash(ctx, stores.Audit, hash)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search audit logs"})
 			return
@@ -1182,8 +2414,7 @@ func RegisterRoutes(r *gin.Engine, mongoColl *mongo.Collection, officialsColl *m
 
 		var requestInfo gin.H
 		// Get audit log's associated request. We can fetch it directly to bypass account_id since this is public verification page
-		var publicRequest models.StudentData
-		err = mongoColl.FindOne(ctx, bson.M{"_id": logs[0].RequestID}).Decode(&publicRequest)
+		publicRequest, err := services.GetRequestByIDUnscoped(ctx, stores.Requests, logs[0].RequestID)
 		if err == nil {
 			requestInfo = gin.H{
 				"prefix":        publicRequest.Prefix,
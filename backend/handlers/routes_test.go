@@ -0,0 +1,1315 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, services.Stores) {
+	t.Helper()
+	t.Setenv("AUTH_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		utils.RegisterIDCardValidation(v)
+	}
+
+	stores := services.NewMemoryStores()
+	r := gin.New()
+	RegisterRoutes(r, stores, nil)
+	return r, stores
+}
+
+func authorizedRequest(t *testing.T, method, target string, body any) *http.Request {
+	t.Helper()
+	token, err := services.IssueSessionJWT("test-secret", "sub-1", "tester", "acct-1", 3600)
+	if err != nil {
+		t.Fatalf("IssueSessionJWT returned error: %v", err)
+	}
+	request := jsonRequest(t, method, target, body)
+	request.Header.Set("Authorization", "Bearer "+token)
+	return request
+}
+
+func jsonRequest(t *testing.T, method, target string, body any) *http.Request {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var payload map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return payload
+}
+
+// testSignatureDataURL returns a canonical PNG signature with a diagonal stroke.
+func testSignatureDataURL(t *testing.T) string {
+	t.Helper()
+	canvas := image.NewNRGBA(image.Rect(0, 0, 200, 80))
+	for x := 20; x < 180; x++ {
+		for dy := 0; dy < 3; dy++ {
+			canvas.Set(x, 20+x/4+dy, color.NRGBA{A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		t.Fatalf("encode signature: %v", err)
+	}
+	dataURL, err := services.NormalizeSignatureImage("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NormalizeSignatureImage returned error: %v", err)
+	}
+	return dataURL
+}
+
+func TestPublicSubmitAppearsInAccountRequests(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/form-links/current", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for form link, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	formToken, _ := decodeBody(t, recorder)["token"].(string)
+	if formToken == "" {
+		t.Fatal("expected form link token")
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, "/api/form-links/"+formToken+"/submit", map[string]string{
+		"name":          "สมหญิง ใจงาม",
+		"prefix":        "นางสาว",
+		"document_type": "ปพ.1",
+		"id_card":       "1234567890123",
+		"date_of_birth": "2008-05-01",
+		"purpose":       "ศึกษาต่อ",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for submit, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for requests, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	payload := decodeBody(t, recorder)
+	if total, _ := payload["total"].(float64); total != 1 {
+		t.Fatalf("expected total 1, got %#v", payload["total"])
+	}
+	requests, _ := payload["requests"].([]any)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %#v", payload["requests"])
+	}
+	first, _ := requests[0].(map[string]any)
+	if first["status"] != string(models.RequestStatusAwaitingStudentSignature) || first["account_id"] != "acct-1" {
+		t.Fatalf("unexpected request payload: %#v", first)
+	}
+}
+
+func TestStudentSignatureQueuesOwnerNotificationBeforeResponding(t *testing.T) {
+	r, stores := newTestRouter(t)
+	services.SetMailer(services.NewQueueMailer(stores.EmailOutbox))
+	t.Cleanup(func() { services.SetMailer(nil) })
+	ctx := context.Background()
+
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", IDCard: "1111111111111", AccountID: "owner@example.com",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPut, "/api/requests/"+id.Hex()+"/signature/student", map[string]any{
+		"data_base64": testSignatureDataURL(t),
+		"method":      "draw",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	entries, err := stores.EmailOutbox.ListByRequestID(ctx, id.Hex(), "owner@example.com")
+	if err != nil {
+		t.Fatalf("ListByRequestID returned error: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Template == services.EmailTemplateOwnerSubmission && entry.To == "owner@example.com" {
+			return
+		}
+	}
+	t.Fatalf("expected the owner notification to be queued, got %+v", entries)
+}
+
+func TestListRequestsFiltersAndSorts(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	for _, student := range []models.StudentData{
+		{Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", IDCard: "1111111111111", StudentID: "6501", AcademicYear: "2567", AccountID: "acct-1"},
+		{Name: "สมหญิง ใจงาม", DocumentType: "ปพ.7", IDCard: "2222222222222", StudentID: "6502", AcademicYear: "2567", AccountID: "acct-1"},
+		{Name: "อนันต์ ใจงาม", DocumentType: "ปพ.1", IDCard: "3333333333333", StudentID: "6503", AcademicYear: "2566", AccountID: "acct-1"},
+		{Name: "สมชาย อื่น", DocumentType: "ปพ.1", IDCard: "4444444444444", StudentID: "6504", AcademicYear: "2567", AccountID: "acct-2"},
+	} {
+		if _, err := services.SaveStudent(ctx, stores.Requests, student, nil); err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+	}
+
+	listNames := func(target string) []string {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for %s, got %d: %s", target, recorder.Code, recorder.Body.String())
+		}
+		requests, _ := decodeBody(t, recorder)["requests"].([]any)
+		names := make([]string, 0, len(requests))
+		for _, item := range requests {
+			record, _ := item.(map[string]any)
+			name, _ := record["name"].(string)
+			names = append(names, name)
+		}
+		return names
+	}
+
+	cases := []struct {
+		target string
+		want   []string
+	}{
+		{"/api/requests?document_type=" + url.QueryEscape("ปพ.1") + "&sort=student_id&order=asc", []string{"สมชาย ดีมาก", "อนันต์ ใจงาม"}},
+		{"/api/requests?name=" + url.QueryEscape("ใจงาม") + "&sort=name&order=desc", []string{"อนันต์ ใจงาม", "สมหญิง ใจงาม"}},
+		{"/api/requests?academic_year=2567&student_id=6502", []string{"สมหญิง ใจงาม"}},
+		{"/api/requests?q=3333333333333", []string{"อนันต์ ใจงาม"}},
+		// Thai names have no word breaks, so search matches parts of words
+		{"/api/requests?q=" + url.QueryEscape("ใจง") + "&sort=name&order=asc", []string{"สมหญิง ใจงาม", "อนันต์ ใจงาม"}},
+		{"/api/requests?q=" + url.QueryEscape("งาม 6503"), []string{"อนันต์ ใจงาม"}},
+	}
+	for _, tc := range cases {
+		got := listNames(tc.target)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("mismatch for %s: got %q want %q", tc.target, got, tc.want)
+		}
+	}
+
+	for _, target := range []string{
+		"/api/requests?sort=father_name",
+		"/api/requests?order=sideways",
+		"/api/requests?created_from=yesterday",
+	} {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400 for %s, got %d", target, recorder.Code)
+		}
+	}
+}
+
+func TestListRequestsReturnsSummaryAndDetailReturnsFullRecord(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", IDCard: "1111111111111", FatherName: "สมศักดิ์", AccountID: "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	if err := stores.Requests.SetSignature(ctx, id, "acct-1", models.SignRoleStudent, models.SignatureBlock{DataBase64: testSignatureDataURL(t), Method: "draw"}, time.Now()); err != nil {
+		t.Fatalf("SetSignature returned error: %v", err)
+	}
+
+	listFirst := func(target string) map[string]any {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for %s, got %d: %s", target, recorder.Code, recorder.Body.String())
+		}
+		requests, _ := decodeBody(t, recorder)["requests"].([]any)
+		if len(requests) != 1 {
+			t.Fatalf("expected 1 request for %s, got %d", target, len(requests))
+		}
+		first, _ := requests[0].(map[string]any)
+		return first
+	}
+
+	summary := listFirst("/api/requests")
+	if summary["id"] != id.Hex() || summary["name"] != "สมชาย ดีมาก" {
+		t.Fatalf("unexpected summary: %#v", summary)
+	}
+	for _, heavy := range []string{"signatures", "decisions", "father_name"} {
+		if _, ok := summary[heavy]; ok {
+			t.Fatalf("expected summary to omit %s, got %#v", heavy, summary)
+		}
+	}
+
+	withSignatures := listFirst("/api/requests?include=signatures")
+	signatures, _ := withSignatures["signatures"].(map[string]any)
+	student, _ := signatures["student"].(map[string]any)
+	if student["blob_id"] == "" || student["blob_id"] == nil || student["data_base64"] != nil {
+		t.Fatalf("expected listed signature to carry a blob reference only, got %#v", student)
+	}
+
+	narrow := listFirst("/api/requests?fields=name,father_name")
+	if len(narrow) != 3 || narrow["father_name"] != "สมศักดิ์" || narrow["id"] != id.Hex() {
+		t.Fatalf("expected only id, name and father_name, got %#v", narrow)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests?include=tracking_token_hash", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown field, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/"+id.Hex(), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for detail, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	detail := decodeBody(t, recorder)
+	signatures, _ = detail["signatures"].(map[string]any)
+	student, _ = signatures["student"].(map[string]any)
+	if detail["father_name"] != "สมศักดิ์" || !strings.HasPrefix(fmt.Sprint(student["data_base64"]), "data:image/png;base64,") {
+		t.Fatalf("expected full record with signature image, got %#v", detail)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/"+primitive.NewObjectID().Hex(), nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for unknown request, got %d", recorder.Code)
+	}
+}
+
+func TestListRequestsSummaryMapsLegacyStatusAndShowsRejection(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	created := time.Now()
+	records := []services.RequestRecord{
+		{AccountID: "acct-1", Name: "คำร้องเดิม", Status: "completed", CreatedAt: created},
+		{AccountID: "acct-1", Name: "คำร้องถูกปฏิเสธ", Status: models.RequestStatusRejected, CreatedAt: created.Add(time.Minute),
+			Rejection: &models.RequestRejection{Role: models.SignRoleRegistrar, Reason: "เอกสารไม่ครบ", RejectedAt: created}},
+	}
+	for i := range records {
+		if _, err := stores.Requests.Insert(ctx, &records[i]); err != nil {
+			t.Fatalf("Insert returned error: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests?sort=created_at&order=asc", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	requests, _ := decodeBody(t, recorder)["requests"].([]any)
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	legacy, _ := requests[0].(map[string]any)
+	if legacy["status"] != string(models.RequestStatusApproved) {
+		t.Fatalf("expected the legacy completed status to be listed as approved, got %#v", legacy["status"])
+	}
+	rejected, _ := requests[1].(map[string]any)
+	rejection, _ := rejected["rejection"].(map[string]any)
+	if rejection["reason"] != "เอกสารไม่ครบ" {
+		t.Fatalf("expected the rejection in the summary, got %#v", rejected)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests?status=approved", nil))
+	requests, _ = decodeBody(t, recorder)["requests"].([]any)
+	if len(requests) != 1 || requests[0].(map[string]any)["name"] != "คำร้องเดิม" {
+		t.Fatalf("expected the status filter to find the legacy completed request, got %#v", requests)
+	}
+}
+
+func TestCursorPaginationWalksRequestsAndAuditLogs(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	var firstID primitive.ObjectID
+	for i := 0; i < 5; i++ {
+		id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+			Name: fmt.Sprintf("นักเรียน %d", i), DocumentType: "ปพ.1", IDCard: "1111111111111", AccountID: "acct-1",
+		}, nil)
+		if err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+		if i == 0 {
+			firstID = id
+		}
+	}
+
+	getJSON := func(target string) map[string]any {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for %s, got %d: %s", target, recorder.Code, recorder.Body.String())
+		}
+		return decodeBody(t, recorder)
+	}
+
+	var pageNames []string
+	for _, item := range getJSON("/api/requests?limit=10")["requests"].([]any) {
+		pageNames = append(pageNames, item.(map[string]any)["name"].(string))
+	}
+
+	var cursorNames []string
+	cursor, pages := "", 0
+	for {
+		payload := getJSON("/api/requests?limit=2&cursor=" + url.QueryEscape(cursor))
+		if _, ok := payload["total"]; ok {
+			t.Fatalf("expected cursor mode to skip the total count, got %#v", payload)
+		}
+		for _, item := range payload["requests"].([]any) {
+			cursorNames = append(cursorNames, item.(map[string]any)["name"].(string))
+		}
+		pages++
+		cursor, _ = payload["next_cursor"].(string)
+		if cursor == "" {
+			break
+		}
+		if pages > 5 {
+			t.Fatal("cursor pagination did not terminate")
+		}
+	}
+	if pages != 3 || strings.Join(cursorNames, ",") != strings.Join(pageNames, ",") {
+		t.Fatalf("expected cursor pages to match page mode in 3 pages, got %d pages: %q vs %q", pages, cursorNames, pageNames)
+	}
+
+	for _, target := range []string{
+		"/api/requests?cursor=not-a-cursor",
+		"/api/requests?cursor=&sort=name",
+		"/api/requests/" + firstID.Hex() + "/audit-logs?cursor=not-a-cursor",
+	} {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400 for %s, got %d", target, recorder.Code)
+		}
+	}
+
+	base := time.Now().UTC()
+	for i, action := range []string{"sign", "approve", "status_change"} {
+		if err := services.RecordAuditLog(ctx, stores.Audit, models.AuditLog{
+			RequestID: firstID, Role: models.SignRoleRegistrar, Action: action, Timestamp: base.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatalf("RecordAuditLog returned error: %v", err)
+		}
+	}
+
+	firstPage := getJSON("/api/requests/" + firstID.Hex() + "/audit-logs?limit=2")
+	logs, _ := firstPage["audit_logs"].([]any)
+	next, _ := firstPage["next_cursor"].(string)
+	if len(logs) != 2 || logs[0].(map[string]any)["action"] != "sign" || next == "" {
+		t.Fatalf("unexpected first audit page: %#v", firstPage)
+	}
+	secondPage := getJSON("/api/requests/" + firstID.Hex() + "/audit-logs?limit=2&cursor=" + url.QueryEscape(next))
+	logs, _ = secondPage["audit_logs"].([]any)
+	if len(logs) != 1 || logs[0].(map[string]any)["action"] != "status_change" || secondPage["next_cursor"] != "" {
+		t.Fatalf("unexpected second audit page: %#v", secondPage)
+	}
+
+	otherID, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{Name: "อื่น", DocumentType: "ปพ.1", IDCard: "2222222222222", AccountID: "acct-2"}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/"+otherID.Hex()+"/audit-logs", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for another account's request, got %d", recorder.Code)
+	}
+}
+
+func TestExportRequestsAsCSVAndXLSX(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	for _, student := range []models.StudentData{
+		{Prefix: "นาย", Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", IDCard: "0123456789012", DateOfBirth: "2008-05-01", Purpose: "=1+1", AccountID: "acct-1"},
+		{Prefix: "นางสาว", Name: "สมหญิง ใจงาม", DocumentType: "ปพ.7", IDCard: "2222222222222", AccountID: "acct-1"},
+		{Prefix: "นาย", Name: "บัญชีอื่น", DocumentType: "ปพ.1", IDCard: "3333333333333", AccountID: "acct-2"},
+	} {
+		if _, err := services.SaveStudent(ctx, stores.Requests, student, nil); err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/export?document_type="+url.QueryEscape("ปพ.1")+"&columns=name,id_card,date_of_birth,purpose,created_at", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for csv export, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/csv") || !strings.Contains(recorder.Header().Get("Content-Disposition"), ".csv") {
+		t.Fatalf("unexpected csv headers: %#v", recorder.Header())
+	}
+	body := recorder.Body.String()
+	if !strings.HasPrefix(body, "\ufeff") {
+		t.Fatal("expected csv export to start with a UTF-8 byte order mark")
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv export: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected header and 1 row, got %q", rows)
+	}
+	wantCreated := fmt.Sprintf("/%d", time.Now().Year()+543)
+	row := rows[1]
+	if rows[0][0] != "ชื่อ-นามสกุล" || row[0] != "นายสมชาย ดีมาก" || row[1] != "0123456789012" || row[2] != "1/พ.ค./2551" || row[3] != "'=1+1" || !strings.HasSuffix(row[4], wantCreated) {
+		t.Fatalf("unexpected csv rows: %q", rows)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/export?format=xlsx&order=asc", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for xlsx export, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	workbook, err := excelize.OpenReader(bytes.NewReader(recorder.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to open xlsx export: %v", err)
+	}
+	defer workbook.Close()
+	sheetRows, err := workbook.GetRows(workbook.GetSheetName(0))
+	if err != nil {
+		t.Fatalf("failed to read xlsx rows: %v", err)
+	}
+	if len(sheetRows) != 3 || sheetRows[0][0] != "วันที่ยื่นคำร้อง" || sheetRows[1][1] != "นายสมชาย ดีมาก" || sheetRows[2][1] != "นางสาวสมหญิง ใจงาม" {
+		t.Fatalf("unexpected xlsx rows: %q", sheetRows)
+	}
+
+	for _, target := range []string{
+		"/api/requests/export?format=pdf",
+		"/api/requests/export?columns=name,tracking_token_hash",
+		"/api/requests/export?sort=name",
+	} {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400 for %s, got %d", target, recorder.Code)
+		}
+	}
+}
+
+func TestBulkPDFDownloadStreamsZipWithManifest(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, name := range []string{"สมชาย ดีมาก", "สมหญิง ใจงาม"} {
+		id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+			Prefix: "นาย", Name: name, DocumentType: "ปพ.1", IDCard: "1111111111111", DateOfBirth: "2008-05-01", AccountID: "acct-1",
+		}, nil)
+		if err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+		ids = append(ids, id.Hex())
+	}
+	missing := primitive.NewObjectID().Hex()
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/pdf/bulk", map[string]any{"ids": []string{ids[1], missing, ids[0]}}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for bulk pdf, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected zip response, got %q", recorder.Header().Get("Content-Type"))
+	}
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	want := []string{"request-" + ids[1] + ".pdf", "request-" + ids[0] + ".pdf", "manifest.json"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected archive entries: %q", names)
+	}
+	reader, err := archive.File[2].Open()
+	if err != nil {
+		t.Fatalf("failed to open manifest: %v", err)
+	}
+	var manifest services.BulkPDFManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	reader.Close()
+	if manifest.Rendered != 2 || len(manifest.Failed) != 1 || manifest.Failed[0].RequestID != missing {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	// without ids the list filters pick the requests
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/pdf/bulk?name="+url.QueryEscape("สมหญิง"), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for filtered bulk pdf, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	archive, err = zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil || len(archive.File) != 2 || archive.File[0].Name != "request-"+ids[1]+".pdf" {
+		t.Fatalf("expected one filtered pdf and the manifest, got %v", err)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/pdf/bulk?document_type="+url.QueryEscape("ปพ.7"), nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 when nothing matches, got %d", recorder.Code)
+	}
+}
+
+func TestBatchPDFMergesRequestsWithCoverPage(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, student := range []models.StudentData{
+		{Prefix: "นาย", Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", IDCard: "1111111111111", AccountID: "acct-1"},
+		{Prefix: "นางสาว", Name: "สมหญิง ใจงาม", DocumentType: "ปพ.7", IDCard: "2222222222222", AccountID: "acct-1"},
+	} {
+		id, err := services.SaveStudent(ctx, stores.Requests, student, nil)
+		if err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+		ids = append(ids, id.Hex())
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/pdf/batch", map[string]any{"ids": ids, "cover": true}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for batch pdf, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	body := recorder.Body.Bytes()
+	if recorder.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(body, []byte("%PDF")) || !bytes.Contains(body, []byte("/Count 3")) {
+		t.Fatalf("expected a 3-page pdf, got %q with %d bytes", recorder.Header().Get("Content-Type"), len(body))
+	}
+
+	missing := primitive.NewObjectID().Hex()
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/pdf/batch", map[string]any{"ids": []string{ids[0], missing}}))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for a missing request, got %d", recorder.Code)
+	}
+	if got, _ := decodeBody(t, recorder)["missing"].([]any); len(got) != 1 || got[0] != missing {
+		t.Fatalf("expected the missing request to be reported, got %#v", got)
+	}
+}
+
+func TestUpdateRequestStatusRejectsIllegalTransition(t *testing.T) {
+	r, stores := newTestRouter(t)
+	id, err := services.SaveStudent(context.Background(), stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	target := "/api/requests/" + id.Hex() + "/status"
+
+	cases := []struct {
+		status string
+		want   int
+	}{
+		{"collected", http.StatusConflict},
+		{"finished", http.StatusBadRequest},
+		{"completed", http.StatusConflict},
+		{"cancelled", http.StatusOK},
+		{"approved", http.StatusConflict},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, target, map[string]string{"status": tc.status}))
+		if recorder.Code != tc.want {
+			t.Fatalf("expected status %d for %s, got %d: %s", tc.want, tc.status, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestAdminCannotSetApprovalChainStatuses(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	moveTo := func(status models.RequestStatus) {
+		t.Helper()
+		record, err := services.GetRequestByID(ctx, stores.Requests, id, "acct-1")
+		if err != nil {
+			t.Fatalf("GetRequestByID returned error: %v", err)
+		}
+		if err := stores.Requests.UpdateStatus(ctx, id, "acct-1", record.Status, status, time.Now()); err != nil {
+			t.Fatalf("UpdateStatus returned error: %v", err)
+		}
+	}
+	setStatus := func(status string) int {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, "/api/requests/"+id.Hex()+"/status", map[string]string{"status": status}))
+		return recorder.Code
+	}
+
+	moveTo(models.RequestStatusAwaitingRegistrar)
+	for _, status := range []string{"approved", "awaiting_director", "completed"} {
+		if code := setStatus(status); code != http.StatusConflict {
+			t.Fatalf("expected status 409 for %s from awaiting_registrar, got %d", status, code)
+		}
+	}
+
+	// the legacy "completed" of older clients marks an approved request issued
+	moveTo(models.RequestStatusApproved)
+	if code := setStatus("completed"); code != http.StatusOK {
+		t.Fatalf("expected status 200 for completed from approved, got %d", code)
+	}
+	record, err := services.GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	if record.Status != models.RequestStatusIssued {
+		t.Fatalf("expected issued, got %s", record.Status)
+	}
+}
+
+func TestEmailSignLinkReportsDeliveryWithoutToken(t *testing.T) {
+	r, stores := newTestRouter(t)
+	services.SetMailer(services.NewQueueMailer(stores.EmailOutbox))
+	t.Cleanup(func() { services.SetMailer(nil) })
+	ctx := context.Background()
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name: "สมหญิง ใจงาม", DocumentType: "ปพ.1", IDCard: "1234567890123", AccountID: "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+id.Hex()+"/sign-links", map[string]string{
+		"role": "registrar", "channel": "email", "recipient_email": "registrar@school.test",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	body := decodeBody(t, recorder)
+	if body["email_sent"] != true || body["role"] != "registrar" || body["channel"] != "email" || body["expires_at"] == nil {
+		t.Fatalf("unexpected email link response: %#v", body)
+	}
+	if body["token"] != nil || body["sign_url"] != nil {
+		t.Fatalf("expected the email link token to stay out of the response: %#v", body)
+	}
+
+	entries, err := stores.EmailOutbox.ListByRequestID(ctx, id.Hex(), "acct-1")
+	if err != nil {
+		t.Fatalf("ListByRequestID returned error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Template != services.EmailTemplateOfficialSignLink || entries[0].To != "registrar@school.test" || !strings.Contains(entries[0].TextBody, "/sign/") {
+		t.Fatalf("expected the sign link to be emailed to the registrar, got %+v", entries)
+	}
+}
+
+func TestSignLinkStoresDecisionComment(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+id.Hex()+"/sign-links", map[string]string{
+		"role":    "registrar",
+		"channel": "copy",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for sign link, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	token, _ := decodeBody(t, recorder)["token"].(string)
+
+	signature := map[string]string{
+		"data_base64": testSignatureDataURL(t),
+		"method":      "draw",
+		"decision":    "reject",
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, "/api/sign-links/"+token+"/sign", signature))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for reject without reason, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	signature["decision"] = "approve"
+	signature["comment"] = "  เอกสารครบถ้วน  "
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, "/api/sign-links/"+token+"/sign", signature))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for sign, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	record, err := services.GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	if record.Decisions.Registrar == nil || record.Decisions.Registrar.Comment != "เอกสารครบถ้วน" {
+		t.Fatalf("unexpected registrar decision: %#v", record.Decisions.Registrar)
+	}
+}
+
+func TestReissueSignLinkRevokesPreviousLinks(t *testing.T) {
+	r, stores := newTestRouter(t)
+	id, err := services.SaveStudent(context.Background(), stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	base := "/api/requests/" + id.Hex() + "/sign-links"
+	issue := map[string]string{"role": "registrar", "channel": "copy"}
+
+	var firstToken string
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, base, issue))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for sign link, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		if i == 0 {
+			firstToken, _ = decodeBody(t, recorder)["token"].(string)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, base+"/reissue", issue))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for reissue, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if revoked, _ := decodeBody(t, recorder)["revoked"].(float64); revoked != 2 {
+		t.Fatalf("expected 2 revoked links, got %v", revoked)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodGet, "/api/sign-links/"+firstToken, nil))
+	if recorder.Code != http.StatusOK || decodeBody(t, recorder)["active"] != false {
+		t.Fatalf("expected the old link to be inactive, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var listing struct {
+		SignLinks []services.SignLinkSummary `json:"sign_links"`
+	}
+	list := func() {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, base, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for listing, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &listing); err != nil {
+			t.Fatalf("decode listing: %v", err)
+		}
+	}
+	list()
+	if len(listing.SignLinks) != 3 {
+		t.Fatalf("expected 3 links, got %d", len(listing.SignLinks))
+	}
+	states := []string{listing.SignLinks[0].State, listing.SignLinks[1].State, listing.SignLinks[2].State}
+	if states[0] != services.SignLinkStateRevoked || states[1] != services.SignLinkStateRevoked || states[2] != services.SignLinkStateActive {
+		t.Fatalf("unexpected link states: %v", states)
+	}
+
+	latest := listing.SignLinks[2].ID.Hex()
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, base+"/"+latest+"/revoke", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for revoke, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	list()
+	if listing.SignLinks[2].State != services.SignLinkStateRevoked || listing.SignLinks[2].RevokedAt == nil {
+		t.Fatalf("expected revoked link, got %#v", listing.SignLinks[2])
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+primitive.NewObjectID().Hex()+"/sign-links/"+latest+"/revoke", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for another request, got %d", recorder.Code)
+	}
+}
+
+// failingInsertSignLinks is a sign link store that cannot store new links.
+type failingInsertSignLinks struct {
+	services.SignLinkStore
+}
+
+func (failingInsertSignLinks) Insert(context.Context, *models.SignLink) error {
+	return errors.New("insert failed")
+}
+
+func TestReissueSignLinkKeepsPreviousLinkWhenCreateFails(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name: "สมหญิง ใจงาม", DocumentType: "ปพ.1", AccountID: "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	_, token, err := services.CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleRegistrar, "copy", "", "https://example.com", models.SigningSettings{})
+	if err != nil {
+		t.Fatalf("CreateSignLink returned error: %v", err)
+	}
+
+	failing := stores
+	failing.SignLinks = failingInsertSignLinks{stores.SignLinks}
+	r = gin.New()
+	RegisterRoutes(r, failing, nil)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+id.Hex()+"/sign-links/reissue", map[string]string{"role": "registrar", "channel": "copy"}))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	link, err := services.GetSignLinkByRawToken(ctx, stores.SignLinks, token)
+	if err != nil || services.ValidateSignLink(link) != nil {
+		t.Fatalf("expected the previous link to stay active, got %+v, %v", link, err)
+	}
+}
+
+func TestSigningSettingsControlLinkAndSessionLifetime(t *testing.T) {
+	r, stores := newTestRouter(t)
+	id, err := services.SaveStudent(context.Background(), stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, "/api/signing-settings", map[string]int{"sign_link_expiry_days": 90}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for out-of-range expiry, got %d", recorder.Code)
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, "/api/signing-settings", map[string]int{"sign_link_expiry_days": 30, "sign_session_ttl_minutes": 5}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for settings, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+id.Hex()+"/sign-links", map[string]string{"role": "registrar", "channel": "copy"}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for sign link, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	link := decodeBody(t, recorder)
+	expiresAt, err := time.Parse(time.RFC3339Nano, link["expires_at"].(string))
+	if err != nil {
+		t.Fatalf("parse expires_at: %v", err)
+	}
+	if remaining := time.Until(expiresAt); remaining < 29*24*time.Hour || remaining > 30*24*time.Hour {
+		t.Fatalf("expected a 30 day link, got %v", remaining)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, "/api/sign-sessions", map[string]string{"token": link["token"].(string), "decision": "approve"}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for sign session, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	sessionExpiresAt, err := time.Parse(time.RFC3339Nano, decodeBody(t, recorder)["expires_at"].(string))
+	if err != nil {
+		t.Fatalf("parse session expires_at: %v", err)
+	}
+	if remaining := time.Until(sessionExpiresAt); remaining < 4*time.Minute || remaining > 5*time.Minute {
+		t.Fatalf("expected a 5 minute session, got %v", remaining)
+	}
+}
+
+func TestTrackingLinkShowsProgressWithoutIDCard(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/form-links/current", nil))
+	formToken, _ := decodeBody(t, recorder)["token"].(string)
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, "/api/form-links/"+formToken+"/submit", map[string]string{
+		"name":          "สมหญิง ใจงาม",
+		"prefix":        "นางสาว",
+		"document_type": "ปพ.1",
+		"id_card":       "1234567890123",
+		"date_of_birth": "2008-05-01",
+		"purpose":       "ศึกษาต่อ",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for submit, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	trackingToken, _ := decodeBody(t, recorder)["tracking_token"].(string)
+	if trackingToken == "" {
+		t.Fatal("expected tracking token in submit response")
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodGet, "/api/track/"+trackingToken, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for tracking, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if strings.Contains(recorder.Body.String(), "1234567890123") {
+		t.Fatalf("tracking response exposes id_card: %s", recorder.Body.String())
+	}
+	payload := decodeBody(t, recorder)
+	steps, _ := payload["steps"].([]any)
+	if payload["status"] != string(models.RequestStatusAwaitingStudentSignature) || len(steps) != 2 || payload["pdf_available"] != false {
+		t.Fatalf("unexpected tracking payload: %#v", payload)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodGet, "/api/track/"+trackingToken+"/pdf", nil))
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for unapproved pdf, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodGet, "/api/track/unknown", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for unknown token, got %d", recorder.Code)
+	}
+}
+
+func TestEmailBrandingAppliesToTemplatePreview(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, "/api/email-branding", map[string]string{"logo_url": "javascript:alert(1)"}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid logo, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPut, "/api/email-branding", map[string]string{
+		"school_name": "โรงเรียนทดสอบ",
+		"logo_url":    "https://example.com/logo.png",
+		"signature":   "งานทะเบียน",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for branding, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/email-templates/official_sign_link/preview", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for preview, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	payload := decodeBody(t, recorder)
+	html, _ := payload["html"].(string)
+	text, _ := payload["text"].(string)
+	if !strings.Contains(html, "https://example.com/logo.png") || !strings.Contains(text, "งานทะเบียน") || !strings.Contains(text, "7 วัน") {
+		t.Fatalf("unexpected preview payload: %#v", payload)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/email-templates/unknown/preview", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for unknown template, got %d", recorder.Code)
+	}
+}
+
+func TestOfficialInboxBulkSignDecidesEachRequest(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	officialRequestAs := func(verifiedEmail, method, target string, body any) *http.Request {
+		t.Helper()
+		token, err := services.IssueSessionJWTWithVerifiedEmail("test-secret", "sub-2", "registrar@school.test", "registrar@school.test", verifiedEmail, "", 3600)
+		if err != nil {
+			t.Fatalf("IssueSessionJWTWithVerifiedEmail returned error: %v", err)
+		}
+		request := jsonRequest(t, method, target, body)
+		request.Header.Set("Authorization", "Bearer "+token)
+		return request
+	}
+	officialRequest := func(method, target string, body any) *http.Request {
+		t.Helper()
+		return officialRequestAs("Registrar@School.test", method, target, body)
+	}
+
+	var ids []primitive.ObjectID
+	for i, recipient := range []string{"registrar@school.test", "registrar@school.test", "someone@else.test"} {
+		id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+			Name:         fmt.Sprintf("นักเรียน %d", i+1),
+			DocumentType: "ปพ.1",
+			AccountID:    "acct-1",
+		}, nil)
+		if err != nil {
+			t.Fatalf("SaveStudent returned error: %v", err)
+		}
+		if _, _, err := services.CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleRegistrar, "email", recipient, "https://example.com", models.SigningSettings{}); err != nil {
+			t.Fatalf("CreateSignLink returned error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	var inbox struct {
+		Requests []services.OfficialInboxItem `json:"requests"`
+	}
+	// an account id or username that looks like the recipient is not a verified email
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequestAs("", http.MethodGet, "/api/official/inbox", nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), &inbox); err != nil {
+		t.Fatalf("decode inbox: %v", err)
+	}
+	if recorder.Code != http.StatusOK || len(inbox.Requests) != 0 {
+		t.Fatalf("expected an empty inbox without a verified email, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest(http.MethodGet, "/api/official/inbox", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for inbox, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &inbox); err != nil {
+		t.Fatalf("decode inbox: %v", err)
+	}
+	if len(inbox.Requests) != 2 || inbox.Requests[0].RequestID != ids[0].Hex() || inbox.Requests[1].RequestID != ids[1].Hex() {
+		t.Fatalf("unexpected inbox: %#v", inbox.Requests)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest(http.MethodPost, "/api/official/bulk-sign", map[string]any{
+		"request_ids": []string{ids[0].Hex(), ids[1].Hex(), ids[2].Hex()},
+		"data_base64": testSignatureDataURL(t),
+		"method":      "draw",
+		"decision":    "approve",
+	}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for bulk sign, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	body := decodeBody(t, recorder)
+	if body["signed"] != float64(2) || body["failed"] != float64(1) {
+		t.Fatalf("unexpected bulk sign result: %s", recorder.Body.String())
+	}
+
+	hashes := map[string]bool{}
+	for _, id := range ids[:2] {
+		record, err := services.GetRequestByID(ctx, stores.Requests, id, "acct-1")
+		if err != nil {
+			t.Fatalf("GetRequestByID returned error: %v", err)
+		}
+		decision := record.Decisions.Registrar
+		if decision == nil || decision.Decision != models.OfficialDecisionApprove || decision.DocumentHash == "" {
+			t.Fatalf("unexpected registrar decision: %#v", decision)
+		}
+		hashes[decision.DocumentHash] = true
+		entries, err := stores.Audit.FindByHash(ctx, decision.DocumentHash)
+		if err != nil {
+			t.Fatalf("FindByHash returned error: %v", err)
+		}
+		approvals := 0
+		for _, entry := range entries {
+			if entry.RequestID == id && entry.Action == string(models.OfficialDecisionApprove) {
+				approvals++
+			}
+		}
+		if approvals != 1 {
+			t.Fatalf("expected one approve audit entry for %s, got %#v", id.Hex(), entries)
+		}
+	}
+	if len(hashes) != 2 {
+		t.Fatal("expected each request to get its own document hash")
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest(http.MethodGet, "/api/official/inbox", nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), &inbox); err != nil {
+		t.Fatalf("decode inbox: %v", err)
+	}
+	if len(inbox.Requests) != 0 {
+		t.Fatalf("expected an empty inbox after signing, got %#v", inbox.Requests)
+	}
+}
+
+func TestSavedSignatureAppliedAfterConfirmation(t *testing.T) {
+	r, stores := newTestRouter(t)
+	ctx := context.Background()
+	id, err := services.SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	signature := testSignatureDataURL(t)
+	officialRequest := func(verifiedEmail, method, target string, body any) *http.Request {
+		t.Helper()
+		token, err := services.IssueSessionJWTWithVerifiedEmail("test-secret", "sub-2", "registrar", "oidc-42", verifiedEmail, "", 3600)
+		if err != nil {
+			t.Fatalf("IssueSessionJWTWithVerifiedEmail returned error: %v", err)
+		}
+		request := jsonRequest(t, method, target, body)
+		request.Header.Set("Authorization", "Bearer "+token)
+		return request
+	}
+
+	// the account only gets the raw token of copy links, and those never unlock saved signatures
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodPost, "/api/requests/"+id.Hex()+"/sign-links", map[string]string{
+		"role": "registrar", "channel": "email", "recipient_email": "registrar@school.test",
+	}))
+	if body := decodeBody(t, recorder); recorder.Code != http.StatusOK || body["token"] != nil || body["sign_url"] != nil {
+		t.Fatalf("expected an email link without its token, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	_, copyToken, err := services.CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleRegistrar, "copy", "registrar@school.test", "https://example.com", models.SigningSettings{})
+	if err != nil {
+		t.Fatalf("CreateSignLink returned error: %v", err)
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodGet, "/api/sign-links/"+copyToken+"/saved-signature", nil))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 for a copy link, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	link, token, err := services.CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleRegistrar, "email", "Registrar@School.test", "https://example.com", models.SigningSettings{})
+	if err != nil {
+		t.Fatalf("CreateSignLink returned error: %v", err)
+	}
+	if err := services.TouchSignLinkSent(ctx, stores.SignLinks, link.ID); err != nil {
+		t.Fatalf("TouchSignLinkSent returned error: %v", err)
+	}
+	base := "/api/sign-links/" + token
+	put := map[string]string{"data_base64": signature, "method": "draw"}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPut, base+"/saved-signature", put))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without a session, got %d", recorder.Code)
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("someone@else.test", http.MethodPut, base+"/saved-signature", put))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for another official, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodPut, base+"/saved-signature", put))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for saving the signature, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	saved, err := stores.Signatures.Find(ctx, "acct-1", "registrar@school.test", models.SignRoleRegistrar)
+	if err != nil {
+		t.Fatalf("Find returned error: %v", err)
+	}
+	if len(saved.Ciphertext) == 0 || strings.Contains(string(saved.Ciphertext), "data:image/png") {
+		t.Fatal("expected the saved signature to be stored encrypted")
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodGet, base, nil))
+	if _, ok := decodeBody(t, recorder)["saved_signature"]; ok {
+		t.Fatalf("expected the saved signature to stay hidden without a session: %s", recorder.Body.String())
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodGet, base, nil))
+	if _, ok := decodeBody(t, recorder)["saved_signature"].(map[string]any); !ok {
+		t.Fatalf("expected the sign link to offer the saved signature: %s", recorder.Body.String())
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodGet, base+"/saved-signature", nil))
+	if recorder.Code != http.StatusOK || decodeBody(t, recorder)["data_base64"] != signature {
+		t.Fatalf("expected the saved signature preview, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	sign := map[string]any{"decision": "approve", "use_saved_signature": true, "confirm_saved_signature": true}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, jsonRequest(t, http.MethodPost, base+"/sign", sign))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for an anonymous saved signature, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	sign["confirm_saved_signature"] = false
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodPost, base+"/sign", sign))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without confirmation, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	sign["confirm_saved_signature"] = true
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, officialRequest("registrar@school.test", http.MethodPost, base+"/sign", sign))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for sign, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	record, err := services.GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	sig := record.Signatures.Registrar
+	if sig == nil || sig.SavedSignatureID == nil || *sig.SavedSignatureID != saved.ID {
+		t.Fatalf("unexpected registrar signature: %#v", sig)
+	}
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, authorizedRequest(t, http.MethodGet, "/api/requests/"+id.Hex()+"/signatures/registrar", nil))
+	if recorder.Code != http.StatusOK || "data:image/png;base64,"+base64.StdEncoding.EncodeToString(recorder.Body.Bytes()) != signature {
+		t.Fatalf("expected the saved signature image, got %d", recorder.Code)
+	}
+	entries, err := stores.Audit.FindByHash(ctx, sig.DocumentHash)
+	if err != nil {
+		t.Fatalf("FindByHash returned error: %v", err)
+	}
+	applied := false
+	for _, entry := range entries {
+		if entry.Action == string(models.OfficialDecisionApprove) && entry.SavedSignatureID != nil && *entry.SavedSignatureID == saved.ID {
+			applied = true
+		}
+	}
+	if !applied {
+		t.Fatalf("expected the audit log to record the saved signature, got %#v", entries)
+	}
+}
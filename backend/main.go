@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"io/fs"
 	"log"
 	"os"
 	"time"
@@ -19,7 +20,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var mongoColl *mongo.Collection
+// embeddedPDFAssets holds the fonts/ and images/ directories when the binary is
+// built with -tags embed_assets (see pdf_assets_embed.go).
+var embeddedPDFAssets fs.FS
 
 func initMongo(uri string) *mongo.Client {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -38,22 +41,58 @@ func initMongo(uri string) *mongo.Client {
 
 func main() {
 	cfg := settings.LoadConfig()
+	// PDF fonts and crest are read once; without the Thai font a production start fails here
+	pdfAssets, err := services.PDFAssetsFromEnv(embeddedPDFAssets)
+	if err != nil {
+		log.Fatalf("pdf assets error: %v", err)
+	}
+	services.SetPDFAssets(pdfAssets)
+	// saved signatures must not be encrypted with the development secret
+	if err := services.CheckSignatureEncryptionKey(); err != nil {
+		log.Fatalf("signature encryption config error: %v", err)
+	}
+	// form links and tracking links are HMACs of this secret, so it must not be public
+	if err := services.CheckFormLinkSecret(); err != nil {
+		log.Fatalf("form link config error: %v", err)
+	}
 	client := initMongo(cfg.MongoURI)
-	// use database from DB_NAME and collection `students`
-	mongoColl = client.Database(cfg.DBName).Collection("students")
-	// use a dedicated collection for school officials (registrar/director)
-	mongoCollOfficials := client.Database(cfg.DBName).Collection("officials")
+	// use database from DB_NAME; stores map onto `students`, `officials`, `approval_chains`,
+	// `sign_links`, `sign_sessions`, `form_links`, `logout_handles`, `email_outbox`,
+	// `saved_signatures` and `audit_logs`; signature images go to the `blobs` GridFS bucket
+	db := client.Database(cfg.DBName)
+	// signature images live in a blob store (BLOB_DRIVER), not in request documents
+	blobs, err := services.NewBlobStoreFromEnv(db)
+	if err != nil {
+		log.Fatalf("blob store config error: %v", err)
+	}
+	stores := services.NewMongoStores(db, blobs)
+	mailer, err := services.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("mail config error: %v", err)
+	}
+	// emails are queued in email_outbox and delivered by background workers with retries
+	services.SetMailer(services.NewQueueMailer(stores.EmailOutbox))
+	services.StartEmailOutboxWorkers(context.Background(), stores.EmailOutbox, mailer, services.EmailOutboxWorkersFromEnv())
+	// finish moving inline images before any listing is served; a failure is
+	// retried on the next start, and listings still leave the images out
+	moved, err := services.MigrateInlineSignatures(context.Background(), stores.Requests, stores.Blobs)
+	if err != nil {
+		log.Printf("signature image migration failed after %d images: %v", moved, err)
+	} else if moved > 0 {
+		log.Printf("moved %d inline signature images to the blob store", moved)
+	}
+	services.StartSignReminderScheduler(context.Background(), stores.Requests, stores.SignLinks, stores.Officials, services.SignReminderConfigFromEnv())
 	// use a dedicated collection for admin users
-	mongoCollAdmin := client.Database(cfg.DBName).Collection("admins")
-	// collections for signing flow
-	mongoCollSignLinks := client.Database(cfg.DBName).Collection("sign_links")
-	mongoCollSignSessions := client.Database(cfg.DBName).Collection("sign_sessions")
-	// collection for reusable public form links
-	mongoCollFormLinks := client.Database(cfg.DBName).Collection("form_links")
-	// collection for OIDC logout handles
-	mongoCollLogoutHandles := client.Database(cfg.DBName).Collection("logout_handles")
-	// collection for audit logs
-	mongoCollAudit := client.Database(cfg.DBName).Collection("audit_logs")
+	mongoCollAdmin := db.Collection("admins")
+	// collections that need indexes at startup
+	mongoCollFormLinks := db.Collection("form_links")
+	mongoCollLogoutHandles := db.Collection("logout_handles")
+	mongoCollStudents := db.Collection("students")
+	mongoCollApprovalChains := db.Collection("approval_chains")
+	mongoCollEmailOutbox := db.Collection("email_outbox")
+	mongoCollSignLinks := db.Collection("sign_links")
+	mongoCollSavedSignatures := db.Collection("saved_signatures")
+	mongoCollAuditLogs := db.Collection("audit_logs")
 
 	// Initialize admin service and create default admin if not exists
 	adminService := services.NewAdminService(mongoCollAdmin)
@@ -82,6 +121,32 @@ func main() {
 		log.Printf("Warning: failed to ensure form_links indexes: %v", indexErr)
 	}
 
+	// request listing filters and sorts are always scoped by account_id
+	_, studentsIndexErr := mongoCollStudents.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "document_type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "academic_year", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "student_id", Value: 1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "id_card", Value: 1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "name", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "tracking_token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+	})
+	if studentsIndexErr != nil {
+		log.Printf("Warning: failed to ensure students indexes: %v", studentsIndexErr)
+	}
+
+	_, chainIndexErr := mongoCollApprovalChains.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if chainIndexErr != nil {
+		log.Printf("Warning: failed to ensure approval_chains indexes: %v", chainIndexErr)
+	}
+
 	_, logoutIndexErr := mongoCollLogoutHandles.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
 			Keys:    bson.D{{Key: "account_id", Value: 1}},
@@ -96,6 +161,44 @@ func main() {
 		log.Printf("Warning: failed to ensure logout_handles indexes: %v", logoutIndexErr)
 	}
 
+	// the reminder scheduler scans active links
+	_, signLinkIndexErr := mongoCollSignLinks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "revoked", Value: 1}, {Key: "expires_at", Value: 1}}},
+		// official inbox lookups by recipient; the collation matches emails case-insensitively
+		{
+			Keys:    bson.D{{Key: "recipient_email", Value: 1}, {Key: "revoked", Value: 1}, {Key: "expires_at", Value: 1}},
+			Options: options.Index().SetCollation(services.SignLinkRecipientCollation),
+		},
+	})
+	if signLinkIndexErr != nil {
+		log.Printf("Warning: failed to ensure sign_links indexes: %v", signLinkIndexErr)
+	}
+
+	_, outboxIndexErr := mongoCollEmailOutbox.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "request_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if outboxIndexErr != nil {
+		log.Printf("Warning: failed to ensure email_outbox indexes: %v", outboxIndexErr)
+	}
+
+	_, savedSignatureIndexErr := mongoCollSavedSignatures.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "email", Value: 1}, {Key: "role", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if savedSignatureIndexErr != nil {
+		log.Printf("Warning: failed to ensure saved_signatures indexes: %v", savedSignatureIndexErr)
+	}
+
+	// verification looks entries up by hash; request audit trails page by (timestamp, _id)
+	_, auditIndexErr := mongoCollAuditLogs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "document_hash", Value: 1}}},
+		{Keys: bson.D{{Key: "request_id", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if auditIndexErr != nil {
+		log.Printf("Warning: failed to ensure audit_logs indexes: %v", auditIndexErr)
+	}
+
 	if err := adminService.InitializeDefaultAdmin(ctx, defaultUsername, defaultPassword); err != nil {
 		log.Printf("Warning: failed to initialize default admin: %v", err)
 	}
@@ -111,8 +214,7 @@ func main() {
 	}
 
 	// Register routes from handlers package (keeps main.go minimal)
-	// pass both the students collection and the officials collection
-	handlers.RegisterRoutes(r, mongoColl, mongoCollOfficials, mongoCollAdmin, mongoCollSignLinks, mongoCollSignSessions, mongoCollFormLinks, mongoCollAudit, mongoCollLogoutHandles)
+	handlers.RegisterRoutes(r, stores, adminService)
 
 	r.Run() // listen and serve on 0.0.0.0:8080
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApprovalStep is one official step of an approval chain.
+type ApprovalStep struct {
+	Role        SignRole `bson:"role" json:"role"`
+	Label       string   `bson:"label" json:"label"` // printed title, e.g. "ครูประจำชั้น"
+	SignerName  string   `bson:"signer_name,omitempty" json:"signer_name,omitempty"`
+	SignerEmail string   `bson:"signer_email,omitempty" json:"signer_email,omitempty"`
+	// Parallel lets this step sign alongside the previous step instead of waiting for it to approve.
+	Parallel bool `bson:"parallel" json:"parallel"`
+}
+
+// ApprovalChain stores the ordered approval steps configured for one account.
+type ApprovalChain struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	AccountID string             `bson:"account_id" json:"account_id"`
+	Steps     []ApprovalStep     `bson:"steps" json:"steps"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+var approvalRolePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+// IsValidApprovalRole reports whether role can be used as an approval step.
+// Custom role names become part of Mongo field paths, so they are restricted
+// to lowercase identifiers and may not shadow the fixed signature fields.
+func IsValidApprovalRole(role SignRole) bool {
+	switch role {
+	case SignRoleRegistrar, SignRoleDirector:
+		return true
+	case SignRoleStudent, SignRoleAdmin, "officials":
+		return false
+	}
+	return approvalRolePattern.MatchString(string(role))
+}
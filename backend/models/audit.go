@@ -12,9 +12,13 @@ type AuditLog struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	RequestID    primitive.ObjectID `bson:"request_id" json:"request_id"`
 	Role         SignRole           `bson:"role" json:"role"`
-	Action       string             `bson:"action" json:"action"`               // e.g., "sign", "approve", "reject"
+	Action       string             `bson:"action" json:"action"`               // e.g., "sign", "approve", "reject", "status_change"
 	DocumentHash string             `bson:"document_hash" json:"document_hash"` // SHA-256 of document state at time of action
+	FromStatus   RequestStatus      `bson:"from_status,omitempty" json:"from_status,omitempty"`
+	ToStatus     RequestStatus      `bson:"to_status,omitempty" json:"to_status,omitempty"`
 	IPAddress    string             `bson:"ip_address" json:"ip_address"`
 	UserAgent    string             `bson:"user_agent" json:"user_agent"`
 	Timestamp    time.Time          `bson:"timestamp" json:"timestamp"`
+	// SavedSignatureID is set when the action applied an official's saved signature.
+	SavedSignatureID *primitive.ObjectID `bson:"saved_signature_id,omitempty" json:"saved_signature_id,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailOutboxStatus is the delivery state of a queued email.
+type EmailOutboxStatus string
+
+const (
+	EmailOutboxPending EmailOutboxStatus = "pending" // waiting for its first attempt
+	EmailOutboxSending EmailOutboxStatus = "sending" // claimed by a worker
+	EmailOutboxSent    EmailOutboxStatus = "sent"
+	EmailOutboxFailed  EmailOutboxStatus = "failed" // last attempt failed; retried at NextAttemptAt
+	EmailOutboxDead    EmailOutboxStatus = "dead"   // gave up after MaxAttempts
+)
+
+// EmailOutboxEntry is one rendered email waiting in, or delivered from, the outbox.
+type EmailOutboxEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AccountID     string             `bson:"account_id,omitempty" json:"account_id,omitempty"`
+	RequestID     string             `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Template      string             `bson:"template,omitempty" json:"template,omitempty"`
+	To            string             `bson:"to" json:"to"`
+	Subject       string             `bson:"subject" json:"subject"`
+	TextBody      string             `bson:"text_body" json:"-"`
+	HTMLBody      string             `bson:"html_body,omitempty" json:"-"`
+	Status        EmailOutboxStatus  `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts" json:"max_attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   *time.Time         `bson:"locked_until,omitempty" json:"-"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
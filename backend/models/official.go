@@ -9,4 +9,23 @@ type Official struct {
 	DirectorEmail  string `bson:"director_email" json:"director_email"`
 	SchoolName     string `bson:"school_name" json:"school_name"`
 	SchoolAddress  string `bson:"school_address" json:"school_address"`
+	// EmailBranding overrides how outgoing emails of this account look.
+	EmailBranding *EmailBranding `bson:"email_branding,omitempty" json:"email_branding,omitempty"`
+	// SigningSettings overrides how long sign links and QR sign sessions stay valid.
+	SigningSettings *SigningSettings `bson:"signing_settings,omitempty" json:"signing_settings,omitempty"`
+}
+
+// EmailBranding customises the header and footer of outgoing emails.
+// An empty SchoolName falls back to Official.SchoolName.
+type EmailBranding struct {
+	SchoolName string `bson:"school_name,omitempty" json:"school_name"`
+	LogoURL    string `bson:"logo_url,omitempty" json:"logo_url"`
+	Signature  string `bson:"signature,omitempty" json:"signature"` // plain text, may span lines
+}
+
+// SigningSettings controls the lifetime of an account's signing credentials.
+// Zero values fall back to the defaults (7 days and 10 minutes).
+type SigningSettings struct {
+	SignLinkExpiryDays    int `bson:"sign_link_expiry_days,omitempty" json:"sign_link_expiry_days"`
+	SignSessionTTLMinutes int `bson:"sign_session_ttl_minutes,omitempty" json:"sign_session_ttl_minutes"`
 }
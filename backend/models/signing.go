@@ -25,10 +25,14 @@ type SignLink struct {
 	ExpiresAt      time.Time          `bson:"expires_at" json:"expires_at"`
 	UsedAt         *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
 	Revoked        bool               `bson:"revoked" json:"revoked"`
+	RevokedAt      *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
 	Channel        string             `bson:"channel" json:"channel"`
 	RecipientEmail string             `bson:"recipient_email,omitempty" json:"recipient_email,omitempty"`
 	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
 	LastSentAt     *time.Time         `bson:"last_sent_at,omitempty" json:"last_sent_at,omitempty"`
+	PublicBaseURL  string             `bson:"public_base_url,omitempty" json:"-"`
+	ReminderCount  int                `bson:"reminder_count" json:"reminder_count"`
+	EscalatedAt    *time.Time         `bson:"escalated_at,omitempty" json:"escalated_at,omitempty"`
 }
 
 // SignSession tracks desktop QR handoff status.
@@ -43,3 +47,17 @@ type SignSession struct {
 	CreatedAt   time.Time             `bson:"created_at" json:"created_at"`
 	CompletedAt *time.Time            `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
 }
+
+// SavedSignature is an official's registered signature image for one account and
+// role. The image is stored AES-GCM encrypted and never returned in listings.
+type SavedSignature struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AccountID  string             `bson:"account_id" json:"account_id"`
+	Email      string             `bson:"email" json:"email"` // lower-cased signer email
+	Role       SignRole           `bson:"role" json:"role"`
+	Method     string             `bson:"method" json:"method"` // draw | upload
+	Ciphertext []byte             `bson:"ciphertext" json:"-"`
+	Nonce      []byte             `bson:"nonce" json:"-"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
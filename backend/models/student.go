@@ -1,14 +1,31 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
 
-// SignatureBlock stores one signature entry for a role.
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SignatureBlock stores one signature entry for a role. The image lives in the
+// blob store under BlobID; DataBase64 only holds it inline for legacy documents
+// and after the image was loaded for rendering.
 type SignatureBlock struct {
-	DataBase64   string    `json:"data_base64" bson:"data_base64"`
+	DataBase64   string    `json:"data_base64,omitempty" bson:"data_base64,omitempty"`
 	Method       string    `json:"method" bson:"method"`         // draw | upload
 	SignedVia    string    `json:"signed_via" bson:"signed_via"` // web | mobile | qr-mobile
 	SignedAt     time.Time `json:"signed_at" bson:"signed_at"`
 	DocumentHash string    `json:"document_hash,omitempty" bson:"document_hash,omitempty"` // SHA-256 of document state
+	// SavedSignatureID is set when the official applied their saved signature.
+	SavedSignatureID *primitive.ObjectID `json:"saved_signature_id,omitempty" bson:"saved_signature_id,omitempty"`
+	BlobID           string              `json:"blob_id,omitempty" bson:"blob_id,omitempty"`
+	ContentHash      string              `json:"content_hash,omitempty" bson:"content_hash,omitempty"` // SHA-256 of the image bytes
+	ContentType      string              `json:"content_type,omitempty" bson:"content_type,omitempty"`
+}
+
+// HasImage reports whether the block holds a signature image, inline or in the blob store.
+func (b *SignatureBlock) HasImage() bool {
+	return b != nil && (strings.TrimSpace(b.DataBase64) != "" || b.BlobID != "")
 }
 
 // RequestSignatures stores signatures for each role in the workflow.
@@ -16,6 +33,22 @@ type RequestSignatures struct {
 	Student   *SignatureBlock `json:"student,omitempty" bson:"student,omitempty"`
 	Registrar *SignatureBlock `json:"registrar,omitempty" bson:"registrar,omitempty"`
 	Director  *SignatureBlock `json:"director,omitempty" bson:"director,omitempty"`
+	// Officials holds signatures of custom approval steps, keyed by role.
+	Officials map[string]*SignatureBlock `json:"officials,omitempty" bson:"officials,omitempty"`
+}
+
+// ForRole returns the signature stored for role, or nil.
+func (s RequestSignatures) ForRole(role SignRole) *SignatureBlock {
+	switch role {
+	case SignRoleStudent:
+		return s.Student
+	case SignRoleRegistrar:
+		return s.Registrar
+	case SignRoleDirector:
+		return s.Director
+	default:
+		return s.Officials[string(role)]
+	}
 }
 
 // OfficialDecisionValue stores an official decision for one role.
@@ -34,14 +67,66 @@ func IsValidOfficialDecision(value OfficialDecisionValue) bool {
 // OfficialDecision stores the selected decision with timestamp.
 type OfficialDecision struct {
 	Decision     OfficialDecisionValue `json:"decision" bson:"decision"`
+	Reason       string                `json:"reason,omitempty" bson:"reason,omitempty"` // required when Decision is reject
+	Comment      string                `json:"comment,omitempty" bson:"comment,omitempty"`
 	DecidedAt    time.Time             `json:"decided_at" bson:"decided_at"`
 	DocumentHash string                `json:"document_hash,omitempty" bson:"document_hash,omitempty"` // SHA-256 of document state
 }
 
+// RequestStatus is one step of the request lifecycle. Allowed moves between
+// statuses are defined by the transition table in services.
+type RequestStatus string
+
+const (
+	RequestStatusSubmitted                RequestStatus = "submitted"
+	RequestStatusAwaitingStudentSignature RequestStatus = "awaiting_student_signature"
+	RequestStatusAwaitingRegistrar        RequestStatus = "awaiting_registrar"
+	RequestStatusAwaitingDirector         RequestStatus = "awaiting_director"
+	RequestStatusAwaitingApproval         RequestStatus = "awaiting_approval" // waiting on a custom approval step
+	RequestStatusApproved                 RequestStatus = "approved"
+	RequestStatusRejected                 RequestStatus = "rejected"
+	RequestStatusIssued                   RequestStatus = "issued"
+	RequestStatusCollected                RequestStatus = "collected"
+	RequestStatusCancelled                RequestStatus = "cancelled"
+)
+
+// IsValidRequestStatus reports whether a status value is part of the lifecycle.
+func IsValidRequestStatus(value RequestStatus) bool {
+	switch value {
+	case RequestStatusSubmitted, RequestStatusAwaitingStudentSignature, RequestStatusAwaitingRegistrar,
+		RequestStatusAwaitingDirector, RequestStatusAwaitingApproval, RequestStatusApproved, RequestStatusRejected,
+		RequestStatusIssued, RequestStatusCollected, RequestStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// RequestRejection records who rejected a request and why.
+type RequestRejection struct {
+	Role       SignRole  `json:"role" bson:"role"`
+	Reason     string    `json:"reason" bson:"reason"`
+	RejectedAt time.Time `json:"rejected_at" bson:"rejected_at"`
+}
+
 // RequestDecisions stores official decisions for each role.
 type RequestDecisions struct {
 	Registrar *OfficialDecision `json:"registrar,omitempty" bson:"registrar,omitempty"`
 	Director  *OfficialDecision `json:"director,omitempty" bson:"director,omitempty"`
+	// Officials holds decisions of custom approval steps, keyed by role.
+	Officials map[string]*OfficialDecision `json:"officials,omitempty" bson:"officials,omitempty"`
+}
+
+// ForRole returns the decision stored for role, or nil.
+func (d RequestDecisions) ForRole(role SignRole) *OfficialDecision {
+	switch role {
+	case SignRoleRegistrar:
+		return d.Registrar
+	case SignRoleDirector:
+		return d.Director
+	default:
+		return d.Officials[string(role)]
+	}
 }
 
 // StudentData represents the payload expected from the frontend for ปพ.1
@@ -53,7 +138,7 @@ type StudentData struct {
 	StudentID    string `json:"student_id" bson:"student_id"`
 	DateOfBirth  string `json:"date_of_birth" bson:"date_of_birth" binding:"required"`
 	Purpose      string `json:"purpose" bson:"purpose" binding:"required"`
-	Status       string `json:"status" bson:"status"` // pending, completed, cancelled
+	Status       string `json:"status" bson:"status"` // see RequestStatus
 	AccountID    string `json:"account_id" bson:"account_id" binding:"required"`
 
 	// optional
@@ -62,6 +147,8 @@ type StudentData struct {
 	AcademicYear string `json:"academic_year" bson:"academic_year"`
 	FatherName   string `json:"father_name" bson:"father_name"`
 	MotherName   string `json:"mother_name" bson:"mother_name"`
+	// RequesterEmail receives status updates about the request.
+	RequesterEmail string `json:"requester_email,omitempty" bson:"requester_email,omitempty" binding:"omitempty,email"`
 
 	Signatures RequestSignatures `json:"signatures" bson:"signatures"`
 	Decisions  RequestDecisions  `json:"decisions,omitempty" bson:"decisions,omitempty"`
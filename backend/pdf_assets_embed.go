@@ -0,0 +1,15 @@
+//go:build embed_assets
+
+package main
+
+import "embed"
+
+// Built with -tags embed_assets, the binary carries the PDF fonts and crest and
+// no longer needs the fonts/ and images/ directories next to it.
+//
+//go:embed fonts images
+var embeddedAssets embed.FS
+
+func init() {
+	embeddedPDFAssets = embeddedAssets
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"backend/models"
+)
+
+var (
+	ErrInvalidApprovalChain = errors.New("invalid approval chain")
+	ErrRoleNotInChain       = errors.New("role is not part of the approval chain")
+	ErrApprovalStepNotOpen  = errors.New("approval step is waiting for an earlier step")
+)
+
+const maxApprovalSteps = 8
+
+// DefaultApprovalSteps is the registrar and director chain used when an account
+// has not configured one. Both officials may sign in either order.
+func DefaultApprovalSteps() []models.ApprovalStep {
+	return []models.ApprovalStep{
+		{Role: models.SignRoleRegistrar, Label: "นายทะเบียน"},
+		{Role: models.SignRoleDirector, Label: "ผู้อำนวยการ", Parallel: true},
+	}
+}
+
+// RequestApprovalSteps returns the chain captured on a request, falling back to the default.
+func RequestApprovalSteps(record *RequestRecord) []models.ApprovalStep {
+	if record == nil || len(record.ApprovalSteps) == 0 {
+		return DefaultApprovalSteps()
+	}
+	return record.ApprovalSteps
+}
+
+// ValidateApprovalSteps checks role names, uniqueness and signer emails.
+func ValidateApprovalSteps(steps []models.ApprovalStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidApprovalChain)
+	}
+	if len(steps) > maxApprovalSteps {
+		return fmt.Errorf("%w: at most %d steps are allowed", ErrInvalidApprovalChain, maxApprovalSteps)
+	}
+	seen := map[models.SignRole]bool{}
+	for i, step := range steps {
+		if !models.IsValidApprovalRole(step.Role) {
+			return fmt.Errorf("%w: invalid role %q", ErrInvalidApprovalChain, step.Role)
+		}
+		if seen[step.Role] {
+			return fmt.Errorf("%w: duplicate role %q", ErrInvalidApprovalChain, step.Role)
+		}
+		seen[step.Role] = true
+		if strings.TrimSpace(step.Label) == "" {
+			return fmt.Errorf("%w: step %d needs a label", ErrInvalidApprovalChain, i+1)
+		}
+		if email := strings.TrimSpace(step.SignerEmail); email != "" {
+			if _, err := mail.ParseAddress(email); err != nil {
+				return fmt.Errorf("%w: invalid signer email for %q", ErrInvalidApprovalChain, step.Role)
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeApprovalSteps(steps []models.ApprovalStep) []models.ApprovalStep {
+	out := make([]models.ApprovalStep, len(steps))
+	for i, step := range steps {
+		out[i] = models.ApprovalStep{
+			Role:        models.SignRole(strings.TrimSpace(string(step.Role))),
+			Label:       strings.TrimSpace(step.Label),
+			SignerName:  strings.TrimSpace(step.SignerName),
+			SignerEmail: strings.TrimSpace(step.SignerEmail),
+			// the first step has nothing to run alongside
+			Parallel: step.Parallel && i > 0,
+		}
+	}
+	return out
+}
+
+// GetApprovalChainSteps returns the account's configured chain, or the default
+// chain with signer names and emails taken from the officials settings.
+func GetApprovalChainSteps(ctx context.Context, chains ApprovalChainStore, officials OfficialStore, accountID string) ([]models.ApprovalStep, error) {
+	if chains != nil {
+		chain, err := chains.FindByAccountID(ctx, accountID)
+		if err == nil && len(chain.Steps) > 0 {
+			return chain.Steps, nil
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	steps := DefaultApprovalSteps()
+	registrarName, directorName, _ := GetOfficialsFromDB(ctx, officials, accountID)
+	registrarEmail, directorEmail, _ := GetOfficialEmailsFromDB(ctx, officials, accountID)
+	steps[0].SignerName, steps[0].SignerEmail = registrarName, registrarEmail
+	steps[1].SignerName, steps[1].SignerEmail = directorName, directorEmail
+	return steps, nil
+}
+
+// SaveApprovalChain validates and stores the account's approval chain.
+func SaveApprovalChain(ctx context.Context, chains ApprovalChainStore, accountID string, steps []models.ApprovalStep) ([]models.ApprovalStep, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("approval chain store is not configured")
+	}
+	steps = normalizeApprovalSteps(steps)
+	if err := ValidateApprovalSteps(steps); err != nil {
+		return nil, err
+	}
+	if err := chains.Upsert(ctx, models.ApprovalChain{AccountID: accountID, Steps: steps, UpdatedAt: time.Now()}); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// approvalStepGroups splits steps into groups that may sign together. A step
+// marked Parallel joins the group of the step before it.
+func approvalStepGroups(steps []models.ApprovalStep) [][]models.ApprovalStep {
+	var groups [][]models.ApprovalStep
+	for i, step := range steps {
+		if i == 0 || !step.Parallel {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], step)
+	}
+	return groups
+}
+
+// FindApprovalStep returns the step for role, if the chain contains it.
+func FindApprovalStep(steps []models.ApprovalStep, role models.SignRole) (models.ApprovalStep, bool) {
+	for _, step := range steps {
+		if step.Role == role {
+			return step, true
+		}
+	}
+	return models.ApprovalStep{}, false
+}
+
+// OpenApprovalSteps returns the undecided steps that may sign now: those in the
+// first group that still has an undecided step.
+func OpenApprovalSteps(steps []models.ApprovalStep, decisions models.RequestDecisions) []models.ApprovalStep {
+	for _, group := range approvalStepGroups(steps) {
+		var open []models.ApprovalStep
+		for _, step := range group {
+			if decisions.ForRole(step.Role) == nil {
+				open = append(open, step)
+			}
+		}
+		if len(open) > 0 {
+			return open
+		}
+	}
+	return nil
+}
+
+// ensureApprovalStepOpen returns an error when role may not decide on the request yet.
+func ensureApprovalStepOpen(record *RequestRecord, role models.SignRole) error {
+	if status := CurrentRequestStatus(record); !acceptsDecisions(status) {
+		return fmt.Errorf("%w: request is %s", ErrRequestClosed, status)
+	}
+	steps := RequestApprovalSteps(record)
+	if _, ok := FindApprovalStep(steps, role); !ok {
+		return fmt.Errorf("%w: %s", ErrRoleNotInChain, role)
+	}
+	// an official may replace their own decision while their group is still open
+	for _, group := range approvalStepGroups(steps) {
+		inGroup := false
+		decided := true
+		for _, step := range group {
+			if step.Role == role {
+				inGroup = true
+			}
+			if !hasDecision(record.Decisions.ForRole(step.Role), models.OfficialDecisionApprove) {
+				decided = false
+			}
+		}
+		if inGroup {
+			return nil
+		}
+		if !decided {
+			return fmt.Errorf("%w: %s", ErrApprovalStepNotOpen, role)
+		}
+	}
+	return nil
+}
+
+// ApprovalStepLabel returns the printed title for role on the request.
+func ApprovalStepLabel(record *RequestRecord, role models.SignRole) string {
+	if step, ok := FindApprovalStep(RequestApprovalSteps(record), role); ok && step.Label != "" {
+		return step.Label
+	}
+	return signRoleLabel(role)
+}
+
+// ApprovalStepSignerEmail returns the email a step's sign link goes to. Registrar
+// and director steps without their own email fall back to the officials settings.
+func ApprovalStepSignerEmail(ctx context.Context, officials OfficialStore, step models.ApprovalStep, accountID string) string {
+	if email := strings.TrimSpace(step.SignerEmail); email != "" {
+		return email
+	}
+	registrarEmail, directorEmail, _ := GetOfficialEmailsFromDB(ctx, officials, accountID)
+	switch step.Role {
+	case models.SignRoleRegistrar:
+		return strings.TrimSpace(registrarEmail)
+	case models.SignRoleDirector:
+		return strings.TrimSpace(directorEmail)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/models"
+)
+
+func TestSaveApprovalChainValidatesSteps(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+
+	invalid := [][]models.ApprovalStep{
+		nil,
+		{{Role: "Homeroom", Label: "ครูประจำชั้น"}},
+		{{Role: models.SignRoleStudent, Label: "นักเรียน"}},
+		{{Role: models.SignRoleRegistrar, Label: "นายทะเบียน"}, {Role: models.SignRoleRegistrar, Label: "นายทะเบียน"}},
+		{{Role: models.SignRoleDirector, Label: ""}},
+		{{Role: models.SignRoleDirector, Label: "ผู้อำนวยการ", SignerEmail: "not-an-email"}},
+	}
+	for _, steps := range invalid {
+		if _, err := SaveApprovalChain(ctx, stores.ApprovalChains, "acct-1", steps); !errors.Is(err, ErrInvalidApprovalChain) {
+			t.Fatalf("expected ErrInvalidApprovalChain for %#v, got %v", steps, err)
+		}
+	}
+
+	steps, err := GetApprovalChainSteps(ctx, stores.ApprovalChains, stores.Officials, "acct-1")
+	if err != nil {
+		t.Fatalf("GetApprovalChainSteps returned error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Role != models.SignRoleRegistrar || steps[1].Role != models.SignRoleDirector {
+		t.Fatalf("expected default chain, got %#v", steps)
+	}
+
+	if _, err := SaveApprovalChain(ctx, stores.ApprovalChains, "acct-1", []models.ApprovalStep{
+		{Role: "homeroom_teacher", Label: " ครูประจำชั้น ", Parallel: true},
+		{Role: models.SignRoleDirector, Label: "ผู้อำนวยการ"},
+	}); err != nil {
+		t.Fatalf("SaveApprovalChain returned error: %v", err)
+	}
+	steps, err = GetApprovalChainSteps(ctx, stores.ApprovalChains, stores.Officials, "acct-1")
+	if err != nil {
+		t.Fatalf("GetApprovalChainSteps returned error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Label != "ครูประจำชั้น" || steps[0].Parallel {
+		t.Fatalf("unexpected saved chain: %#v", steps)
+	}
+}
+
+func TestCustomSequentialChainDrivesStatus(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	steps := []models.ApprovalStep{
+		{Role: "homeroom_teacher", Label: "ครูประจำชั้น"},
+		{Role: models.SignRoleRegistrar, Label: "นายทะเบียน"},
+	}
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		AccountID:    "acct-1",
+	}, steps)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	sig := models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw", SignedVia: "web"}
+	if err := UpsertSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, sig, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertSignature returned error: %v", err)
+	}
+	status, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, "127.0.0.1", "test", "acct-1")
+	if err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+	if status != models.RequestStatusAwaitingApproval {
+		t.Fatalf("status mismatch: got %q want %q", status, models.RequestStatusAwaitingApproval)
+	}
+
+	approve := models.OfficialDecision{Decision: models.OfficialDecisionApprove}
+	err = UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, sig, approve, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrApprovalStepNotOpen) {
+		t.Fatalf("expected ErrApprovalStepNotOpen, got %v", err)
+	}
+	err = UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleDirector, sig, approve, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrRoleNotInChain) {
+		t.Fatalf("expected ErrRoleNotInChain, got %v", err)
+	}
+
+	for _, step := range steps {
+		if err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, step.Role, sig, approve, "127.0.0.1", "test", "acct-1"); err != nil {
+			t.Fatalf("UpsertOfficialDecisionAndSignature(%s) returned error: %v", step.Role, err)
+		}
+		status, err = RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, step.Role, "127.0.0.1", "test", "acct-1")
+		if err != nil {
+			t.Fatalf("RecomputeRequestStatus(%s) returned error: %v", step.Role, err)
+		}
+	}
+	if status != models.RequestStatusApproved {
+		t.Fatalf("status mismatch: got %q want %q", status, models.RequestStatusApproved)
+	}
+
+	record, err := GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	if record.Signatures.ForRole("homeroom_teacher") == nil || record.Decisions.ForRole("homeroom_teacher") == nil {
+		t.Fatalf("expected custom step signature and decision, got %#v / %#v", record.Signatures, record.Decisions)
+	}
+}
@@ -8,21 +8,20 @@ import (
 
 	"backend/models"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // RecordAuditLog inserts an immutable audit log entry into the database.
-func RecordAuditLog(ctx context.Context, coll *mongo.Collection, logEntry models.AuditLog) error {
-	if coll == nil {
-		log.Printf("[AUDIT] Error: audit collection is nil")
-		return fmt.Errorf("audit collection is nil")
+func RecordAuditLog(ctx context.Context, store AuditStore, logEntry models.AuditLog) error {
+	if store == nil {
+		log.Printf("[AUDIT] Error: audit store is nil")
+		return fmt.Errorf("audit store is nil")
 	}
 	if logEntry.Timestamp.IsZero() {
 		logEntry.Timestamp = time.Now().UTC()
 	}
 	log.Printf("[AUDIT] Recording event: Action=%s, Role=%s, Hash=%s", logEntry.Action, logEntry.Role, logEntry.DocumentHash)
-	_, err := coll.InsertOne(ctx, logEntry)
+	err := store.Insert(ctx, logEntry)
 	if err != nil {
 		log.Printf("[AUDIT] Insert Error: %v", err)
 	}
@@ -30,22 +29,41 @@ func RecordAuditLog(ctx context.Context, coll *mongo.Collection, logEntry models
 }
 
 // GetAuditLogsByHash retrieves all audit logs associated with a specific document hash.
-func GetAuditLogsByHash(ctx context.Context, coll *mongo.Collection, hash string) ([]models.AuditLog, error) {
-	if coll == nil {
-		return nil, fmt.Errorf("audit collection is nil")
+func GetAuditLogsByHash(ctx context.Context, store AuditStore, hash string) ([]models.AuditLog, error) {
+	if store == nil {
+		return nil, fmt.Errorf("audit store is nil")
 	}
 	log.Printf("[AUDIT] Searching for hash: %s", hash)
-	cursor, err := coll.Find(ctx, bson.M{"document_hash": hash})
+	logs, err := store.FindByHash(ctx, hash)
 	if err != nil {
 		log.Printf("[AUDIT] Search Error: %v", err)
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-
-	var logs []models.AuditLog
-	if err := cursor.All(ctx, &logs); err != nil {
-		return nil, err
-	}
 	log.Printf("[AUDIT] Found %d logs for hash", len(logs))
 	return logs, nil
 }
+
+// ListRequestAuditLogs returns one page of the audit trail of an account's
+// request, oldest first, starting after cursor (empty for the first page). It
+// returns the cursor of the next page, or "" when this page is the last.
+func ListRequestAuditLogs(ctx context.Context, requests RequestStore, audits AuditStore, requestID primitive.ObjectID, accountID, cursor string, limit int) ([]models.AuditLog, string, error) {
+	after, err := DecodePageCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := requests.FindByID(ctx, requestID, accountID); err != nil {
+		return nil, "", err
+	}
+
+	logs, err := audits.ListByRequestID(ctx, requestID, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(logs) > limit {
+		logs = logs[:limit]
+		last := logs[limit-1]
+		next = PageCursor{At: last.Timestamp, ID: last.ID}.Encode()
+	}
+	return logs, next, nil
+}
@@ -254,6 +254,8 @@ type sessionJWTPayload struct {
 	Iat            int64    `json:"iat,omitempty"`
 	SessionExp     int64    `json:"sessionExp,omitempty"`
 	LogoutHandleID string   `json:"logoutHandleId,omitempty"`
+	// Email is only set when the identity provider verified it.
+	Email string `json:"email,omitempty"`
 }
 
 // OIDCTransactionPayload is signed and stored in the oidc_tx cookie during the auth flow.
@@ -281,6 +283,9 @@ type SessionClaims struct {
 	Iat            int64
 	SessionExp     int64
 	LogoutHandleID string
+	// Email is the user's verified email address, or empty when the identity
+	// provider did not verify one. Officials are identified by it.
+	Email string
 }
 
 type sessionContext struct {
@@ -292,6 +297,7 @@ type sessionContext struct {
 	DisplayName string
 	Scope       string
 	Scopes      []string
+	Email       string
 }
 
 func normalizeSessionExp(exp, sessionExp int64) int64 {
@@ -347,6 +353,7 @@ func canonicalSessionContextFromClaims(claims *SessionClaims) sessionContext {
 		DisplayName: strings.TrimSpace(claims.DisplayName),
 		Scope:       strings.TrimSpace(claims.Scope),
 		Scopes:      canonicalSessionScopes(scopes),
+		Email:       strings.TrimSpace(claims.Email),
 	}
 
 	if ctx.Subject == "" {
@@ -438,6 +445,7 @@ func issueSessionJWT(
 		DisplayName: ctx.DisplayName,
 		Scope:       ctx.Scope,
 		Scopes:      ctx.Scopes,
+		Email:       ctx.Email,
 	})
 
 	if expiresIn <= 0 {
@@ -474,6 +482,7 @@ func issueSessionJWT(
 		Iat:            issuedAt,
 		SessionExp:     sessionExp,
 		LogoutHandleID: strings.TrimSpace(logoutHandleID),
+		Email:          ctx.Email,
 	}
 	return buildJWT(header, payload, authSecret)
 }
@@ -553,9 +562,19 @@ func IssueSessionJWT(authSecret, sub, username, accountID string, expiresIn int)
 // IssueSessionJWTWithLogoutHandle creates an HMAC-SHA256 signed session JWT and preserves
 // the opaque logout handle reference needed for RP-initiated logout.
 func IssueSessionJWTWithLogoutHandle(authSecret, sub, username, accountID, logoutHandleID string, expiresIn int) (string, error) {
+	return IssueSessionJWTWithVerifiedEmail(authSecret, sub, username, accountID, "", logoutHandleID, expiresIn)
+}
+
+// IssueSessionJWTWithVerifiedEmail is IssueSessionJWTWithLogoutHandle for a login
+// whose email address the identity provider verified. Only pass an email the
+// provider marked verified: it identifies the user as the official sign links
+// are addressed to.
+func IssueSessionJWTWithVerifiedEmail(authSecret, sub, username, accountID, email, logoutHandleID string, expiresIn int) (string, error) {
 	now := time.Now().Unix()
 	sessionExp := now + defaultSessionMaxAgeSeconds
-	return issueSessionJWT(authSecret, canonicalSessionContextFromLogin(sub, username, accountID), now, sessionExp, logoutHandleID, 1, expiresIn)
+	ctx := canonicalSessionContextFromLogin(sub, username, accountID)
+	ctx.Email = strings.TrimSpace(email)
+	return issueSessionJWT(authSecret, ctx, now, sessionExp, logoutHandleID, 1, expiresIn)
 }
 
 // IssueSessionJWTForSession creates a new access token while keeping the original
@@ -628,6 +647,7 @@ func VerifySessionJWTAllowExpired(authSecret, rawToken string) (*SessionClaims,
 		Iat:            payload.Iat,
 		SessionExp:     normalizeSessionExp(payload.Exp, payload.SessionExp),
 		LogoutHandleID: strings.TrimSpace(payload.LogoutHandleID),
+		Email:          strings.TrimSpace(payload.Email),
 	}
 	if claims.AuthSubject == "" {
 		claims.AuthSubject = claims.AccountID
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+const (
+	BlobDriverGridFS = "gridfs"
+	BlobDriverLocal  = "local"
+)
+
+// BlobStore keeps binary objects such as signature images outside the request
+// documents. Blobs are content-addressed: the ID is the hex SHA-256 of the data,
+// so storing the same bytes twice yields one blob. Blobs may be shared between
+// documents and are never deleted.
+type BlobStore interface {
+	// Put stores data and returns its ID. Storing existing data is a no-op.
+	Put(ctx context.Context, data []byte) (string, error)
+	// Get returns the data of a blob, or ErrBlobNotFound.
+	Get(ctx context.Context, id string) ([]byte, error)
+}
+
+// BlobID returns the content-addressed ID of data.
+func BlobID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func validBlobID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// NewBlobStoreFromEnv picks the blob store from BLOB_DRIVER:
+// - gridfs (default): the `blobs` GridFS bucket of db
+// - local: files under BLOB_DIR (default "blobs")
+func NewBlobStoreFromEnv(db *mongo.Database) (BlobStore, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("BLOB_DRIVER")))
+	switch driver {
+	case "", BlobDriverGridFS:
+		return NewGridFSBlobStore(db), nil
+	case BlobDriverLocal:
+		dir := strings.TrimSpace(os.Getenv("BLOB_DIR"))
+		if dir == "" {
+			dir = "blobs"
+		}
+		return NewLocalBlobStore(dir), nil
+	default:
+		return nil, fmt.Errorf("unsupported BLOB_DRIVER: %q", driver)
+	}
+}
+
+// ─── GridFS ────────────────────────────────────────────────────────────────────
+
+type gridFSBlobStore struct {
+	db *mongo.Database
+}
+
+// NewGridFSBlobStore stores blobs in the `blobs` GridFS bucket, using the blob
+// ID as the file ID.
+func NewGridFSBlobStore(db *mongo.Database) BlobStore {
+	return &gridFSBlobStore{db: db}
+}
+
+// bucket opens a bucket bounded by the deadline of ctx. GridFS deadlines are set
+// per bucket, so each call gets its own.
+func (s *gridFSBlobStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(s.db, options.GridFSBucket().SetName("blobs"))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func (s *gridFSBlobStore) Put(ctx context.Context, data []byte) (string, error) {
+	id := BlobID(data)
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return "", err
+	}
+	count, err := bucket.GetFilesCollection().CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return id, nil
+	}
+	if err := bucket.UploadFromStreamWithID(id, id, bytes.NewReader(data)); err != nil {
+		// a concurrent upload of the same content won the race
+		if mongo.IsDuplicateKeyError(err) {
+			return id, nil
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *gridFSBlobStore) Get(ctx context.Context, id string) ([]byte, error) {
+	if !validBlobID(id) {
+		return nil, ErrBlobNotFound
+	}
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(id, &buf); err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ─── Local filesystem ──────────────────────────────────────────────────────────
+
+type localBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore stores blobs as files under dir, fanned out by the first two
+// characters of their ID.
+func NewLocalBlobStore(dir string) BlobStore {
+	return &localBlobStore{dir: dir}
+}
+
+func (s *localBlobStore) path(id string) string {
+	return filepath.Join(s.dir, id[:2], id)
+}
+
+func (s *localBlobStore) Put(_ context.Context, data []byte) (string, error) {
+	id := BlobID(data)
+	path := s.path(id)
+	if _, err := os.Stat(path); err == nil {
+		return id, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	// write to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), id+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *localBlobStore) Get(_ context.Context, id string) ([]byte, error) {
+	if !validBlobID(id) {
+		return nil, ErrBlobNotFound
+	}
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// ─── Memory ────────────────────────────────────────────────────────────────────
+
+type memoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memoryBlobStore) Put(_ context.Context, data []byte) (string, error) {
+	id := BlobID(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[id]; !ok {
+		s.blobs[id] = append([]byte(nil), data...)
+	}
+	return id, nil
+}
+
+func (s *memoryBlobStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.blobs[id]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrEmailNotFound = errors.New("email not found")
+
+const (
+	// emailOutboxMaxAttempts is how many deliveries are tried before an email is dead-lettered.
+	emailOutboxMaxAttempts = 6
+	emailOutboxBaseBackoff = 30 * time.Second
+	emailOutboxMaxBackoff  = time.Hour
+	// emailOutboxLease is how long a claimed email stays invisible to other workers;
+	// emails of a worker that died mid-send become due again once it expires.
+	emailOutboxLease        = 2 * time.Minute
+	emailOutboxPollInterval = 5 * time.Second
+	emailOutboxSendTimeout  = 60 * time.Second
+	maxEmailOutboxErrorLen  = 500
+	defaultEmailWorkers     = 2
+)
+
+// queueMailer is a Mailer that stores messages in the outbox for the workers to deliver.
+type queueMailer struct {
+	store EmailOutboxStore
+}
+
+// NewQueueMailer returns a Mailer whose Send only enqueues the message. Run
+// StartEmailOutboxWorkers with the real transport to deliver queued messages.
+func NewQueueMailer(store EmailOutboxStore) Mailer {
+	return queueMailer{store: store}
+}
+
+func (m queueMailer) Send(ctx context.Context, msg MailMessage) error {
+	if err := validateMailMessage(msg); err != nil {
+		return err
+	}
+	now := time.Now()
+	entry := &models.EmailOutboxEntry{
+		AccountID:     msg.AccountID,
+		RequestID:     msg.RequestID,
+		Template:      msg.Template,
+		To:            strings.TrimSpace(msg.To),
+		Subject:       msg.Subject,
+		TextBody:      msg.Body,
+		HTMLBody:      msg.HTMLBody,
+		Status:        models.EmailOutboxPending,
+		MaxAttempts:   emailOutboxMaxAttempts,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := m.store.Insert(ctx, entry); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// emailRetryDelay is the wait after the given number of failed attempts:
+// 30s, 1m, 2m, 4m ... capped at one hour.
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailOutboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= emailOutboxMaxBackoff {
+			return emailOutboxMaxBackoff
+		}
+	}
+	return delay
+}
+
+// DeliverNextEmail claims one due outbox entry and sends it through transport.
+// It reports false when nothing was due. A failed send is rescheduled with
+// exponential backoff, or marked dead once MaxAttempts is reached; the send
+// error itself is returned so the caller can log it.
+func DeliverNextEmail(ctx context.Context, store EmailOutboxStore, transport Mailer, now time.Time) (bool, error) {
+	entry, err := store.ClaimDue(ctx, now, emailOutboxLease)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, emailOutboxSendTimeout)
+	sendErr := transport.Send(sendCtx, MailMessage{
+		To:        entry.To,
+		Subject:   entry.Subject,
+		Body:      entry.TextBody,
+		HTMLBody:  entry.HTMLBody,
+		AccountID: entry.AccountID,
+		RequestID: entry.RequestID,
+		Template:  entry.Template,
+	})
+	cancel()
+
+	attempts := entry.Attempts + 1
+	if sendErr == nil {
+		return true, store.MarkSent(ctx, entry.ID, attempts, time.Now())
+	}
+
+	maxAttempts := entry.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = emailOutboxMaxAttempts
+	}
+	status := models.EmailOutboxFailed
+	nextAttemptAt := now.Add(emailRetryDelay(attempts))
+	if attempts >= maxAttempts {
+		status = models.EmailOutboxDead
+	}
+	lastError := sendErr.Error()
+	if len(lastError) > maxEmailOutboxErrorLen {
+		lastError = lastError[:maxEmailOutboxErrorLen]
+	}
+	if err := store.MarkFailed(ctx, entry.ID, status, attempts, lastError, nextAttemptAt, time.Now()); err != nil {
+		return true, err
+	}
+	return true, fmt.Errorf("email %s attempt %d/%d (%s): %w", entry.ID.Hex(), attempts, maxAttempts, status, sendErr)
+}
+
+// EmailOutboxWorkersFromEnv reads the delivery worker count from MAIL_WORKERS (default 2).
+func EmailOutboxWorkersFromEnv() int {
+	raw := strings.TrimSpace(os.Getenv("MAIL_WORKERS"))
+	if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+		return n
+	}
+	if raw != "" {
+		log.Printf("Warning: invalid MAIL_WORKERS %q, using %d", raw, defaultEmailWorkers)
+	}
+	return defaultEmailWorkers
+}
+
+// StartEmailOutboxWorkers runs n workers that deliver queued emails through
+// transport until ctx is cancelled. The returned WaitGroup completes once every
+// worker has stopped.
+func StartEmailOutboxWorkers(ctx context.Context, store EmailOutboxStore, transport Mailer, n int) *sync.WaitGroup {
+	if n <= 0 {
+		n = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				delivered, err := DeliverNextEmail(ctx, store, transport, time.Now())
+				if err != nil {
+					log.Printf("email outbox worker %d: %v", worker, err)
+				}
+				if delivered {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(emailOutboxPollInterval):
+				}
+			}
+		}(i)
+	}
+	return &wg
+}
+
+// ListRequestEmails returns the delivery state of every email queued for a request.
+func ListRequestEmails(ctx context.Context, store EmailOutboxStore, requestID primitive.ObjectID, accountID string) ([]models.EmailOutboxEntry, error) {
+	entries, err := store.ListByRequestID(ctx, requestID.Hex(), accountID)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.EmailOutboxEntry{}
+	}
+	return entries, nil
+}
+
+// RetryEmail puts a failed or dead email back in the queue with a fresh attempt budget.
+func RetryEmail(ctx context.Context, store EmailOutboxStore, emailID string, accountID string) (*models.EmailOutboxEntry, error) {
+	objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(emailID))
+	if err != nil {
+		return nil, ErrEmailNotFound
+	}
+	if err := store.Requeue(ctx, objectID, accountID, time.Now()); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrEmailNotFound
+		}
+		return nil, err
+	}
+	entry, err := store.FindByID(ctx, objectID, accountID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrEmailNotFound
+	}
+	return entry, err
+}
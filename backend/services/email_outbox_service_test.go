@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// flakyMailer fails every send while down is true.
+type flakyMailer struct {
+	down bool
+	sent []MailMessage
+}
+
+func (m *flakyMailer) Send(_ context.Context, msg MailMessage) error {
+	if m.down {
+		return errors.New("smtp unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestEmailOutboxRetriesThenDeadLettersAndRequeues(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	requestID := primitive.NewObjectID()
+	record := &RequestRecord{ID: requestID, AccountID: "acct-1", Prefix: "นาย", Name: "สมชาย ใจดี", DocumentType: "ปพ.1", CreatedAt: time.Now()}
+
+	SetMailer(NewQueueMailer(stores.EmailOutbox))
+	t.Cleanup(func() { SetMailer(nil) })
+	if err := SendOfficialSignLink(ctx, models.EmailBranding{}, record, "นายทะเบียน", "registrar@example.com", "https://example.com/sign/abc", time.Now().Add(72*time.Hour)); err != nil {
+		t.Fatalf("SendOfficialSignLink returned error: %v", err)
+	}
+
+	emails, err := ListRequestEmails(ctx, stores.EmailOutbox, requestID, "acct-1")
+	if err != nil {
+		t.Fatalf("ListRequestEmails returned error: %v", err)
+	}
+	if len(emails) != 1 || emails[0].Status != models.EmailOutboxPending || emails[0].Template != EmailTemplateOfficialSignLink {
+		t.Fatalf("expected one pending sign link email, got %#v", emails)
+	}
+	if other, _ := ListRequestEmails(ctx, stores.EmailOutbox, requestID, "acct-2"); len(other) != 0 {
+		t.Fatalf("expected emails to be scoped by account, got %d", len(other))
+	}
+
+	transport := &flakyMailer{down: true}
+	now := time.Now()
+	for attempt := 1; attempt <= emailOutboxMaxAttempts; attempt++ {
+		delivered, err := DeliverNextEmail(ctx, stores.EmailOutbox, transport, now)
+		if !delivered || err == nil {
+			t.Fatalf("attempt %d: expected a failed delivery, got delivered=%v err=%v", attempt, delivered, err)
+		}
+		// not due again before the backoff elapses
+		if delivered, _ := DeliverNextEmail(ctx, stores.EmailOutbox, transport, now.Add(emailRetryDelay(attempt)-time.Second)); delivered {
+			t.Fatalf("attempt %d: retried before backoff elapsed", attempt)
+		}
+		now = now.Add(emailRetryDelay(attempt))
+	}
+
+	emails, _ = ListRequestEmails(ctx, stores.EmailOutbox, requestID, "acct-1")
+	if emails[0].Status != models.EmailOutboxDead || emails[0].Attempts != emailOutboxMaxAttempts || emails[0].LastError != "smtp unavailable" {
+		t.Fatalf("expected dead-lettered email, got %#v", emails[0])
+	}
+	if delivered, _ := DeliverNextEmail(ctx, stores.EmailOutbox, transport, now.Add(24*time.Hour)); delivered {
+		t.Fatal("dead emails must not be retried automatically")
+	}
+
+	if _, err := RetryEmail(ctx, stores.EmailOutbox, emails[0].ID.Hex(), "acct-2"); !errors.Is(err, ErrEmailNotFound) {
+		t.Fatalf("expected ErrEmailNotFound for another account, got %v", err)
+	}
+	retried, err := RetryEmail(ctx, stores.EmailOutbox, emails[0].ID.Hex(), "acct-1")
+	if err != nil {
+		t.Fatalf("RetryEmail returned error: %v", err)
+	}
+	if retried.Status != models.EmailOutboxPending || retried.Attempts != 0 {
+		t.Fatalf("expected requeued email, got %#v", retried)
+	}
+
+	transport.down = false
+	if delivered, err := DeliverNextEmail(ctx, stores.EmailOutbox, transport, time.Now()); !delivered || err != nil {
+		t.Fatalf("expected delivery after retry, got delivered=%v err=%v", delivered, err)
+	}
+	if len(transport.sent) != 1 || transport.sent[0].To != "registrar@example.com" || transport.sent[0].HTMLBody == "" {
+		t.Fatalf("unexpected sent messages: %#v", transport.sent)
+	}
+	emails, _ = ListRequestEmails(ctx, stores.EmailOutbox, requestID, "acct-1")
+	if emails[0].Status != models.EmailOutboxSent || emails[0].SentAt == nil {
+		t.Fatalf("expected sent email, got %#v", emails[0])
+	}
+	if _, err := RetryEmail(ctx, stores.EmailOutbox, emails[0].ID.Hex(), "acct-1"); !errors.Is(err, ErrEmailNotFound) {
+		t.Fatalf("expected sent emails to be rejected by RetryEmail, got %v", err)
+	}
+}
+
+func TestEmailOutboxReclaimsExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryEmailOutboxStore()
+	if err := NewQueueMailer(store).Send(ctx, MailMessage{To: "owner@example.com", Subject: "s", Body: "b"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := store.ClaimDue(ctx, now, emailOutboxLease); err != nil {
+		t.Fatalf("ClaimDue returned error: %v", err)
+	}
+	if _, err := store.ClaimDue(ctx, now.Add(time.Minute), emailOutboxLease); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected leased email to be hidden, got %v", err)
+	}
+	if _, err := store.ClaimDue(ctx, now.Add(emailOutboxLease+time.Second), emailOutboxLease); err != nil {
+		t.Fatalf("expected expired lease to be reclaimed, got %v", err)
+	}
+}
@@ -11,8 +11,6 @@ import (
 
 	"backend/models"
 
-	"mime"
-
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/googleapi"
@@ -60,16 +58,24 @@ func loadDelegatedGmailService(ctx context.Context) (*gmail.Service, string, err
 	return srv, delegate, nil
 }
 
-func sendRawEmail(_ context.Context, srv *gmail.Service, from, to, subject, body string) error {
-	encodedSubject := mime.BEncoding.Encode("utf-8", subject)
-	raw := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
-		from, to, encodedSubject, body)
+// gmailMailer sends through the Gmail API as GMAIL_DELEGATE_EMAIL using a
+// service account with domain-wide delegation.
+type gmailMailer struct{}
+
+func (gmailMailer) Send(ctx context.Context, msg MailMessage) error {
+	if err := validateMailMessage(msg); err != nil {
+		return err
+	}
+
+	srv, delegate, err := loadDelegatedGmailService(ctx)
+	if err != nil {
+		return err
+	}
 
-	encoded := base64.URLEncoding.EncodeToString([]byte(raw))
+	encoded := base64.URLEncoding.EncodeToString(buildRawMessage(delegate, msg, time.Now()))
 	encoded = strings.TrimRight(encoded, "=")
 
-	msg := &gmail.Message{Raw: encoded}
-	_, err := srv.Users.Messages.Send("me", msg).Do()
+	_, err = srv.Users.Messages.Send("me", &gmail.Message{Raw: encoded}).Context(ctx).Do()
 	if err != nil {
 		if gerr, ok := err.(*googleapi.Error); ok {
 			return fmt.Errorf("gmail api error: %d %s", gerr.Code, gerr.Message)
@@ -79,11 +85,9 @@ func sendRawEmail(_ context.Context, srv *gmail.Service, from, to, subject, body
 	return nil
 }
 
-// SendSubmissionNotification sends an email using a service account with domain-wide delegation.
+// SendSubmissionNotification sends an email through the configured Mailer (see NewMailerFromEnv).
 // Environment variables expected:
-// - GMAIL_SERVICE_ACCOUNT_JSON : path to JSON file or raw JSON content of the service account key
-// - GMAIL_DELEGATE_EMAIL       : the user email to impersonate (must be in same Workspace and DWD enabled)
-// - NOTIFY_TO                  : recipient email address
+// - NOTIFY_TO : recipient email address
 func SendSubmissionNotification(ctx context.Context, payload models.StudentData, insertedID interface{}) error {
 	select {
 	case <-ctx.Done():
@@ -97,22 +101,21 @@ func SendSubmissionNotification(ctx context.Context, payload models.StudentData,
 		return fmt.Errorf("NOTIFY_TO must be set")
 	}
 
-	srv, delegate, err := loadDelegatedGmailService(ctx)
-	if err != nil {
-		return err
+	data := EmailTemplateData{
+		RequestID:     requestIDString(insertedID),
+		RequesterName: strings.TrimSpace(payload.Prefix + payload.Name),
+		DocumentType:  payload.DocumentType,
+		StudentID:     payload.StudentID,
+		Purpose:       payload.Purpose,
+		SubmittedAt:   formatThaiDate(time.Now()),
 	}
-
-	// Compose subject and body in Thai (UTF-8)
-	subject := "ได้รับรายการใหม่จากการยื่นเอกสาร"
-	body := fmt.Sprintf("มีคำร้องใหม่ที่ถูกยื่นเข้ามา\n\nID: %v\nชื่อ: %s %s\nเอกสาร: %s\nรหัสนักศึกษา: %s\nวัตถุประสงค์: %s\nเวลาที่ยื่น: %s\n",
-		insertedID, payload.Prefix, payload.Name, payload.DocumentType, payload.StudentID, payload.Purpose, time.Now().Format(time.RFC1123))
-	return sendRawEmail(ctx, srv, delegate, notifyTo, subject, body)
+	return sendTemplatedMail(ctx, payload.AccountID, notifyTo, EmailTemplateOwnerSubmission, data)
 }
 
 // SendSubmissionNotificationByRequest sends a notification email to the form owner.
 // The recipient is request.AccountID, which equals the owner's OIDC email in this system.
 // If AccountID is not a valid email address, the function returns an error and no email is sent.
-func SendSubmissionNotificationByRequest(ctx context.Context, request *RequestRecord) error {
+func SendSubmissionNotificationByRequest(ctx context.Context, branding models.EmailBranding, request *RequestRecord) error {
 	if request == nil {
 		return fmt.Errorf("request is required")
 	}
@@ -128,29 +131,135 @@ func SendSubmissionNotificationByRequest(ctx context.Context, request *RequestRe
 	default:
 	}
 
-	srv, delegate, err := loadDelegatedGmailService(ctx)
-	if err != nil {
-		return err
-	}
-
-	subject := "ได้รับรายการใหม่จากการยื่นเอกสาร"
-	body := fmt.Sprintf("มีคำร้องใหม่ที่ถูกยื่นเข้ามา\n\nID: %v\nชื่อ: %s %s\nเอกสาร: %s\nรหัสนักศึกษา: %s\nวัตถุประสงค์: %s\nเวลาที่ยื่น: %s\n",
-		request.ID, request.Prefix, request.Name, request.DocumentType, request.StudentID, request.Purpose, time.Now().Format(time.RFC1123))
-	return sendRawEmail(ctx, srv, delegate, notifyTo, subject, body)
+	return sendTemplatedMail(ctx, request.AccountID, notifyTo, EmailTemplateOwnerSubmission, requestTemplateData(branding, request))
 }
 
 // SendOfficialSignLink sends a signing URL to one official recipient.
-func SendOfficialSignLink(ctx context.Context, role, toEmail, signURL string, requestID interface{}) error {
+// The email states the real remaining lifetime of the link from expiresAt.
+func SendOfficialSignLink(ctx context.Context, branding models.EmailBranding, request *RequestRecord, roleLabel, toEmail, signURL string, expiresAt time.Time) error {
+	return sendOfficialSignLinkMail(ctx, branding, request, roleLabel, toEmail, signURL, expiresAt, false)
+}
+
+// SendOfficialSignLinkReminder resends a still unsigned sign link to its official.
+func SendOfficialSignLinkReminder(ctx context.Context, branding models.EmailBranding, request *RequestRecord, roleLabel, toEmail, signURL string, expiresAt time.Time) error {
+	return sendOfficialSignLinkMail(ctx, branding, request, roleLabel, toEmail, signURL, expiresAt, true)
+}
+
+func sendOfficialSignLinkMail(ctx context.Context, branding models.EmailBranding, request *RequestRecord, roleLabel, toEmail, signURL string, expiresAt time.Time, reminder bool) error {
 	if strings.TrimSpace(toEmail) == "" {
 		return fmt.Errorf("recipient email is required")
 	}
+	if request == nil {
+		return fmt.Errorf("request is required")
+	}
 
-	srv, delegate, err := loadDelegatedGmailService(ctx)
+	data := requestTemplateData(branding, request)
+	data.RoleLabel = roleLabel
+	data.ActionURL = signURL
+	data.ExpiresAt = formatThaiDate(expiresAt)
+	data.ExpiresIn = formatExpiresIn(expiresAt, time.Now())
+	data.Reminder = reminder
+	return sendTemplatedMail(ctx, request.AccountID, toEmail, EmailTemplateOfficialSignLink, data)
+}
+
+// SendSignLinkExpiringNotification warns the form owner that a sign link is about
+// to expire without being signed.
+func SendSignLinkExpiringNotification(ctx context.Context, branding models.EmailBranding, request *RequestRecord, roleLabel, signerEmail string, expiresAt time.Time) error {
+	if request == nil {
+		return fmt.Errorf("request is required")
+	}
+
+	notifyTo := strings.TrimSpace(request.AccountID)
+	if !strings.Contains(notifyTo, "@") {
+		return fmt.Errorf("owner email not available for account: %q", request.AccountID)
+	}
+
+	data := requestTemplateData(branding, request)
+	data.RoleLabel = roleLabel
+	data.SignerEmail = signerEmail
+	data.ExpiresAt = formatThaiDate(expiresAt)
+	data.ExpiresIn = formatExpiresIn(expiresAt, time.Now())
+	return sendTemplatedMail(ctx, request.AccountID, notifyTo, EmailTemplateOwnerLinkExpiring, data)
+}
+
+// SendRequestRejectedNotification tells the form owner that an official rejected a request.
+func SendRequestRejectedNotification(ctx context.Context, branding models.EmailBranding, request *RequestRecord, roleLabel string) error {
+	if request == nil || request.Rejection == nil {
+		return fmt.Errorf("rejected request is required")
+	}
+
+	notifyTo := strings.TrimSpace(request.AccountID)
+	if !strings.Contains(notifyTo, "@") {
+		return fmt.Errorf("owner email not available for account: %q", request.AccountID)
+	}
+
+	data := requestTemplateData(branding, request)
+	data.RoleLabel = roleLabel
+	data.Rejected = true
+	data.Reason = request.Rejection.Reason
+	return sendTemplatedMail(ctx, request.AccountID, notifyTo, EmailTemplateOwnerRejection, data)
+}
+
+// RequesterNotice identifies which status update is emailed to the requester.
+type RequesterNotice string
+
+const (
+	RequesterNoticeSubmitted RequesterNotice = "submitted"
+	RequesterNoticeDecision  RequesterNotice = "decision"
+	RequesterNoticeCompleted RequesterNotice = "completed"
+	RequesterNoticeCancelled RequesterNotice = "cancelled"
+)
+
+// requesterNotificationData picks the template and data of a requester email.
+// role is the deciding approval step and is only used for decision notices.
+func requesterNotificationData(branding models.EmailBranding, request *RequestRecord, notice RequesterNotice, role models.SignRole, statusURL string) (string, EmailTemplateData, error) {
+	data := requestTemplateData(branding, request)
+	data.ActionURL = statusURL
+
+	switch notice {
+	case RequesterNoticeSubmitted:
+		return EmailTemplateRequesterSubmitted, data, nil
+	case RequesterNoticeDecision:
+		decision := request.Decisions.ForRole(role)
+		if decision == nil && request.Rejection != nil && request.Rejection.Role == role {
+			// staff rejections through the status endpoint carry no official decision
+			decision = &models.OfficialDecision{Decision: models.OfficialDecisionReject, Reason: request.Rejection.Reason}
+		}
+		if decision == nil {
+			return "", data, fmt.Errorf("no decision recorded for role: %s", role)
+		}
+		data.RoleLabel = ApprovalStepLabel(request, role)
+		data.Rejected = decision.Decision == models.OfficialDecisionReject
+		data.Reason = decision.Reason
+		data.Comment = decision.Comment
+		return EmailTemplateRequesterDecision, data, nil
+	case RequesterNoticeCompleted:
+		return EmailTemplateRequesterCompleted, data, nil
+	case RequesterNoticeCancelled:
+		return EmailTemplateRequesterCancelled, data, nil
+	default:
+		return "", data, fmt.Errorf("unsupported requester notice: %s", notice)
+	}
+}
+
+// SendRequesterNotification emails the requester a status update with a link to the
+// tracking page. Requests without a requester email are skipped without error.
+func SendRequesterNotification(ctx context.Context, requests RequestStore, branding models.EmailBranding, request *RequestRecord, notice RequesterNotice, role models.SignRole, publicBaseURL string) error {
+	if request == nil {
+		return fmt.Errorf("request is required")
+	}
+	notifyTo := strings.TrimSpace(request.RequesterEmail)
+	if notifyTo == "" {
+		return nil
+	}
+
+	trackingToken, err := TrackingTokenForRequest(ctx, requests, request)
 	if err != nil {
 		return err
 	}
-
-	subject := "ลิงก์ลงนามเอกสารคำร้อง"
-	body := fmt.Sprintf("เรียนเจ้าหน้าที่ (%s)\n\nกรุณาลงนามคำร้องเลขที่: %v\nลิงก์ลงนาม: %s\n\nลิงก์นี้จะหมดอายุภายใน 7 วัน\n", role, requestID, signURL)
-	return sendRawEmail(ctx, srv, delegate, toEmail, subject, body)
+	name, data, err := requesterNotificationData(branding, request, notice, role, TrackingURL(publicBaseURL, trackingToken))
+	if err != nil {
+		return err
+	}
+	return sendTemplatedMail(ctx, request.AccountID, notifyTo, name, data)
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestRequesterNotificationLinksToTrackingPage(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{
+		Prefix:         "นางสาว",
+		Name:           "สมหญิง ใจงาม",
+		DocumentType:   "ปพ.1",
+		AccountID:      "acct-1",
+		RequesterEmail: "student@example.com",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	record, err := GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+
+	// legacy requests get a token on first use; later calls rebuild the same one
+	token, err := TrackingTokenForRequest(ctx, stores.Requests, record)
+	if err != nil {
+		t.Fatalf("TrackingTokenForRequest returned error: %v", err)
+	}
+	record, _ = GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	again, err := TrackingTokenForRequest(ctx, stores.Requests, record)
+	if err != nil {
+		t.Fatalf("TrackingTokenForRequest returned error: %v", err)
+	}
+	if again != token {
+		t.Fatalf("token mismatch: got %q want %q", again, token)
+	}
+	if tracked, err := GetRequestByTrackingToken(ctx, stores.Requests, token); err != nil || tracked.RequesterEmail != "student@example.com" {
+		t.Fatalf("GetRequestByTrackingToken returned %#v, %v", tracked, err)
+	}
+
+	statusURL := TrackingURL("https://example.com", token)
+	record.Decisions.Registrar = &models.OfficialDecision{Decision: models.OfficialDecisionReject, Reason: "ข้อมูลไม่ครบ", Comment: "ติดต่อห้องทะเบียน"}
+	branding := models.EmailBranding{SchoolName: "โรงเรียนทดสอบ", Signature: "งานทะเบียน"}
+	render := func(notice RequesterNotice, role models.SignRole) (RenderedEmail, error) {
+		name, data, err := requesterNotificationData(branding, record, notice, role, statusURL)
+		if err != nil {
+			return RenderedEmail{}, err
+		}
+		return RenderEmail(name, data)
+	}
+
+	rendered, err := render(RequesterNoticeDecision, models.SignRoleRegistrar)
+	if err != nil {
+		t.Fatalf("render decision returned error: %v", err)
+	}
+	for _, want := range []string{"นายทะเบียน", "ข้อมูลไม่ครบ", "ติดต่อห้องทะเบียน", statusURL, "โรงเรียนทดสอบ", "งานทะเบียน"} {
+		if !strings.Contains(rendered.Text, want) || !strings.Contains(rendered.HTML, want) {
+			t.Fatalf("expected both bodies to contain %q, got text %q", want, rendered.Text)
+		}
+	}
+	if rendered.Subject == "" {
+		t.Fatal("expected a subject")
+	}
+
+	if _, err := render(RequesterNoticeDecision, models.SignRoleDirector); err == nil {
+		t.Fatal("expected error for a role without a decision")
+	}
+	for _, notice := range []RequesterNotice{RequesterNoticeSubmitted, RequesterNoticeCompleted, RequesterNoticeCancelled} {
+		if rendered, err := render(notice, ""); err != nil || !strings.Contains(rendered.Text, statusURL) {
+			t.Fatalf("notice %s: body %q, err %v", notice, rendered.Text, err)
+		}
+	}
+}
+
+func TestEmailTemplatesRenderWithBranding(t *testing.T) {
+	branding := models.EmailBranding{SchoolName: "โรงเรียน <ทดสอบ>", LogoURL: "https://example.com/logo.png", Signature: "ฝ่ายทะเบียน"}
+	data := SampleEmailTemplateData(branding, "https://example.com")
+	for _, name := range EmailTemplateNames() {
+		rendered, err := RenderEmail(name, data)
+		if err != nil {
+			t.Fatalf("RenderEmail(%s) returned error: %v", name, err)
+		}
+		if rendered.Subject == "" || strings.Contains(rendered.Subject, "\n") {
+			t.Fatalf("%s: unexpected subject %q", name, rendered.Subject)
+		}
+		if !strings.Contains(rendered.HTML, "โรงเรียน &lt;ทดสอบ&gt;") || !strings.Contains(rendered.HTML, branding.LogoURL) {
+			t.Fatalf("%s: html misses escaped branding: %s", name, rendered.HTML)
+		}
+		if !strings.Contains(rendered.Text, "ฝ่ายทะเบียน") {
+			t.Fatalf("%s: text misses signature: %q", name, rendered.Text)
+		}
+	}
+	if _, err := RenderEmail("missing", data); !errors.Is(err, ErrEmailTemplateNotFound) {
+		t.Fatalf("expected ErrEmailTemplateNotFound, got %v", err)
+	}
+}
+
+func TestSignLinkEmailStatesRealExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	cases := map[time.Duration]string{
+		3 * 24 * time.Hour: "3 วัน",
+		5 * time.Hour:      "5 ชั่วโมง",
+		90 * time.Minute:   "2 ชั่วโมง",
+	}
+	for remaining, want := range cases {
+		if got := formatExpiresIn(now.Add(remaining), now); got != want {
+			t.Fatalf("formatExpiresIn(%v) mismatch: got %q want %q", remaining, got, want)
+		}
+	}
+
+	outbox := useOutboxMailer(t)
+	record := &RequestRecord{ID: "req-1", Prefix: "นาย", Name: "สมชาย ใจดี", DocumentType: "ปพ.1", CreatedAt: now}
+	if err := SendOfficialSignLink(context.Background(), models.EmailBranding{}, record, "ผู้อำนวยการ", "director@example.com", "https://example.com/sign/abc", time.Now().Add(72*time.Hour+time.Minute)); err != nil {
+		t.Fatalf("SendOfficialSignLink returned error: %v", err)
+	}
+	messages, err := outbox.Messages()
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d (%v)", len(messages), err)
+	}
+	if !strings.Contains(messages[0], "multipart/alternative") {
+		t.Fatalf("expected multipart message, got %q", messages[0])
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"backend/models"
+)
+
+// Email template names. Each name maps onto templates/email/<name>.tmpl, which
+// defines "subject", "text" and "content" (the HTML body inside the shared layout).
+const (
+	EmailTemplateOwnerSubmission    = "owner_submission"
+	EmailTemplateOfficialSignLink   = "official_sign_link"
+	EmailTemplateOwnerRejection     = "owner_rejection"
+	EmailTemplateOwnerLinkExpiring  = "owner_sign_link_expiring"
+	EmailTemplateRequesterSubmitted = "requester_submitted"
+	EmailTemplateRequesterDecision  = "requester_decision"
+	EmailTemplateRequesterCompleted = "requester_completed"
+	EmailTemplateRequesterCancelled = "requester_cancelled"
+)
+
+var (
+	ErrEmailTemplateNotFound = errors.New("email template not found")
+	ErrInvalidEmailBranding  = errors.New("invalid email branding")
+)
+
+const (
+	maxBrandingSchoolNameLength = 200
+	maxBrandingLogoURLLength    = 2048
+	maxBrandingSignatureLength  = 1000
+)
+
+//go:embed templates/email/*.tmpl
+var emailTemplateFS embed.FS
+
+// EmailTemplateData is the value every email template renders. Fields a template
+// does not need are left empty.
+type EmailTemplateData struct {
+	Branding      models.EmailBranding
+	RequestID     string
+	RequesterName string
+	DocumentType  string
+	StudentID     string
+	Purpose       string
+	SubmittedAt   string
+	RoleLabel     string
+	SignerEmail   string
+	ActionURL     string // sign link or tracking page
+	ExpiresAt     string
+	ExpiresIn     string
+	Reminder      bool // the sign link email is a reminder of an earlier one
+	Rejected      bool
+	Reason        string
+	Comment       string
+}
+
+// RenderedEmail is a rendered template ready to be sent as multipart/alternative.
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+type emailButton struct {
+	URL   string
+	Label string
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var emailTemplates = mustParseEmailTemplates()
+
+func mustParseEmailTemplates() map[string]emailTemplate {
+	funcs := map[string]any{
+		"button": func(u, label string) emailButton { return emailButton{URL: u, Label: label} },
+	}
+	names := []string{
+		EmailTemplateOwnerSubmission,
+		EmailTemplateOfficialSignLink,
+		EmailTemplateOwnerRejection,
+		EmailTemplateOwnerLinkExpiring,
+		EmailTemplateRequesterSubmitted,
+		EmailTemplateRequesterDecision,
+		EmailTemplateRequesterCompleted,
+		EmailTemplateRequesterCancelled,
+	}
+
+	templates := make(map[string]emailTemplate, len(names))
+	for _, name := range names {
+		files := []string{"templates/email/layout.tmpl", "templates/email/" + name + ".tmpl"}
+		text := texttemplate.Must(texttemplate.New(name).Funcs(funcs).ParseFS(emailTemplateFS, files...))
+		html := htmltemplate.Must(htmltemplate.New(name).Funcs(funcs).ParseFS(emailTemplateFS, files...))
+		templates[name] = emailTemplate{text: text, html: html}
+	}
+	return templates
+}
+
+// EmailTemplateNames lists every template that can be rendered or previewed.
+func EmailTemplateNames() []string {
+	names := make([]string, 0, len(emailTemplates))
+	for name := range emailTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderEmail renders the subject, plain-text and HTML bodies of a template.
+func RenderEmail(name string, data EmailTemplateData) (RenderedEmail, error) {
+	tmpl, ok := emailTemplates[name]
+	if !ok {
+		return RenderedEmail{}, fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return RenderedEmail{}, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return RenderedEmail{}, fmt.Errorf("render %s text: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return RenderedEmail{}, fmt.Errorf("render %s html: %w", name, err)
+	}
+	return RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// sendTemplatedMail renders a template and sends it through the configured Mailer.
+// accountID and data.RequestID tag the message so its delivery shows up on the request.
+func sendTemplatedMail(ctx context.Context, accountID, to, name string, data EmailTemplateData) error {
+	rendered, err := RenderEmail(name, data)
+	if err != nil {
+		return err
+	}
+	m, err := currentMailer()
+	if err != nil {
+		return err
+	}
+	return m.Send(ctx, MailMessage{
+		To:        to,
+		Subject:   rendered.Subject,
+		Body:      rendered.Text,
+		HTMLBody:  rendered.HTML,
+		AccountID: accountID,
+		RequestID: data.RequestID,
+		Template:  name,
+	})
+}
+
+// LoadEmailBranding returns the account's email branding with the school name
+// falling back to the officials settings. Missing settings yield empty branding.
+func LoadEmailBranding(ctx context.Context, officials OfficialStore, accountID string) models.EmailBranding {
+	if officials == nil {
+		return models.EmailBranding{}
+	}
+	doc, err := officials.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return models.EmailBranding{}
+	}
+	var branding models.EmailBranding
+	if doc.EmailBranding != nil {
+		branding = *doc.EmailBranding
+	}
+	if strings.TrimSpace(branding.SchoolName) == "" {
+		branding.SchoolName = doc.SchoolName
+	}
+	return branding
+}
+
+// SaveEmailBranding validates and stores an account's email branding.
+func SaveEmailBranding(ctx context.Context, officials OfficialStore, accountID string, branding models.EmailBranding) (models.EmailBranding, error) {
+	branding.SchoolName = strings.TrimSpace(branding.SchoolName)
+	branding.LogoURL = strings.TrimSpace(branding.LogoURL)
+	branding.Signature = strings.TrimSpace(branding.Signature)
+
+	if utf8.RuneCountInString(branding.SchoolName) > maxBrandingSchoolNameLength {
+		return models.EmailBranding{}, fmt.Errorf("%w: school_name is too long", ErrInvalidEmailBranding)
+	}
+	if utf8.RuneCountInString(branding.Signature) > maxBrandingSignatureLength {
+		return models.EmailBranding{}, fmt.Errorf("%w: signature is too long", ErrInvalidEmailBranding)
+	}
+	if branding.LogoURL != "" {
+		parsed, err := url.Parse(branding.LogoURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || len(branding.LogoURL) > maxBrandingLogoURLLength {
+			return models.EmailBranding{}, fmt.Errorf("%w: logo_url must be an http(s) URL", ErrInvalidEmailBranding)
+		}
+	}
+
+	if err := officials.SetEmailBranding(ctx, accountID, branding); err != nil {
+		return models.EmailBranding{}, err
+	}
+	return branding, nil
+}
+
+// formatThaiDate renders a date as "16 ตุลาคม 2569" (Buddhist year) in Asia/Bangkok.
+func formatThaiDate(t time.Time) string {
+	thaiMonths := []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน", "กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+	if loc, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		t = t.In(loc)
+	}
+	return fmt.Sprintf("%d %s %d เวลา %02d:%02d น.", t.Day(), thaiMonths[t.Month()-1], t.Year()+543, t.Hour(), t.Minute())
+}
+
+// formatExpiresIn describes how long until expiresAt, e.g. "7 วัน" or "5 ชั่วโมง".
+func formatExpiresIn(expiresAt, now time.Time) string {
+	remaining := expiresAt.Sub(now)
+	switch {
+	case remaining <= 0:
+		return "0 ชั่วโมง"
+	case remaining >= 24*time.Hour:
+		return fmt.Sprintf("%d วัน", int(math.Round(remaining.Hours()/24)))
+	default:
+		return fmt.Sprintf("%d ชั่วโมง", int(math.Ceil(remaining.Hours())))
+	}
+}
+
+// requestIDString renders a request id as plain hex so it matches the outbox request_id.
+func requestIDString(id interface{}) string {
+	if objectID, err := ToObjectID(id); err == nil {
+		return objectID.Hex()
+	}
+	return fmt.Sprint(id)
+}
+
+// requestTemplateData fills the request fields shared by every template.
+func requestTemplateData(branding models.EmailBranding, request *RequestRecord) EmailTemplateData {
+	return EmailTemplateData{
+		Branding:      branding,
+		RequestID:     requestIDString(request.ID),
+		RequesterName: strings.TrimSpace(request.Prefix + request.Name),
+		DocumentType:  request.DocumentType,
+		StudentID:     request.StudentID,
+		Purpose:       request.Purpose,
+		SubmittedAt:   formatThaiDate(request.CreatedAt),
+	}
+}
+
+// SampleEmailTemplateData returns realistic placeholder values for previews.
+func SampleEmailTemplateData(branding models.EmailBranding, publicBaseURL string) EmailTemplateData {
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, 7)
+	return EmailTemplateData{
+		Branding:      branding,
+		RequestID:     "000000000000000000000000",
+		RequesterName: "นางสาวสมหญิง ใจงาม",
+		DocumentType:  "ปพ.1",
+		StudentID:     "12345",
+		Purpose:       "ศึกษาต่อ",
+		SubmittedAt:   formatThaiDate(now),
+		RoleLabel:     "นายทะเบียน",
+		SignerEmail:   "registrar@example.com",
+		ActionURL:     strings.TrimRight(publicBaseURL, "/") + "/track/preview",
+		ExpiresAt:     formatThaiDate(expiresAt),
+		ExpiresIn:     formatExpiresIn(expiresAt, now),
+		Rejected:      true,
+		Reason:        "เอกสารประกอบไม่ครบถ้วน",
+		Comment:       "กรุณาติดต่อห้องทะเบียน",
+	}
+}
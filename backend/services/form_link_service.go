@@ -14,18 +14,26 @@ import (
 
 	"backend/models"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var (
-	ErrFormLinkNotFound = errors.New("form link not found")
-	ErrFormLinkRevoked  = errors.New("form link revoked")
+	ErrFormLinkNotFound      = errors.New("form link not found")
+	ErrFormLinkRevoked       = errors.New("form link revoked")
+	ErrFormLinkSecretMissing = errors.New("FORM_LINK_SECRET (or JWT_SECRET) must be set in production")
 )
 
 const fallbackFormLinkSecret = "dev-form-link-secret-change-me"
 
+// CheckFormLinkSecret fails in production when form link and tracking tokens
+// would be derived from the built-in development secret, which makes them forgeable.
+func CheckFormLinkSecret() error {
+	if os.Getenv("GO_ENV") == "production" && formLinkSecret() == fallbackFormLinkSecret {
+		return ErrFormLinkSecretMissing
+	}
+	return nil
+}
+
 func formLinkSecret() string {
 	secret := strings.TrimSpace(os.Getenv("FORM_LINK_SECRET"))
 	if secret == "" {
@@ -65,7 +73,7 @@ func nextTokenVersion(prev int64) int64 {
 	return next
 }
 
-func createFormLink(ctx context.Context, coll *mongo.Collection, accountID string) (*models.FormLink, string, error) {
+func createFormLink(ctx context.Context, store FormLinkStore, accountID string) (*models.FormLink, string, error) {
 	now := time.Now()
 	version := nextTokenVersion(0)
 	rawToken, err := buildFormLinkToken(accountID, version)
@@ -82,29 +90,24 @@ func createFormLink(ctx context.Context, coll *mongo.Collection, accountID strin
 		UpdatedAt:    now,
 	}
 
-	res, err := coll.InsertOne(ctx, record)
-	if err != nil {
+	if err := store.Insert(ctx, &record); err != nil {
 		return nil, "", err
 	}
-	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
-		record.ID = oid
-	}
 
 	return &record, rawToken, nil
 }
 
 // GetOrCreateActiveFormLink returns the active reusable public form link for an account.
-func GetOrCreateActiveFormLink(ctx context.Context, coll *mongo.Collection, accountID string) (*models.FormLink, string, error) {
+func GetOrCreateActiveFormLink(ctx context.Context, store FormLinkStore, accountID string) (*models.FormLink, string, error) {
 	account := strings.TrimSpace(accountID)
 	if account == "" {
 		return nil, "", fmt.Errorf("account id is required")
 	}
 
-	var record models.FormLink
-	err := coll.FindOne(ctx, bson.M{"account_id": account}).Decode(&record)
+	record, err := store.FindByAccountID(ctx, account)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return createFormLink(ctx, coll, account)
+		if errors.Is(err, ErrNotFound) {
+			return createFormLink(ctx, store, account)
 		}
 		return nil, "", err
 	}
@@ -122,17 +125,7 @@ func GetOrCreateActiveFormLink(ctx context.Context, coll *mongo.Collection, acco
 
 	shouldSync := record.TokenHash != expectedHash || record.Revoked
 	if shouldSync {
-		_, err = coll.UpdateOne(
-			ctx,
-			bson.M{"_id": record.ID},
-			bson.M{"$set": bson.M{
-				"token_hash":    expectedHash,
-				"token_version": record.TokenVersion,
-				"revoked":       false,
-				"updated_at":    now,
-			}},
-		)
-		if err != nil {
+		if err := store.SetToken(ctx, record.ID, record.TokenVersion, expectedHash, now); err != nil {
 			return nil, "", err
 		}
 		record.TokenHash = expectedHash
@@ -140,21 +133,20 @@ func GetOrCreateActiveFormLink(ctx context.Context, coll *mongo.Collection, acco
 		record.UpdatedAt = now
 	}
 
-	return &record, rawToken, nil
+	return record, rawToken, nil
 }
 
 // RotateFormLink regenerates the account's form link token and revokes prior token value.
-func RotateFormLink(ctx context.Context, coll *mongo.Collection, accountID string) (*models.FormLink, string, error) {
+func RotateFormLink(ctx context.Context, store FormLinkStore, accountID string) (*models.FormLink, string, error) {
 	account := strings.TrimSpace(accountID)
 	if account == "" {
 		return nil, "", fmt.Errorf("account id is required")
 	}
 
-	var record models.FormLink
-	err := coll.FindOne(ctx, bson.M{"account_id": account}).Decode(&record)
+	record, err := store.FindByAccountID(ctx, account)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return createFormLink(ctx, coll, account)
+		if errors.Is(err, ErrNotFound) {
+			return createFormLink(ctx, store, account)
 		}
 		return nil, "", err
 	}
@@ -167,17 +159,7 @@ func RotateFormLink(ctx context.Context, coll *mongo.Collection, accountID strin
 
 	now := time.Now()
 	newHash := tokenHash(rawToken)
-	_, err = coll.UpdateOne(
-		ctx,
-		bson.M{"_id": record.ID},
-		bson.M{"$set": bson.M{
-			"token_version": newVersion,
-			"token_hash":    newHash,
-			"revoked":       false,
-			"updated_at":    now,
-		}},
-	)
-	if err != nil {
+	if err := store.SetToken(ctx, record.ID, newVersion, newHash, now); err != nil {
 		return nil, "", err
 	}
 
@@ -185,41 +167,35 @@ func RotateFormLink(ctx context.Context, coll *mongo.Collection, accountID strin
 	record.TokenHash = newHash
 	record.Revoked = false
 	record.UpdatedAt = now
-	return &record, rawToken, nil
+	return record, rawToken, nil
 }
 
 // RevokeFormLink invalidates the active token for an account.
-func RevokeFormLink(ctx context.Context, coll *mongo.Collection, accountID string) error {
+func RevokeFormLink(ctx context.Context, store FormLinkStore, accountID string) error {
 	account := strings.TrimSpace(accountID)
 	if account == "" {
 		return fmt.Errorf("account id is required")
 	}
 
-	res, err := coll.UpdateOne(
-		ctx,
-		bson.M{"account_id": account},
-		bson.M{"$set": bson.M{"revoked": true, "updated_at": time.Now()}},
-	)
-	if err != nil {
+	if err := store.RevokeByAccountID(ctx, account, time.Now()); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrFormLinkNotFound
+		}
 		return err
 	}
-	if res.MatchedCount == 0 {
-		return ErrFormLinkNotFound
-	}
 	return nil
 }
 
 // GetFormLinkByRawToken resolves account scope from an opaque token.
-func GetFormLinkByRawToken(ctx context.Context, coll *mongo.Collection, rawToken string) (*models.FormLink, error) {
+func GetFormLinkByRawToken(ctx context.Context, store FormLinkStore, rawToken string) (*models.FormLink, error) {
 	token := strings.TrimSpace(rawToken)
 	if token == "" {
 		return nil, ErrFormLinkNotFound
 	}
 
-	var record models.FormLink
-	err := coll.FindOne(ctx, bson.M{"token_hash": tokenHash(token)}).Decode(&record)
+	record, err := store.FindByTokenHash(ctx, tokenHash(token))
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, ErrNotFound) {
 			return nil, ErrFormLinkNotFound
 		}
 		return nil, err
@@ -237,15 +213,9 @@ func GetFormLinkByRawToken(ctx context.Context, coll *mongo.Collection, rawToken
 		return nil, ErrFormLinkNotFound
 	}
 
-	return &record, nil
+	return record, nil
 }
 
-func TouchFormLinkUsed(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID) error {
-	now := time.Now()
-	_, err := coll.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"last_used_at": now, "updated_at": now}},
-	)
-	return err
+func TouchFormLinkUsed(ctx context.Context, store FormLinkStore, id primitive.ObjectID) error {
+	return store.TouchUsed(ctx, id, time.Now())
 }
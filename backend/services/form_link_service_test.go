@@ -0,0 +1,24 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckFormLinkSecretRefusesFallbackInProduction(t *testing.T) {
+	t.Setenv("FORM_LINK_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+
+	t.Setenv("GO_ENV", "development")
+	if err := CheckFormLinkSecret(); err != nil {
+		t.Fatalf("expected the development fallback outside production, got %v", err)
+	}
+	t.Setenv("GO_ENV", "production")
+	if err := CheckFormLinkSecret(); !errors.Is(err, ErrFormLinkSecretMissing) {
+		t.Fatalf("expected ErrFormLinkSecretMissing, got %v", err)
+	}
+	t.Setenv("JWT_SECRET", "a-real-secret")
+	if err := CheckFormLinkSecret(); err != nil {
+		t.Fatalf("CheckFormLinkSecret returned error: %v", err)
+	}
+}
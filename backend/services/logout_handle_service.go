@@ -12,9 +12,7 @@ import (
 
 	"backend/models"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var (
@@ -142,6 +140,13 @@ func parseIDTokenMetadata(rawToken string) (*idTokenMetadata, error) {
 	return meta, nil
 }
 
+func mapLogoutHandleNotFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return ErrLogoutHandleNotFound
+	}
+	return err
+}
+
 func parseLogoutHandleObjectID(handleID string) (primitive.ObjectID, error) {
 	trimmed := strings.TrimSpace(handleID)
 	if trimmed == "" {
@@ -154,9 +159,9 @@ func parseLogoutHandleObjectID(handleID string) (primitive.ObjectID, error) {
 	return objectID, nil
 }
 
-func CreateLogoutHandle(ctx context.Context, coll *mongo.Collection, accountID, sessionSubject, idToken, expectedClientID string, fallbackExpiry time.Time) (*models.LogoutHandle, error) {
-	if coll == nil {
-		return nil, fmt.Errorf("logout handle collection is not configured")
+func CreateLogoutHandle(ctx context.Context, store LogoutHandleStore, accountID, sessionSubject, idToken, expectedClientID string, fallbackExpiry time.Time) (*models.LogoutHandle, error) {
+	if store == nil {
+		return nil, fmt.Errorf("logout handle store is not configured")
 	}
 
 	accountID = strings.TrimSpace(accountID)
@@ -211,29 +216,25 @@ func CreateLogoutHandle(ctx context.Context, coll *mongo.Collection, accountID,
 		UpdatedAt:      now,
 	}
 
-	result, err := coll.InsertOne(ctx, record)
-	if err != nil {
+	if err := store.Insert(ctx, &record); err != nil {
 		return nil, err
 	}
-	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
-		record.ID = objectID
-	}
 
 	return &record, nil
 }
 
-func GetLogoutHandleByID(ctx context.Context, coll *mongo.Collection, handleID string) (*models.LogoutHandle, error) {
-	if coll == nil {
-		return nil, fmt.Errorf("logout handle collection is not configured")
+func GetLogoutHandleByID(ctx context.Context, store LogoutHandleStore, handleID string) (*models.LogoutHandle, error) {
+	if store == nil {
+		return nil, fmt.Errorf("logout handle store is not configured")
 	}
 	objectID, err := parseLogoutHandleObjectID(handleID)
 	if err != nil {
 		return nil, err
 	}
 
-	var record models.LogoutHandle
-	if err := coll.FindOne(ctx, bson.M{"_id": objectID}).Decode(&record); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+	record, err := store.FindByID(ctx, objectID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
 			return nil, ErrLogoutHandleNotFound
 		}
 		return nil, err
@@ -244,36 +245,24 @@ func GetLogoutHandleByID(ctx context.Context, coll *mongo.Collection, handleID s
 	if !record.ExpiresAt.IsZero() && time.Now().After(record.ExpiresAt) {
 		return nil, ErrLogoutHandleExpired
 	}
-	return &record, nil
+	return record, nil
 }
 
-func RevokeLogoutHandleByID(ctx context.Context, coll *mongo.Collection, handleID string) error {
-	if coll == nil {
-		return fmt.Errorf("logout handle collection is not configured")
+func RevokeLogoutHandleByID(ctx context.Context, store LogoutHandleStore, handleID string) error {
+	if store == nil {
+		return fmt.Errorf("logout handle store is not configured")
 	}
 	objectID, err := parseLogoutHandleObjectID(handleID)
 	if err != nil {
 		return err
 	}
 
-	now := time.Now()
-	result, err := coll.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
-	)
-	if err != nil {
-		return err
-	}
-	if result.MatchedCount == 0 {
-		return ErrLogoutHandleNotFound
-	}
-	return nil
+	return mapLogoutHandleNotFound(store.RevokeByID(ctx, objectID, time.Now()))
 }
 
-func RevokeLogoutHandlesBySID(ctx context.Context, coll *mongo.Collection, sid string) error {
-	if coll == nil {
-		return fmt.Errorf("logout handle collection is not configured")
+func RevokeLogoutHandlesBySID(ctx context.Context, store LogoutHandleStore, sid string) error {
+	if store == nil {
+		return fmt.Errorf("logout handle store is not configured")
 	}
 
 	normalizedSID := strings.TrimSpace(sid)
@@ -281,24 +270,12 @@ func RevokeLogoutHandlesBySID(ctx context.Context, coll *mongo.Collection, sid s
 		return ErrLogoutHandleNotFound
 	}
 
-	now := time.Now()
-	result, err := coll.UpdateMany(
-		ctx,
-		bson.M{"sid": normalizedSID},
-		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
-	)
-	if err != nil {
-		return err
-	}
-	if result.MatchedCount == 0 {
-		return ErrLogoutHandleNotFound
-	}
-	return nil
+	return mapLogoutHandleNotFound(store.RevokeBySID(ctx, normalizedSID, time.Now()))
 }
 
-func UpdateLogoutHandlesSessionVersionBySID(ctx context.Context, coll *mongo.Collection, sid string, sessionVersion int64) error {
-	if coll == nil {
-		return fmt.Errorf("logout handle collection is not configured")
+func UpdateLogoutHandlesSessionVersionBySID(ctx context.Context, store LogoutHandleStore, sid string, sessionVersion int64) error {
+	if store == nil {
+		return fmt.Errorf("logout handle store is not configured")
 	}
 
 	normalizedSID := strings.TrimSpace(sid)
@@ -306,23 +283,5 @@ func UpdateLogoutHandlesSessionVersionBySID(ctx context.Context, coll *mongo.Col
 		return ErrLogoutHandleNotFound
 	}
 
-	now := time.Now()
-	result, err := coll.UpdateMany(
-		ctx,
-		bson.M{
-			"sid": normalizedSID,
-			"$or": []bson.M{
-				{"session_version": bson.M{"$lt": sessionVersion}},
-				{"session_version": bson.M{"$exists": false}},
-			},
-		},
-		bson.M{"$set": bson.M{"session_version": sessionVersion, "updated_at": now}},
-	)
-	if err != nil {
-		return err
-	}
-	if result.MatchedCount == 0 {
-		return ErrLogoutHandleNotFound
-	}
-	return nil
+	return mapLogoutHandleNotFound(store.UpdateSessionVersionBySID(ctx, normalizedSID, sessionVersion, time.Now()))
 }
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailMessage is one outgoing email. The sender is chosen by the Mailer.
+type MailMessage struct {
+	To       string
+	Subject  string
+	Body     string // text/plain, UTF-8
+	HTMLBody string // optional; when set the message is multipart/alternative
+
+	// Outbox metadata used to show delivery state per request; transports ignore it.
+	AccountID string
+	RequestID string
+	Template  string
+}
+
+// Mailer delivers outgoing email through one transport.
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
+
+// Mail drivers selectable through MAIL_DRIVER.
+const (
+	MailDriverGmail  = "gmail"
+	MailDriverSMTP   = "smtp"
+	MailDriverOutbox = "outbox"
+)
+
+var (
+	mailerMu      sync.RWMutex
+	defaultMailer Mailer
+)
+
+// SetMailer replaces the transport used by every Send* email helper.
+func SetMailer(m Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	defaultMailer = m
+}
+
+func currentMailer() (Mailer, error) {
+	mailerMu.RLock()
+	m := defaultMailer
+	mailerMu.RUnlock()
+	if m != nil {
+		return m, nil
+	}
+	return NewMailerFromEnv()
+}
+
+// NewMailerFromEnv builds the Mailer named by MAIL_DRIVER (default gmail).
+// Environment variables per driver:
+// - gmail  : GMAIL_SERVICE_ACCOUNT_JSON, GMAIL_DELEGATE_EMAIL
+// - smtp   : SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM, SMTP_SECURITY (starttls | tls | none)
+// - outbox : MAIL_OUTBOX_DIR (default ./mail-outbox), MAIL_FROM
+func NewMailerFromEnv() (Mailer, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	switch driver {
+	case "", MailDriverGmail:
+		return gmailMailer{}, nil
+	case MailDriverSMTP:
+		return newSMTPMailerFromEnv()
+	case MailDriverOutbox:
+		dir := strings.TrimSpace(os.Getenv("MAIL_OUTBOX_DIR"))
+		if dir == "" {
+			dir = "mail-outbox"
+		}
+		return NewOutboxMailer(dir, os.Getenv("MAIL_FROM")), nil
+	default:
+		return nil, fmt.Errorf("unsupported MAIL_DRIVER: %q", driver)
+	}
+}
+
+// buildRawMessage renders an RFC 5322 message. Plain messages keep a bare UTF-8
+// text body; messages with HTMLBody become multipart/alternative with base64 parts.
+func buildRawMessage(from string, msg MailMessage, at time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTMLBody == "" {
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		buf.WriteString("\r\n")
+		buf.WriteString(msg.Body)
+		return buf.Bytes()
+	}
+
+	writer := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Body},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		w, _ := writer.CreatePart(header)
+		writeBase64Lines(w, []byte(part.body))
+	}
+	writer.Close()
+	return buf.Bytes()
+}
+
+// writeBase64Lines writes data as base64 wrapped at 76 characters per line (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
+
+func validateMailMessage(msg MailMessage) error {
+	to := strings.TrimSpace(msg.To)
+	if to == "" {
+		return fmt.Errorf("recipient email is required")
+	}
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid mail header value")
+	}
+	return nil
+}
+
+// ─── SMTP ──────────────────────────────────────────────────────────────────────
+
+type smtpMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	security string // starttls | tls | none
+}
+
+func newSMTPMailerFromEnv() (Mailer, error) {
+	host := strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	from := strings.TrimSpace(os.Getenv("SMTP_FROM"))
+	if host == "" || from == "" {
+		return nil, fmt.Errorf("SMTP_HOST and SMTP_FROM must be set")
+	}
+
+	security := strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_SECURITY")))
+	if security == "" {
+		security = "starttls"
+	}
+	defaultPort := 587
+	switch security {
+	case "starttls":
+	case "tls":
+		defaultPort = 465
+	case "none":
+		defaultPort = 25
+	default:
+		return nil, fmt.Errorf("unsupported SMTP_SECURITY: %q", security)
+	}
+
+	port := defaultPort
+	if raw := strings.TrimSpace(os.Getenv("SMTP_PORT")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 65535 {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %q", raw)
+		}
+		port = parsed
+	}
+
+	return smtpMailer{
+		host:     host,
+		port:     port,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     from,
+		security: security,
+	}, nil
+}
+
+func (m smtpMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	tlsConfig := &tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if m.security == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	if m.security == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (m smtpMailer) Send(ctx context.Context, msg MailMessage) error {
+	if err := validateMailMessage(msg); err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(strings.TrimSpace(msg.To)); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(buildRawMessage(m.from, msg, time.Now())); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write smtp message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	// the server accepted the message; failing here would make the outbox send it again
+	if err := client.Quit(); err != nil {
+		log.Printf("smtp QUIT failed after delivery to %s: %v", msg.To, err)
+	}
+	return nil
+}
+
+// ─── Outbox ────────────────────────────────────────────────────────────────────
+
+// OutboxMailer writes each message as an .eml file into a maildir-style
+// directory (tmp/ then new/) instead of sending it. Use it in development and tests.
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+// NewOutboxMailer returns an OutboxMailer rooted at dir.
+func NewOutboxMailer(dir, from string) *OutboxMailer {
+	if strings.TrimSpace(from) == "" {
+		from = "no-reply@localhost"
+	}
+	return &OutboxMailer{Dir: dir, From: from}
+}
+
+func (m *OutboxMailer) Send(_ context.Context, msg MailMessage) error {
+	if err := validateMailMessage(msg); err != nil {
+		return err
+	}
+
+	tmpDir := filepath.Join(m.Dir, "tmp")
+	newDir := filepath.Join(m.Dir, "new")
+	for _, dir := range []string{tmpDir, newDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create outbox directory: %w", err)
+		}
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%d.%s.eml", now.UnixNano(), hex.EncodeToString(suffix))
+
+	tmpPath := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(tmpPath, buildRawMessage(m.From, msg, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write outbox message: %w", err)
+	}
+	return os.Rename(tmpPath, filepath.Join(newDir, name))
+}
+
+// Messages returns the raw contents of every delivered outbox message, oldest first.
+func (m *OutboxMailer) Messages() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(m.Dir, "new"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	messages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		raw, err := os.ReadFile(filepath.Join(m.Dir, "new", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, string(raw))
+	}
+	return messages, nil
+}
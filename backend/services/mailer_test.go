@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// useOutboxMailer routes every email of the test into a temporary outbox.
+func useOutboxMailer(t *testing.T) *OutboxMailer {
+	t.Helper()
+	outbox := NewOutboxMailer(t.TempDir(), "records@example.com")
+	SetMailer(outbox)
+	t.Cleanup(func() { SetMailer(nil) })
+	return outbox
+}
+
+func TestNewMailerFromEnvSelectsDriver(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "outbox")
+	t.Setenv("MAIL_OUTBOX_DIR", t.TempDir())
+	if m, err := NewMailerFromEnv(); err != nil {
+		t.Fatalf("NewMailerFromEnv returned error: %v", err)
+	} else if _, ok := m.(*OutboxMailer); !ok {
+		t.Fatalf("expected outbox mailer, got %T", m)
+	}
+
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "")
+	if _, err := NewMailerFromEnv(); err == nil {
+		t.Fatal("expected error for smtp without host")
+	}
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "records@example.com")
+	t.Setenv("SMTP_SECURITY", "tls")
+	m, err := NewMailerFromEnv()
+	if err != nil {
+		t.Fatalf("NewMailerFromEnv returned error: %v", err)
+	}
+	if smtpM, ok := m.(smtpMailer); !ok || smtpM.port != 465 {
+		t.Fatalf("expected implicit TLS smtp mailer on 465, got %#v", m)
+	}
+
+	t.Setenv("MAIL_DRIVER", "carrier-pigeon")
+	if _, err := NewMailerFromEnv(); err == nil {
+		t.Fatal("expected error for unknown driver")
+	}
+}
+
+func TestOutboxMailerWritesMessages(t *testing.T) {
+	outbox := useOutboxMailer(t)
+	if err := outbox.Send(context.Background(), MailMessage{To: "registrar@example.com", Subject: "ลิงก์ลงนาม", Body: "https://example.com/sign/abc"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if err := outbox.Send(context.Background(), MailMessage{To: "bad\r\nBcc: x@example.com", Subject: "subject", Body: "body"}); err == nil {
+		t.Fatal("expected header injection to be rejected")
+	}
+
+	messages, err := outbox.Messages()
+	if err != nil {
+		t.Fatalf("Messages returned error: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	for _, want := range []string{"To: registrar@example.com", "From: records@example.com", "https://example.com/sign/abc"} {
+		if !strings.Contains(messages[0], want) {
+			t.Fatalf("expected message to contain %q, got %q", want, messages[0])
+		}
+	}
+}
+
+// serveSMTP answers one SMTP session on a local port, replying quitReply to
+// QUIT, and sends the DATA payload it receives on the returned channel.
+func serveSMTP(t *testing.T, quitReply string) (smtpMailer, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 queued")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply(quitReply)
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return smtpMailer{host: "127.0.0.1", port: addr.Port, from: "records@example.com", security: "none"}, received
+}
+
+func TestSMTPMailerDeliversPlainSession(t *testing.T) {
+	m, received := serveSMTP(t, "221 bye")
+	if err := m.Send(context.Background(), MailMessage{To: "student@example.com", Subject: "ทดสอบ", Body: "hello"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	raw := <-received
+	if !strings.Contains(raw, "To: student@example.com") || !strings.Contains(raw, "hello") {
+		t.Fatalf("unexpected smtp payload: %q", raw)
+	}
+}
+
+func TestSMTPMailerIgnoresQuitFailureAfterDelivery(t *testing.T) {
+	m, received := serveSMTP(t, "421 shutting down")
+	if err := m.Send(context.Background(), MailMessage{To: "student@example.com", Subject: "ทดสอบ", Body: "hello"}); err != nil {
+		t.Fatalf("expected a delivered message to count as sent, got %v", err)
+	}
+	if raw := <-received; !strings.Contains(raw, "hello") {
+		t.Fatalf("unexpected smtp payload: %q", raw)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNoPendingSignLink = errors.New("no pending sign link for this request")
+
+// MaxBulkSignRequests caps the requests one bulk sign may decide on.
+const MaxBulkSignRequests = 200
+
+// OfficialInboxItem is a request waiting for the signed-in official's decision.
+type OfficialInboxItem struct {
+	RequestID    string               `json:"request_id"`
+	SignLinkID   string               `json:"sign_link_id"`
+	Role         models.SignRole      `json:"role"`
+	RoleLabel    string               `json:"role_label"`
+	Prefix       string               `json:"prefix"`
+	Name         string               `json:"name"`
+	DocumentType string               `json:"document_type"`
+	StudentID    string               `json:"student_id"`
+	Class        string               `json:"class"`
+	Room         string               `json:"room"`
+	Purpose      string               `json:"purpose"`
+	Status       models.RequestStatus `json:"status"`
+	CreatedAt    time.Time            `json:"created_at"`
+	ExpiresAt    time.Time            `json:"expires_at"`
+
+	link    models.SignLink
+	request *RequestRecord
+}
+
+// BulkSignResult is the outcome of a bulk sign for one request. Err is nil when
+// the decision and signature were saved.
+type BulkSignResult struct {
+	RequestID primitive.ObjectID
+	Role      models.SignRole
+	AccountID string
+	Status    models.RequestStatus
+	Err       error
+}
+
+// matchesOfficialEmail reports whether a sign link was sent to one of emails.
+func matchesOfficialEmail(link models.SignLink, emails []string) bool {
+	recipient := strings.TrimSpace(link.RecipientEmail)
+	if recipient == "" {
+		return false
+	}
+	for _, email := range emails {
+		if strings.EqualFold(recipient, strings.TrimSpace(email)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListOfficialInbox returns the requests with an active sign link sent to one of
+// the official's emails whose approval step is open now, oldest request first.
+// Sign links are issued by the requesting account, so request lookups are unscoped;
+// only requests the official holds a link for are read.
+// When one request has links for several of the official's roles, only the first
+// open one is listed; signing it opens the next.
+func ListOfficialInbox(ctx context.Context, requests RequestStore, signLinks SignLinkStore, emails []string) ([]OfficialInboxItem, error) {
+	if len(emails) == 0 {
+		return []OfficialInboxItem{}, nil
+	}
+	links, err := signLinks.ListActiveByRecipient(ctx, emails, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	requestCache := map[primitive.ObjectID]*RequestRecord{}
+	listed := map[primitive.ObjectID]bool{}
+	items := []OfficialInboxItem{}
+	for _, link := range links {
+		if listed[link.RequestID] || !models.IsValidApprovalRole(link.Role) {
+			continue
+		}
+		request, ok := requestCache[link.RequestID]
+		if !ok {
+			request, err = requests.FindByIDUnscoped(ctx, link.RequestID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			requestCache[link.RequestID] = request
+		}
+		if request == nil || !approvalStepOpenNow(request, link.Role) {
+			continue
+		}
+
+		listed[link.RequestID] = true
+		items = append(items, OfficialInboxItem{
+			RequestID:    link.RequestID.Hex(),
+			SignLinkID:   link.ID.Hex(),
+			Role:         link.Role,
+			RoleLabel:    ApprovalStepLabel(request, link.Role),
+			Prefix:       request.Prefix,
+			Name:         request.Name,
+			DocumentType: request.DocumentType,
+			StudentID:    request.StudentID,
+			Class:        request.Class,
+			Room:         request.Room,
+			Purpose:      request.Purpose,
+			Status:       CurrentRequestStatus(request),
+			CreatedAt:    request.CreatedAt,
+			ExpiresAt:    link.ExpiresAt,
+			link:         link,
+			request:      request,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+// approvalStepOpenNow reports whether role is one of the undecided steps that may sign now.
+func approvalStepOpenNow(request *RequestRecord, role models.SignRole) bool {
+	if !acceptsDecisions(CurrentRequestStatus(request)) {
+		return false
+	}
+	for _, step := range OpenApprovalSteps(RequestApprovalSteps(request), request.Decisions) {
+		if step.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// BulkSignAsOfficial applies one signature and decision to each request in
+// requestIDs that is in the official's inbox. Every request is decided through
+// UpsertOfficialDecisionAndSignature, so each gets its own document hash and audit
+// entry, then its status is recomputed and its sign link marked used. A failure
+// on one request is reported in its result and does not stop the others.
+func BulkSignAsOfficial(ctx context.Context, requests RequestStore, audits AuditStore, signLinks SignLinkStore, emails []string, requestIDs []primitive.ObjectID, sig models.SignatureBlock, decision models.OfficialDecision, ipAddress, userAgent string) ([]BulkSignResult, error) {
+	if len(requestIDs) == 0 {
+		return nil, fmt.Errorf("at least one request is required")
+	}
+	if len(requestIDs) > MaxBulkSignRequests {
+		return nil, fmt.Errorf("at most %d requests can be signed at once", MaxBulkSignRequests)
+	}
+
+	inbox, err := ListOfficialInbox(ctx, requests, signLinks, emails)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[primitive.ObjectID]OfficialInboxItem, len(inbox))
+	for _, item := range inbox {
+		pending[item.link.RequestID] = item
+	}
+
+	results := make([]BulkSignResult, 0, len(requestIDs))
+	seen := map[primitive.ObjectID]bool{}
+	for _, requestID := range requestIDs {
+		if seen[requestID] {
+			continue
+		}
+		seen[requestID] = true
+
+		result := BulkSignResult{RequestID: requestID}
+		item, ok := pending[requestID]
+		if !ok {
+			result.Err = ErrNoPendingSignLink
+			results = append(results, result)
+			continue
+		}
+		result.Role = item.link.Role
+		result.AccountID = item.request.AccountID
+
+		if err := UpsertOfficialDecisionAndSignature(ctx, requests, audits, requestID, item.link.Role, sig, decision, ipAddress, userAgent, item.request.AccountID); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		result.Status, result.Err = RecomputeRequestStatus(ctx, requests, audits, requestID, item.link.Role, ipAddress, userAgent, item.request.AccountID)
+		if result.Err == nil {
+			if err := MarkSignLinkUsed(ctx, signLinks, item.link.ID); err != nil {
+				log.Printf("failed to mark sign link used: %v", err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
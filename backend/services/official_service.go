@@ -4,10 +4,6 @@ import (
 	"context"
 
 	"backend/models"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // GetOfficials returns default dummy names. Do NOT read from .env here.
@@ -20,13 +16,11 @@ func GetOfficials() (registrar string, director string) {
 }
 
 // GetOfficialEmailsFromDB reads optional official email fields.
-func GetOfficialEmailsFromDB(ctx context.Context, coll *mongo.Collection, accountID string) (registrarEmail string, directorEmail string, err error) {
-	if coll == nil {
+func GetOfficialEmailsFromDB(ctx context.Context, store OfficialStore, accountID string) (registrarEmail string, directorEmail string, err error) {
+	if store == nil {
 		return "", "", nil
 	}
-	var doc models.Official
-	filter := bson.M{"account_id": accountID}
-	err = coll.FindOne(ctx, filter).Decode(&doc)
+	doc, err := store.FindByAccountID(ctx, accountID)
 	if err != nil {
 		return "", "", nil
 	}
@@ -34,30 +28,26 @@ func GetOfficialEmailsFromDB(ctx context.Context, coll *mongo.Collection, accoun
 }
 
 // GetSchoolInfoFromDB reads optional school metadata fields.
-func GetSchoolInfoFromDB(ctx context.Context, coll *mongo.Collection, accountID string) (schoolName string, schoolAddress string, err error) {
-	if coll == nil {
+func GetSchoolInfoFromDB(ctx context.Context, store OfficialStore, accountID string) (schoolName string, schoolAddress string, err error) {
+	if store == nil {
 		return "", "", nil
 	}
-	var doc models.Official
-	filter := bson.M{"account_id": accountID}
-	err = coll.FindOne(ctx, filter).Decode(&doc)
+	doc, err := store.FindByAccountID(ctx, accountID)
 	if err != nil {
 		return "", "", nil
 	}
 	return doc.SchoolName, doc.SchoolAddress, nil
 }
 
-// GetOfficialsFromDB tries to load officials from the provided collection.
+// GetOfficialsFromDB tries to load officials from the provided store.
 // It uses accountID to scope the lookup.
-// If the collection is nil or the document is not found, it returns empty strings and a nil error
+// If the store is nil or the document is not found, it returns empty strings and a nil error
 // so callers can decide to use GetOfficials() as fallback.
-func GetOfficialsFromDB(ctx context.Context, coll *mongo.Collection, accountID string) (registrar string, director string, err error) {
-	if coll == nil {
+func GetOfficialsFromDB(ctx context.Context, store OfficialStore, accountID string) (registrar string, director string, err error) {
+	if store == nil {
 		return "", "", nil
 	}
-	var doc models.Official
-	filter := bson.M{"account_id": accountID}
-	err = coll.FindOne(ctx, filter).Decode(&doc)
+	doc, err := store.FindByAccountID(ctx, accountID)
 	if err != nil {
 		// return empty on not found / error so caller can fallback to dummy
 		return "", "", nil
@@ -67,25 +57,18 @@ func GetOfficialsFromDB(ctx context.Context, coll *mongo.Collection, accountID s
 
 // SaveOfficialsToDB saves or updates the officials data in the database.
 // It uses upsert to create the document if it doesn't exist or update if it does.
-func SaveOfficialsToDB(ctx context.Context, coll *mongo.Collection, accountID, registrarName, directorName, registrarEmail, directorEmail, schoolName, schoolAddress string) error {
-	if coll == nil {
-		return nil // No collection to save to
-	}
-
-	filter := bson.M{"account_id": accountID}
-	update := bson.M{
-		"$set": bson.M{
-			"account_id":      accountID,
-			"registrar_name":  registrarName,
-			"director_name":   directorName,
-			"registrar_email": registrarEmail,
-			"director_email":  directorEmail,
-			"school_name":     schoolName,
-			"school_address":  schoolAddress,
-		},
+func SaveOfficialsToDB(ctx context.Context, store OfficialStore, accountID, registrarName, directorName, registrarEmail, directorEmail, schoolName, schoolAddress string) error {
+	if store == nil {
+		return nil // No store to save to
 	}
 
-	_, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
-
-	return err
+	return store.Upsert(ctx, models.Official{
+		AccountID:      accountID,
+		RegistrarName:  registrarName,
+		DirectorName:   directorName,
+		RegistrarEmail: registrarEmail,
+		DirectorEmail:  directorEmail,
+		SchoolName:     schoolName,
+		SchoolAddress:  schoolAddress,
+	})
 }
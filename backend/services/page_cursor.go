@@ -0,0 +1,83 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrCursorSort    = errors.New("cursor pagination only supports sort=created_at")
+)
+
+// PageCursor marks a position in a keyset-paginated listing: the sort time and
+// _id of the last item of a page. The next page starts strictly after it, so
+// inserts and deletes between requests never shift or repeat items.
+type PageCursor struct {
+	At time.Time
+	ID primitive.ObjectID
+}
+
+type pageCursorPayload struct {
+	At time.Time `json:"t"`
+	ID string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c PageCursor) Encode() string {
+	raw, _ := json.Marshal(pageCursorPayload{At: c.At, ID: c.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodePageCursor parses a token returned by Encode. An empty token means the
+// first page and yields nil.
+func DecodePageCursor(token string) (*PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload pageCursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(payload.ID)
+	if err != nil || payload.At.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &PageCursor{At: payload.At, ID: id}, nil
+}
+
+// follows reports whether the item at (at, id) comes after the cursor in the
+// given direction.
+func (c *PageCursor) follows(at time.Time, id primitive.ObjectID, asc bool) bool {
+	cmp := at.Compare(c.At)
+	if cmp == 0 {
+		cmp = bytes.Compare(id[:], c.ID[:])
+	}
+	if asc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// keysetMatch returns the Mongo filter selecting documents after the cursor,
+// ordered by field then _id.
+func keysetMatch(field string, after *PageCursor, asc bool) bson.M {
+	op := "$lt"
+	if asc {
+		op = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: after.At}},
+		bson.M{field: after.At, "_id": bson.M{op: after.ID}},
+	}}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	pdfThaiFontFile     = "THSarabun.ttf"
+	pdfThaiFontBoldFile = "THSarabun Bold.ttf"
+	pdfCrestFile        = "garuda.png"
+	pdfCrestAlias       = "crest"
+)
+
+var ErrThaiFontMissing = errors.New(pdfThaiFontFile + " not found")
+
+// PDFAssets are the fonts and crest image drawn on request PDFs. They are read
+// once at startup and shared by every PDF rendered afterwards.
+type PDFAssets struct {
+	ThaiFont     []byte // nil falls back to Arial
+	ThaiFontBold []byte // nil reuses ThaiFont for bold text
+	Crest        []byte // opaque PNG; nil leaves the crest out
+}
+
+var (
+	pdfAssetsMu      sync.RWMutex
+	defaultPDFAssets *PDFAssets
+)
+
+// SetPDFAssets replaces the assets used by GeneratePDF and GenerateBatchPDF.
+func SetPDFAssets(assets *PDFAssets) {
+	pdfAssetsMu.Lock()
+	defer pdfAssetsMu.Unlock()
+	defaultPDFAssets = assets
+}
+
+// currentPDFAssets returns the assets set at startup. When none were set, they
+// are loaded from the environment on first use and kept for later PDFs.
+func currentPDFAssets() *PDFAssets {
+	pdfAssetsMu.RLock()
+	assets := defaultPDFAssets
+	pdfAssetsMu.RUnlock()
+	if assets != nil {
+		return assets
+	}
+
+	pdfAssetsMu.Lock()
+	defer pdfAssetsMu.Unlock()
+	if defaultPDFAssets == nil {
+		loaded, err := PDFAssetsFromEnv(nil)
+		if err != nil {
+			log.Printf("warning: failed to load PDF assets: %v", err)
+			loaded = &PDFAssets{}
+		}
+		defaultPDFAssets = loaded
+	}
+	return defaultPDFAssets
+}
+
+// LoadPDFAssets reads the Thai fonts from fonts and the crest from images.
+// Missing files are left nil; any other read error is returned. The crest is
+// flattened onto white here so PDFs don't split off its alpha channel each time.
+func LoadPDFAssets(fonts, images fs.FS) (*PDFAssets, error) {
+	var assets PDFAssets
+	var err error
+	if assets.ThaiFont, err = readOptionalAsset(fonts, pdfThaiFontFile); err != nil {
+		return nil, err
+	}
+	if assets.ThaiFontBold, err = readOptionalAsset(fonts, pdfThaiFontBoldFile); err != nil {
+		return nil, err
+	}
+	if assets.Crest, err = readOptionalAsset(images, pdfCrestFile); err != nil {
+		return nil, err
+	}
+	if assets.Crest != nil {
+		if assets.Crest, err = flattenPNG(assets.Crest); err != nil {
+			return nil, fmt.Errorf("read %s: %w", pdfCrestFile, err)
+		}
+	}
+	return &assets, nil
+}
+
+// flattenPNG draws a PNG with transparency onto white and re-encodes it without
+// an alpha channel. Opaque images are returned unchanged.
+func flattenPNG(data []byte) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return data, nil
+	}
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, flat); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readOptionalAsset(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return nil, nil
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// PDFAssetsFromEnv loads the PDF assets from:
+//   - PDF_FONTS_DIR / PDF_IMAGES_DIR when set
+//   - otherwise the fonts/ and images/ directories of embedded, when the binary
+//     was built with its assets embedded
+//   - otherwise fonts/ and images/ next to the executable or under the working
+//     directory (or its backend/ folder)
+//
+// With GO_ENV=production a missing Thai font is an error instead of a fallback
+// to Arial, since Thai text would not render.
+func PDFAssetsFromEnv(embedded fs.FS) (*PDFAssets, error) {
+	fonts, err := pdfAssetDir("PDF_FONTS_DIR", "fonts", pdfThaiFontFile, embedded)
+	if err != nil {
+		return nil, err
+	}
+	images, err := pdfAssetDir("PDF_IMAGES_DIR", "images", pdfCrestFile, embedded)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := LoadPDFAssets(fonts, images)
+	if err != nil {
+		return nil, err
+	}
+
+	if assets.ThaiFont == nil {
+		if os.Getenv("GO_ENV") == "production" {
+			return nil, ErrThaiFontMissing
+		}
+		log.Printf("warning: %s not found; using fallback font Arial", pdfThaiFontFile)
+	}
+	if assets.Crest == nil {
+		log.Printf("warning: %s not found; PDFs are rendered without the crest", pdfCrestFile)
+	}
+	return assets, nil
+}
+
+// pdfAssetDir resolves one asset directory. Without an env override or embedded
+// assets it picks the first candidate directory holding marker.
+func pdfAssetDir(envKey, name, marker string, embedded fs.FS) (fs.FS, error) {
+	if dir := strings.TrimSpace(os.Getenv(envKey)); dir != "" {
+		return os.DirFS(dir), nil
+	}
+	if embedded != nil {
+		return fs.Sub(embedded, name)
+	}
+
+	candidates := []string{
+		name,
+		filepath.Join("backend", name),
+	}
+	if exe, err := os.Executable(); err == nil {
+		exeDir := filepath.Dir(exe)
+		candidates = append([]string{filepath.Join(exeDir, name), filepath.Join(exeDir, "backend", name)}, candidates...)
+	}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return os.DirFS(dir), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const (
+	testFontsDir  = "../fonts"
+	testImagesDir = "../images"
+)
+
+func TestPDFAssetsFromEnvFailsWithoutThaiFontInProduction(t *testing.T) {
+	t.Setenv("PDF_FONTS_DIR", t.TempDir())
+	t.Setenv("PDF_IMAGES_DIR", t.TempDir())
+
+	t.Setenv("GO_ENV", "production")
+	if _, err := PDFAssetsFromEnv(nil); !errors.Is(err, ErrThaiFontMissing) {
+		t.Fatalf("expected ErrThaiFontMissing in production, got %v", err)
+	}
+
+	t.Setenv("GO_ENV", "development")
+	assets, err := PDFAssetsFromEnv(nil)
+	if err != nil {
+		t.Fatalf("PDFAssetsFromEnv returned error: %v", err)
+	}
+	if assets.ThaiFont != nil || assets.Crest != nil {
+		t.Fatal("expected no assets from empty directories")
+	}
+}
+
+func TestPDFAssetsFromEnvReadsConfiguredAndEmbeddedDirectories(t *testing.T) {
+	t.Setenv("GO_ENV", "production")
+	t.Setenv("PDF_FONTS_DIR", testFontsDir)
+	t.Setenv("PDF_IMAGES_DIR", testImagesDir)
+	assets, err := PDFAssetsFromEnv(nil)
+	if err != nil {
+		t.Fatalf("PDFAssetsFromEnv returned error: %v", err)
+	}
+	if assets.ThaiFont == nil || assets.ThaiFontBold == nil || assets.Crest == nil {
+		t.Fatal("expected fonts and crest to be read from the configured directories")
+	}
+
+	t.Setenv("PDF_FONTS_DIR", "")
+	t.Setenv("PDF_IMAGES_DIR", "")
+	embedded := fstest.MapFS{
+		"fonts/" + pdfThaiFontFile: {Data: assets.ThaiFont},
+		"images/" + pdfCrestFile:   {Data: assets.Crest},
+	}
+	fromEmbed, err := PDFAssetsFromEnv(embedded)
+	if err != nil {
+		t.Fatalf("PDFAssetsFromEnv returned error: %v", err)
+	}
+	if !bytes.Equal(fromEmbed.ThaiFont, assets.ThaiFont) || !bytes.Equal(fromEmbed.Crest, assets.Crest) || fromEmbed.ThaiFontBold != nil {
+		t.Fatal("expected the embedded fonts and crest to be used")
+	}
+}
+
+// BenchmarkGeneratePDF compares rendering with the assets loaded once against
+// reading the fonts and the raw crest from disk for every PDF, as before.
+func BenchmarkGeneratePDF(b *testing.B) {
+	assets, err := LoadPDFAssets(os.DirFS(testFontsDir), os.DirFS(testImagesDir))
+	if err != nil {
+		b.Fatalf("LoadPDFAssets returned error: %v", err)
+	}
+	if assets.ThaiFont == nil {
+		b.Skip("THSarabun.ttf not found")
+	}
+	previous := currentPDFAssets()
+	b.Cleanup(func() { SetPDFAssets(previous) })
+
+	request := &RequestRecord{Prefix: "นาย", Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", CreatedAt: time.Now()}
+
+	b.Run("cached", func(b *testing.B) {
+		SetPDFAssets(assets)
+		for i := 0; i < b.N; i++ {
+			if _, err := GeneratePDF(request, "", "", "", "", "https://records.example"); err != nil {
+				b.Fatalf("GeneratePDF returned error: %v", err)
+			}
+		}
+	})
+	b.Run("read-per-request", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var perRequest PDFAssets
+			var err error
+			if perRequest.ThaiFont, err = os.ReadFile(filepath.Join(testFontsDir, pdfThaiFontFile)); err != nil {
+				b.Fatalf("ReadFile returned error: %v", err)
+			}
+			if perRequest.ThaiFontBold, err = os.ReadFile(filepath.Join(testFontsDir, pdfThaiFontBoldFile)); err != nil {
+				b.Fatalf("ReadFile returned error: %v", err)
+			}
+			if perRequest.Crest, err = os.ReadFile(filepath.Join(testImagesDir, pdfCrestFile)); err != nil {
+				b.Fatalf("ReadFile returned error: %v", err)
+			}
+			SetPDFAssets(&perRequest)
+			if _, err := GeneratePDF(request, "", "", "", "", "https://records.example"); err != nil {
+				b.Fatalf("GeneratePDF returned error: %v", err)
+			}
+		}
+	})
+}
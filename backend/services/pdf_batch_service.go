@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrEmptyPDFBatch = errors.New("no requests to render")
+
+// BatchPDFOptions holds the account details printed on every form of a batch.
+type BatchPDFOptions struct {
+	RegistrarName string
+	DirectorName  string
+	SchoolName    string
+	SchoolAddress string
+	// BaseURL is the public URL used to build the verification QR codes.
+	BaseURL string
+	// CoverPage adds a first page listing the requests and totals by document type.
+	CoverPage bool
+}
+
+// GenerateBatchPDF renders requests back to back into one print-ready PDF, each
+// form starting on a new page. The fonts and crest are embedded once for the
+// whole document.
+func GenerateBatchPDF(requests []RequestRecord, opts BatchPDFOptions) ([]byte, error) {
+	if len(requests) == 0 {
+		return nil, ErrEmptyPDFBatch
+	}
+	doc := newRequestPDF()
+	if opts.CoverPage {
+		doc.drawBatchCover(requests, opts.SchoolName, opts.SchoolAddress, time.Now())
+	}
+	for i := range requests {
+		doc.drawRequest(&requests[i], opts.RegistrarName, opts.DirectorName, opts.SchoolName, opts.SchoolAddress, opts.BaseURL, fmt.Sprintf("r%d-", i))
+	}
+	return doc.output()
+}
+
+// batchCoverColumns are the cover page table columns; widths add up to the printable width.
+var batchCoverColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"ลำดับ", 12, "C"},
+	{"ชื่อ-นามสกุล", 60, "L"},
+	{"เอกสาร", 20, "C"},
+	{"รหัสนักเรียน", 24, "C"},
+	{"วันที่ยื่นคำร้อง", 26, "C"},
+	{"สถานะ", 32, "L"},
+}
+
+// drawBatchCover draws the cover page of a batch: one row per request in print
+// order, then the number of requests per document type.
+func (d *requestPDF) drawBatchCover(requests []RequestRecord, schoolName, schoolAddress string, printedAt time.Time) {
+	pdf, pageMargins, thaiFontFamily := d.pdf, d.pageMargins, d.thaiFontFamily
+	resolvedSchoolName, _ := resolveSchoolInfo(schoolName, schoolAddress)
+	pageW, _ := pdf.GetPageSize()
+	printableW := pageW - pageMargins.Left - pageMargins.Right
+
+	pdf.AddPage()
+	pdf.SetFont(thaiFontFamily, "B", 18)
+	pdf.CellFormat(printableW, 8, "รายการคำร้องขอเอกสาร", "", 1, "C", false, 0, "")
+	pdf.SetFont(thaiFontFamily, "", 14)
+	pdf.CellFormat(printableW, 6, resolvedSchoolName, "", 1, "C", false, 0, "")
+	pdf.CellFormat(printableW, 6, fmt.Sprintf("พิมพ์เมื่อวันที่ %d %s พ.ศ. %d", printedAt.Day(), thaiMonths[printedAt.Month()-1], printedAt.Year()+543), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	drawHeader := func() {
+		pdf.SetFont(thaiFontFamily, "B", 14)
+		pdf.SetFillColor(230, 230, 230)
+		for _, column := range batchCoverColumns {
+			pdf.CellFormat(column.width, 7, column.title, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(thaiFontFamily, "", 14)
+	}
+	drawHeader()
+
+	_, pageH := pdf.GetPageSize()
+	totals := map[string]int{}
+	for i := range requests {
+		request := &requests[i]
+		// repeat the header on every page the table runs onto
+		if pdf.GetY()+7 > pageH-pageMargins.Bottom {
+			pdf.AddPage()
+			drawHeader()
+		}
+		cells := []string{
+			fmt.Sprintf("%d", i+1),
+			strings.TrimSpace(request.Prefix + request.Name),
+			request.DocumentType,
+			request.StudentID,
+			formatThaiShortDate(request.CreatedAt),
+			requestStatusLabel(request),
+		}
+		for j, column := range batchCoverColumns {
+			pdf.CellFormat(column.width, 7, fitPDFText(pdf.GetStringWidth, cells[j], column.width-2), "1", 0, column.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		docType := strings.TrimSpace(request.DocumentType)
+		if docType == "" {
+			docType = "ไม่ระบุ"
+		}
+		totals[docType]++
+	}
+
+	docTypes := make([]string, 0, len(totals))
+	for docType := range totals {
+		docTypes = append(docTypes, docType)
+	}
+	sort.Strings(docTypes)
+
+	pdf.Ln(6)
+	pdf.SetFont(thaiFontFamily, "B", 14)
+	pdf.CellFormat(printableW, 7, "สรุปตามประเภทเอกสาร", "", 1, "L", false, 0, "")
+	pdf.SetFont(thaiFontFamily, "", 14)
+	for _, docType := range docTypes {
+		pdf.SetX(pageMargins.Left + 9)
+		pdf.CellFormat(60, 7, docType, "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 7, fmt.Sprintf("%d ฉบับ", totals[docType]), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont(thaiFontFamily, "B", 14)
+	pdf.SetX(pageMargins.Left + 9)
+	pdf.CellFormat(60, 7, "รวมทั้งสิ้น", "", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, fmt.Sprintf("%d ฉบับ", len(requests)), "", 1, "R", false, 0, "")
+}
+
+// fitPDFText shortens text with an ellipsis until it fits in width.
+func fitPDFText(measure func(string) float64, text string, width float64) string {
+	if measure(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && measure(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestGenerateBatchPDFRendersEachRequestWithItsOwnSignature(t *testing.T) {
+	signature := func(ink color.NRGBA) string {
+		img := image.NewNRGBA(image.Rect(0, 0, 120, 40))
+		for x := 10; x < 110; x++ {
+			img.Set(x, 20, ink)
+		}
+		return encodeTestImage(t, img, "png")
+	}
+	requests := []RequestRecord{
+		{Name: "สมชาย ดีมาก", DocumentType: "ปพ.1", CreatedAt: time.Now(), Signatures: models.RequestSignatures{
+			Student: &models.SignatureBlock{DataBase64: signature(color.NRGBA{B: 200, A: 255})},
+		}},
+		{Name: "สมหญิง ใจงาม", DocumentType: "ปพ.7", CreatedAt: time.Now(), Signatures: models.RequestSignatures{
+			Student: &models.SignatureBlock{DataBase64: signature(color.NRGBA{R: 200, A: 255})},
+		}},
+	}
+
+	withCover, err := GenerateBatchPDF(requests, BatchPDFOptions{BaseURL: "https://records.example", CoverPage: true})
+	if err != nil {
+		t.Fatalf("GenerateBatchPDF returned error: %v", err)
+	}
+	if !bytes.Contains(withCover, []byte("/Count 3")) {
+		t.Fatal("expected a cover page and one page per request")
+	}
+	// each request keeps its own signature and QR code instead of reusing the first one's
+	if images := bytes.Count(withCover, []byte("/Subtype /Image")); images < 4 {
+		t.Fatalf("expected at least 4 embedded images, got %d", images)
+	}
+
+	withoutCover, err := GenerateBatchPDF(requests, BatchPDFOptions{})
+	if err != nil {
+		t.Fatalf("GenerateBatchPDF returned error: %v", err)
+	}
+	if !bytes.Contains(withoutCover, []byte("/Count 2")) {
+		t.Fatal("expected one page per request without a cover")
+	}
+
+	if _, err := GenerateBatchPDF(nil, BatchPDFOptions{}); !errors.Is(err, ErrEmptyPDFBatch) {
+		t.Fatalf("expected ErrEmptyPDFBatch, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxBulkPDFRequests    = 500
+	defaultBulkPDFWorkers = 4
+	bulkPDFManifestName   = "manifest.json"
+)
+
+var (
+	ErrNoBulkPDFRequests      = errors.New("no requests matched")
+	ErrTooManyBulkPDFRequests = fmt.Errorf("at most %d requests can be downloaded at once", MaxBulkPDFRequests)
+)
+
+// RequestPDFRenderer renders the PDF of one request.
+type RequestPDFRenderer func(ctx context.Context, request *RequestRecord) ([]byte, error)
+
+// BulkPDFFailure is a request left out of a bulk download.
+type BulkPDFFailure struct {
+	RequestID string `json:"request_id"`
+	Name      string `json:"name,omitempty"`
+	Error     string `json:"error"`
+}
+
+// BulkPDFManifest is written as manifest.json at the end of a bulk download.
+type BulkPDFManifest struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Requested   int              `json:"requested"`
+	Rendered    int              `json:"rendered"`
+	Failed      []BulkPDFFailure `json:"failed"`
+}
+
+// BulkPDFWorkersFromEnv reads the number of concurrent PDF renders per bulk
+// download from PDF_WORKERS (default 4).
+func BulkPDFWorkersFromEnv() int {
+	raw := strings.TrimSpace(os.Getenv("PDF_WORKERS"))
+	if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+		return n
+	}
+	if raw != "" {
+		log.Printf("Warning: invalid PDF_WORKERS %q, using %d", raw, defaultBulkPDFWorkers)
+	}
+	return defaultBulkPDFWorkers
+}
+
+// CollectBulkPDFRequests picks the account's requests for a bulk download: the
+// given IDs in order when any are passed, otherwise every request matching
+// filter, oldest first. IDs that do not resolve are returned as failures.
+// Either way each request is loaded in full, since listings leave out inline
+// signature images that the PDF still has to draw.
+func CollectBulkPDFRequests(ctx context.Context, store RequestStore, accountID string, ids []primitive.ObjectID, filter RequestFilter) ([]RequestRecord, []BulkPDFFailure, error) {
+	if len(ids) == 0 {
+		matched, err := store.ListByCursor(ctx, RequestListQuery{
+			AccountID: accountID,
+			Filter:    filter,
+			SortAsc:   true,
+			Limit:     MaxBulkPDFRequests + 1,
+			Fields:    []string{"id"},
+		}, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, record := range matched {
+			id, err := ToObjectID(record.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxBulkPDFRequests {
+		return nil, nil, ErrTooManyBulkPDFRequests
+	}
+
+	var records []RequestRecord
+	var failures []BulkPDFFailure
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		record, err := store.FindByID(ctx, id, accountID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				failures = append(failures, BulkPDFFailure{RequestID: id.Hex(), Error: "request not found"})
+				continue
+			}
+			return nil, nil, err
+		}
+		records = append(records, *record)
+	}
+
+	if len(records) == 0 {
+		return nil, failures, ErrNoBulkPDFRequests
+	}
+	return records, failures, nil
+}
+
+type bulkPDFResult struct {
+	data []byte
+	err  error
+}
+
+// WriteBulkPDFZip renders requests with a pool of workers and streams them to w
+// as a ZIP archive, in the order given, followed by a manifest listing the
+// requests that failed to render along with failures found earlier. At most
+// twice as many PDFs as workers are held in memory at a time.
+func WriteBulkPDFZip(ctx context.Context, w io.Writer, requests []RequestRecord, failures []BulkPDFFailure, render RequestPDFRenderer, workers int) (BulkPDFManifest, error) {
+	manifest := BulkPDFManifest{
+		GeneratedAt: time.Now(),
+		Requested:   len(requests) + len(failures),
+		Failed:      append([]BulkPDFFailure{}, failures...),
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// on return, stop handing out work first, then wait for renders in flight
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan bulkPDFResult, len(requests))
+	for i := range results {
+		results[i] = make(chan bulkPDFResult, 1)
+	}
+	// a slot is taken when a render starts and freed once its PDF is written, so
+	// a slow early PDF cannot make later ones pile up in memory
+	slots := make(chan struct{}, workers*2)
+	jobs := make(chan int)
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				data, err := renderBulkPDF(ctx, render, &requests[i])
+				results[i] <- bulkPDFResult{data: data, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range requests {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	archive := zip.NewWriter(w)
+	for i := range requests {
+		var result bulkPDFResult
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+			return manifest, ctx.Err()
+		}
+		<-slots
+
+		id := requestIDString(requests[i].ID)
+		if result.err != nil {
+			log.Printf("bulk pdf: failed to render request %s: %v", id, result.err)
+			manifest.Failed = append(manifest.Failed, BulkPDFFailure{
+				RequestID: id,
+				Name:      strings.TrimSpace(requests[i].Prefix + requests[i].Name),
+				Error:     "failed to render PDF",
+			})
+			continue
+		}
+		// PDF streams are already compressed
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     "request-" + id + ".pdf",
+			Method:   zip.Store,
+			Modified: manifest.GeneratedAt,
+		})
+		if err != nil {
+			return manifest, err
+		}
+		if _, err := entry.Write(result.data); err != nil {
+			return manifest, err
+		}
+		manifest.Rendered++
+	}
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     bulkPDFManifestName,
+		Method:   zip.Deflate,
+		Modified: manifest.GeneratedAt,
+	})
+	if err != nil {
+		return manifest, err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return manifest, err
+	}
+	return manifest, archive.Close()
+}
+
+// renderBulkPDF renders one request, turning a panic in the PDF library into an
+// error so one broken request cannot abort the whole download.
+func renderBulkPDF(ctx context.Context, render RequestPDFRenderer, request *RequestRecord) (data []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("pdf render panic: %v", recovered)
+		}
+	}()
+	return render(ctx, request)
+}
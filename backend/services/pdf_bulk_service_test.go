@@ -0,0 +1,141 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWriteBulkPDFZipKeepsOrderAndReportsFailures(t *testing.T) {
+	requests := make([]RequestRecord, 12)
+	position := map[string]int{}
+	for i := range requests {
+		requests[i] = RequestRecord{ID: primitive.NewObjectID(), Name: "นักเรียน"}
+		position[requestIDString(requests[i].ID)] = i
+	}
+	broken := requestIDString(requests[3].ID)
+	panicking := requestIDString(requests[7].ID)
+
+	var running, peak int32
+	render := func(_ context.Context, request *RequestRecord) ([]byte, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if n <= seen || atomic.CompareAndSwapInt32(&peak, seen, n) {
+				break
+			}
+		}
+		// later requests finish first so the writer has to restore the order
+		time.Sleep(time.Duration(12-position[requestIDString(request.ID)]) * time.Millisecond)
+		switch requestIDString(request.ID) {
+		case broken:
+			return nil, errors.New("boom")
+		case panicking:
+			panic("font table corrupt")
+		}
+		return []byte("%PDF-" + requestIDString(request.ID)), nil
+	}
+
+	missing := []BulkPDFFailure{{RequestID: primitive.NewObjectID().Hex(), Error: "request not found"}}
+	var out bytes.Buffer
+	manifest, err := WriteBulkPDFZip(context.Background(), &out, requests, missing, render, 3)
+	if err != nil {
+		t.Fatalf("WriteBulkPDFZip returned error: %v", err)
+	}
+	if peak > 3 {
+		t.Fatalf("expected at most 3 concurrent renders, saw %d", peak)
+	}
+	if manifest.Requested != 13 || manifest.Rendered != 10 || len(manifest.Failed) != 3 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if len(names) != 11 || names[10] != bulkPDFManifestName {
+		t.Fatalf("unexpected archive entries: %q", names)
+	}
+	wantFirst := "request-" + requestIDString(requests[0].ID) + ".pdf"
+	wantFourth := "request-" + requestIDString(requests[4].ID) + ".pdf"
+	if names[0] != wantFirst || names[3] != wantFourth {
+		t.Fatalf("expected archive in request order, got %q", names)
+	}
+
+	reader, err := archive.File[10].Open()
+	if err != nil {
+		t.Fatalf("failed to open manifest: %v", err)
+	}
+	defer reader.Close()
+	raw, _ := io.ReadAll(reader)
+	var written BulkPDFManifest
+	if err := json.Unmarshal(raw, &written); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	failed := map[string]bool{}
+	for _, failure := range written.Failed {
+		failed[failure.RequestID] = true
+	}
+	if !failed[broken] || !failed[panicking] || !failed[missing[0].RequestID] {
+		t.Fatalf("expected manifest to list every failure, got %s", raw)
+	}
+}
+
+// listingRequestStore drops inline signature images from listings, as the
+// Mongo store's list projection does.
+type listingRequestStore struct {
+	RequestStore
+}
+
+func (s listingRequestStore) ListByCursor(ctx context.Context, query RequestListQuery, after *PageCursor) ([]RequestRecord, error) {
+	records, err := s.RequestStore.ListByCursor(ctx, query, after)
+	for i := range records {
+		records[i].Signatures = models.RequestSignatures{}
+	}
+	return records, err
+}
+
+func TestCollectBulkPDFRequestsLoadsInlineSignaturesForFilter(t *testing.T) {
+	stores := NewMemoryStores()
+	// an unmigrated request still carries its signature image inline
+	id, err := stores.Requests.Insert(context.Background(), &RequestRecord{
+		AccountID:  "acct-1",
+		Name:       "สมหญิง ใจงาม",
+		Signatures: models.RequestSignatures{Student: &models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw"}},
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Insert returned error: %v", err)
+	}
+
+	store := listingRequestStore{stores.Requests}
+	byFilter, failures, err := CollectBulkPDFRequests(context.Background(), store, "acct-1", nil, RequestFilter{})
+	if err != nil {
+		t.Fatalf("CollectBulkPDFRequests returned error: %v", err)
+	}
+	byID, _, err := CollectBulkPDFRequests(context.Background(), store, "acct-1", []primitive.ObjectID{id}, RequestFilter{})
+	if err != nil {
+		t.Fatalf("CollectBulkPDFRequests returned error: %v", err)
+	}
+	if len(byFilter) != 1 || len(failures) != 0 {
+		t.Fatalf("expected one request, got %d and failures %+v", len(byFilter), failures)
+	}
+	if !byFilter[0].Signatures.Student.HasImage() || !byID[0].Signatures.Student.HasImage() {
+		t.Fatal("expected the student signature image in both selection modes")
+	}
+}
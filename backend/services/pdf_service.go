@@ -5,9 +5,9 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
 	"log"
-	"os"
-	"path/filepath"
+	"math"
 	"strings"
 	"time"
 
@@ -57,13 +57,25 @@ func decodeSignatureData(raw string) ([]byte, string, error) {
 
 func drawSignatureImage(pdf *gofpdf.Fpdf, alias string, rawData string, x, y, w, h float64) {
 	data, imageType, err := decodeSignatureData(rawData)
+	if err == nil {
+		// a corrupt image would fail the whole document in gofpdf, so check it first
+		_, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
 	if err != nil {
+		log.Printf("skipping unreadable signature %s: %v", alias, err)
 		return
 	}
 
 	opt := gofpdf.ImageOptions{ImageType: imageType, ReadDpi: true}
-	pdf.RegisterImageOptionsReader(alias, opt, bytes.NewReader(data))
-	pdf.ImageOptions(alias, x, y, w, h, false, opt, 0, "")
+	info := pdf.RegisterImageOptionsReader(alias, opt, bytes.NewReader(data))
+	if info == nil || info.Width() <= 0 || info.Height() <= 0 {
+		return
+	}
+	// signatures are trimmed to their ink, so fit them into the box keeping their
+	// aspect ratio instead of stretching them
+	scale := math.Min(w/info.Width(), h/info.Height())
+	drawW, drawH := info.Width()*scale, info.Height()*scale
+	pdf.ImageOptions(alias, x+(w-drawW)/2, y+(h-drawH)/2, drawW, drawH, false, opt, 0, "")
 }
 
 func drawDecisionCircle(pdf *gofpdf.Fpdf, x, y float64, selected bool) {
@@ -76,6 +88,29 @@ func drawDecisionCircle(pdf *gofpdf.Fpdf, x, y float64, selected bool) {
 	}
 }
 
+func decisionComment(decision *models.OfficialDecision) string {
+	if decision == nil {
+		return ""
+	}
+	return strings.TrimSpace(decision.Comment)
+}
+
+// signRoleLabel returns the Thai title printed for a signer role.
+func signRoleLabel(role models.SignRole) string {
+	switch role {
+	case models.SignRoleRegistrar:
+		return "นายทะเบียน"
+	case models.SignRoleDirector:
+		return "ผู้อำนวยการ"
+	case models.SignRoleAdmin:
+		return "เจ้าหน้าที่"
+	case models.SignRoleStudent:
+		return "ผู้ยื่นคำร้อง"
+	default:
+		return string(role)
+	}
+}
+
 func resolveSchoolInfo(schoolName, schoolAddress string) (name string, addressLines []string) {
 	name = strings.TrimSpace(schoolName)
 	if name == "" {
@@ -99,20 +134,41 @@ func resolveSchoolInfo(schoolName, schoolAddress string) (name string, addressLi
 	return name, addressLines
 }
 
-// GeneratePDF generates a PDF for the given RequestRecord and returns the PDF bytes.
-// registrarName and directorName are the names to print on signature lines.
-// baseURL is the public URL used to build the verification QR code.
-func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName, schoolAddress, baseURL string) ([]byte, error) {
+var thaiMonths = []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน", "กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+
+var thaiShortMonths = []string{"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.", "ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค."}
+
+// formatThaiShortDate formats t as Day/ShortMonth/BuddhistYear, e.g. 5/มี.ค./2568,
+// or a blank placeholder for the zero time.
+func formatThaiShortDate(t time.Time) string {
+	if t.IsZero() {
+		return "___/___/___"
+	}
+	return fmt.Sprintf("%d/%s/%d", t.Day(), thaiShortMonths[t.Month()-1], t.Year()+543)
+}
+
+// pdfPageMargins are the page margins of request documents, in mm.
+type pdfPageMargins struct {
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+}
+
+// requestPDF is an A4 document set up with the request form's margins, the Thai
+// font and the crest image. Several requests can be drawn into one document.
+type requestPDF struct {
+	pdf            *gofpdf.Fpdf
+	pageMargins    pdfPageMargins
+	thaiFontFamily string
+	crest          []byte
+}
+
+func newRequestPDF() *requestPDF {
 	pdf := gofpdf.New("P", "mm", "A4", "")
-	resolvedSchoolName, schoolAddressLines := resolveSchoolInfo(schoolName, schoolAddress)
 
 	// Page margin variables (left, right, top, bottom)
-	pageMargins := struct {
-		Left   float64
-		Right  float64
-		Top    float64
-		Bottom float64
-	}{
+	pageMargins := pdfPageMargins{
 		Left:   18, // mm
 		Right:  18, // mm
 		Top:    12, // mm
@@ -122,81 +178,47 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pdf.SetMargins(pageMargins.Left, pageMargins.Top, pageMargins.Right)
 	pdf.SetAutoPageBreak(true, pageMargins.Bottom)
 
-	// Try to load THSarabun.ttf from known locations and register as UTF-8 font
-	thaiFontFamily := "Arial" // fallback
-
-	// Build candidate paths including path relative to executable to be robust when running as binary
-	tryPaths := []string{
-		filepath.Join("fonts", "THSarabun.ttf"),
-		filepath.Join("backend", "fonts", "THSarabun.ttf"),
-		filepath.Join(".", "backend", "fonts", "THSarabun.ttf"),
-	}
-	if exe, err := os.Executable(); err == nil {
-		exeDir := filepath.Dir(exe)
-		tryPaths = append([]string{filepath.Join(exeDir, "fonts", "THSarabun.ttf"), filepath.Join(exeDir, "backend", "fonts", "THSarabun.ttf")}, tryPaths...)
+	// Register the Thai font loaded at startup, falling back to Arial without it
+	assets := currentPDFAssets()
+	thaiFontFamily := "Arial"
+	if assets.ThaiFont != nil {
+		pdf.AddUTF8FontFromBytes("THSarabun", "", assets.ThaiFont)
+		bold := assets.ThaiFontBold
+		if bold == nil {
+			// register bold style with the regular font if a separate bold file wasn't found
+			bold = assets.ThaiFont
+		}
+		pdf.AddUTF8FontFromBytes("THSarabun", "B", bold)
+		thaiFontFamily = "THSarabun"
 	}
 
-	var regBytes []byte
-	var boldBytes []byte
-	// search for regular and bold files separately
-	candidatesReg := append([]string{}, tryPaths...)
-	candidatesBold := []string{
-		filepath.Join("fonts", "THSarabun Bold.ttf"),
-		filepath.Join("backend", "fonts", "THSarabun Bold.ttf"),
-		filepath.Join(".", "backend", "fonts", "THSarabun Bold.ttf"),
-	}
-	if exe, err := os.Executable(); err == nil {
-		exeDir := filepath.Dir(exe)
-		candidatesReg = append([]string{filepath.Join(exeDir, "fonts", "THSarabun.ttf"), filepath.Join(exeDir, "backend", "fonts", "THSarabun.ttf")}, candidatesReg...)
-		candidatesBold = append([]string{filepath.Join(exeDir, "fonts", "THSarabun Bold.ttf"), filepath.Join(exeDir, "backend", "fonts", "THSarabun Bold.ttf")}, candidatesBold...)
-	}
+	return &requestPDF{pdf: pdf, pageMargins: pageMargins, thaiFontFamily: thaiFontFamily, crest: assets.Crest}
+}
 
-	for _, p := range candidatesReg {
-		if b, err := os.ReadFile(p); err == nil {
-			regBytes = b
-			break
-		}
-	}
-	for _, p := range candidatesBold {
-		if b, err := os.ReadFile(p); err == nil {
-			boldBytes = b
-			break
-		}
+func (d *requestPDF) output() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	if regBytes != nil {
-		pdf.AddUTF8FontFromBytes("THSarabun", "", regBytes)
-		thaiFontFamily = "THSarabun"
-	}
-	if boldBytes != nil {
-		pdf.AddUTF8FontFromBytes("THSarabun", "B", boldBytes)
-	} else if regBytes != nil {
-		// fallback: register bold style with same bytes if a separate bold file wasn't found
-		pdf.AddUTF8FontFromBytes("THSarabun", "B", regBytes)
-	}
-	if thaiFontFamily == "Arial" {
-		log.Printf("warning: THSarabun.ttf not found; using fallback font %s", thaiFontFamily)
-	}
+// GeneratePDF generates a PDF for the given RequestRecord and returns the PDF bytes.
+// registrarName and directorName are the names to print on signature lines.
+// baseURL is the public URL used to build the verification QR code.
+func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName, schoolAddress, baseURL string) ([]byte, error) {
+	doc := newRequestPDF()
+	doc.drawRequest(request, registrarName, directorName, schoolName, schoolAddress, baseURL, "")
+	return doc.output()
+}
 
-	// Try to find garuda image in common locations
-	imgPaths := []string{
-		filepath.Join("images", "garuda.png"),
-		filepath.Join("backend", "images", "garuda.png"),
-		filepath.Join(".", "backend", "images", "garuda.png"),
-	}
-	if exe, err := os.Executable(); err == nil {
-		exeDir := filepath.Dir(exe)
-		imgPaths = append([]string{filepath.Join(exeDir, "images", "garuda.png"), filepath.Join(exeDir, "backend", "images", "garuda.png")}, imgPaths...)
-	}
-	var imgPath string
-	for _, p := range imgPaths {
-		if _, err := os.Stat(p); err == nil {
-			imgPath = p
-			break
-		}
-	}
+// drawRequest draws the request form on a new page. Images are registered
+// under aliasPrefix so requests sharing a document keep their own signatures.
+func (d *requestPDF) drawRequest(request *RequestRecord, registrarName, directorName, schoolName, schoolAddress, baseURL, aliasPrefix string) {
+	pdf, pageMargins, thaiFontFamily := d.pdf, d.pageMargins, d.thaiFontFamily
+	resolvedSchoolName, schoolAddressLines := resolveSchoolInfo(schoolName, schoolAddress)
 
-	// Start the first page, then draw centered crest and titles at the top
+	// Start a new page, then draw centered crest and titles at the top
 	pdf.AddPage()
 
 	// Header with centered crest and titles (match provided form look)
@@ -204,12 +226,13 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pageW, _ := pdf.GetPageSize()
 	printableW := pageW - pageMargins.Left - pageMargins.Right
 	colW := printableW / 2
-	if imgPath != "" {
+	if d.crest != nil {
 		imgW := 25.0 // mm, adjust to match appearance
 		x := pageMargins.Left + (printableW-imgW)/2
-		// ImageOptions will accept file path directly
+		// the crest is registered once per document and reused by every page
 		opt := gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}
-		pdf.ImageOptions(imgPath, x, pageMargins.Top/2+5, imgW, 0, false, opt, 0, "")
+		pdf.RegisterImageOptionsReader(pdfCrestAlias, opt, bytes.NewReader(d.crest))
+		pdf.ImageOptions(pdfCrestAlias, x, pageMargins.Top/2+5, imgW, 0, false, opt, 0, "")
 	}
 
 	// Small vertical spacing after crest
@@ -246,7 +269,6 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pdf.SetFont(thaiFontFamily, "", 14)
 	// Format request.CreatedAt into Thai date (day, Thai month name, Buddhist year)
 	reqDate := request.CreatedAt
-	thaiMonths := []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน", "กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
 	day := reqDate.Day()
 	month := ""
 	if int(reqDate.Month()) >= 1 && int(reqDate.Month()) <= 12 {
@@ -396,7 +418,7 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 		// Center signature image specifically over the underline part to avoid overlapping "ลงชื่อ"
 		imgX := lineStartX + labelW + (underlineW-signatureW)/2
 		// Move up slightly more by adjusting Y offset from studentSignLineY
-		drawSignatureImage(pdf, "sig-student", request.Signatures.Student.DataBase64, imgX, studentSignLineY-4.0, signatureW, 11)
+		drawSignatureImage(pdf, aliasPrefix+"sig-student", request.Signatures.Student.DataBase64, imgX, studentSignLineY-4.0, signatureW, 11)
 	}
 
 	// Name in parentheses - center only over the underline part to match signature
@@ -419,6 +441,25 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pdf.SetFont(thaiFontFamily, "", curFontSize)
 	pdf.Ln(7) // Reduced from 10
 
+	// Free-text comments sit under each heading; the taller column decides where the circles start
+	registrarComment := decisionComment(request.Decisions.Registrar)
+	directorComment := decisionComment(request.Decisions.Director)
+	if registrarComment != "" || directorComment != "" {
+		commentTop := pdf.GetY()
+		commentBottom := commentTop
+		for i, comment := range []string{registrarComment, directorComment} {
+			if comment == "" {
+				continue
+			}
+			pdf.SetXY(pageMargins.Left+float64(i)*colW+9, commentTop)
+			pdf.MultiCell(colW-12, 6, comment, "", "L", false)
+			if pdf.GetY() > commentBottom {
+				commentBottom = pdf.GetY()
+			}
+		}
+		pdf.SetXY(pageMargins.Left, commentBottom+1)
+	}
+
 	pdf.SetX(pageMargins.Left + 9)
 	pdf.CellFormat(18.0, 6, "เห็นควร", "", 0, "L", false, 0, "")
 	yRef := pdf.GetY() + 3.0
@@ -487,7 +528,7 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 		}
 		// Center signature relative to the entire "ลงนาม _____" block
 		imgX := regSignStartX + (offTotalW-sigW)/2
-		drawSignatureImage(pdf, "sig-registrar", request.Signatures.Registrar.DataBase64, imgX, officialSignLineY-4.0, sigW, 12)
+		drawSignatureImage(pdf, aliasPrefix+"sig-registrar", request.Signatures.Registrar.DataBase64, imgX, officialSignLineY-4.0, sigW, 12)
 	}
 	if request.Signatures.Director != nil {
 		sigW := 40.0
@@ -496,7 +537,7 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 		}
 		// Center signature relative to the entire "ลงนาม _____" block
 		imgX := dirSignStartX + (offTotalW-sigW)/2
-		drawSignatureImage(pdf, "sig-director", request.Signatures.Director.DataBase64, imgX, officialSignLineY-4.0, sigW, 12)
+		drawSignatureImage(pdf, aliasPrefix+"sig-director", request.Signatures.Director.DataBase64, imgX, officialSignLineY-4.0, sigW, 12)
 	}
 
 	pdf.Ln(4) // Reduced from 5 to save space
@@ -504,19 +545,6 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pdf.CellFormat(colW, 6, fmt.Sprintf("( %s )", directorName), "", 1, "C", false, 0, "")
 	pdf.Ln(2) // Reduced from 5 to bring date closer to name
 
-	// Helper for Thai short date format: Day/ShortMonth/BuddhistYear
-	formatThaiShortDate := func(t time.Time) string {
-		if t.IsZero() {
-			return "___/___/___"
-		}
-		shortMonths := []string{"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.", "ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค."}
-		mIdx := int(t.Month()) - 1
-		if mIdx < 0 || mIdx > 11 {
-			return "___/___/___"
-		}
-		return fmt.Sprintf("%d/%s/%d", t.Day(), shortMonths[mIdx], t.Year()+543)
-	}
-
 	regDateStr := "___/___/___"
 	if request.Decisions.Registrar != nil && !request.Decisions.Registrar.DecidedAt.IsZero() {
 		regDateStr = formatThaiShortDate(request.Decisions.Registrar.DecidedAt)
@@ -529,6 +557,63 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 	pdf.CellFormat(colW, 6, regDateStr, "", 0, "C", false, 0, "")
 	pdf.CellFormat(colW, 6, dirDateStr, "", 1, "C", false, 0, "")
 
+	// Custom approval steps (e.g. homeroom teacher) get one compact row each below the two fixed columns
+	for _, step := range RequestApprovalSteps(request) {
+		if step.Role == models.SignRoleRegistrar || step.Role == models.SignRoleDirector {
+			continue
+		}
+		decision := request.Decisions.ForRole(step.Role)
+		pdf.Ln(2)
+		pdf.SetFont(thaiFontFamily, "B", curFontSize)
+		pdf.CellFormat(printableW, 6, "ความเห็น"+step.Label, "", 1, "L", false, 0, "")
+		pdf.SetFont(thaiFontFamily, "", curFontSize)
+		if comment := decisionComment(decision); comment != "" {
+			pdf.SetX(pageMargins.Left + 9)
+			pdf.MultiCell(printableW-9, 6, comment, "", "L", false)
+		}
+
+		rowY := pdf.GetY()
+		pdf.SetX(pageMargins.Left + 9)
+		pdf.CellFormat(18.0, 6, "เห็นควร", "", 0, "L", false, 0, "")
+		approveX := pdf.GetX() + 2.5
+		pdf.CellFormat(5, 6, "", "", 0, "L", false, 0, "")
+		drawDecisionCircle(pdf, approveX, rowY+3.0, hasDecision(decision, models.OfficialDecisionApprove))
+		pdf.CellFormat(25, 6, "อนุญาต", "", 0, "L", false, 0, "")
+		rejectX := pdf.GetX() + 2.5
+		pdf.CellFormat(5, 6, "", "", 0, "L", false, 0, "")
+		drawDecisionCircle(pdf, rejectX, rowY+3.0, hasDecision(decision, models.OfficialDecisionReject))
+		pdf.CellFormat(25, 6, "ไม่อนุญาต", "", 0, "L", false, 0, "")
+
+		pdf.SetX(pageMargins.Left + colW + 9)
+		pdf.CellFormat(offLabelW, 6, officialLabel, "", 0, "L", false, 0, "")
+		signX := pdf.GetX()
+		pdf.CellFormat(offUnderlineW, 6, "", "", 1, "L", false, 0, "")
+		if sig := request.Signatures.ForRole(step.Role); sig != nil {
+			drawSignatureImage(pdf, aliasPrefix+"sig-"+string(step.Role), sig.DataBase64, signX+(offUnderlineW-30)/2, rowY-3.0, 30, 9)
+		}
+
+		stepDate := "___/___/___"
+		if decision != nil {
+			stepDate = formatThaiShortDate(decision.DecidedAt)
+		}
+		signerLine := stepDate
+		if strings.TrimSpace(step.SignerName) != "" {
+			signerLine = fmt.Sprintf("( %s )  %s", step.SignerName, stepDate)
+		}
+		pdf.SetX(pageMargins.Left + colW)
+		pdf.CellFormat(colW, 6, signerLine, "", 1, "C", false, 0, "")
+	}
+
+	if request.Rejection != nil {
+		pdf.Ln(2)
+		pdf.SetFont(thaiFontFamily, "B", curFontSize)
+		pdf.SetTextColor(180, 0, 0)
+		pdf.MultiCell(printableW, 6, fmt.Sprintf("ไม่อนุมัติโดย%s เมื่อ %s", ApprovalStepLabel(request, request.Rejection.Role), formatThaiShortDate(request.Rejection.RejectedAt)), "", "L", false)
+		pdf.SetFont(thaiFontFamily, "", curFontSize)
+		pdf.MultiCell(printableW, 6, "เหตุผล: "+request.Rejection.Reason, "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+	}
+
 	// --- Traceability Footer (ETDA Compliance) ---
 	// Pick the most relevant hash (latest one available)
 	refHash := ComputeRequestHash(request)
@@ -545,7 +630,7 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 		verifyURL := fmt.Sprintf("%s/verify?hash=%s", strings.TrimRight(baseURL, "/"), refHash)
 		qrBytes, err := qrcode.Encode(verifyURL, qrcode.Medium, 256)
 		if err == nil {
-			qrAlias := "qr-verification"
+			qrAlias := aliasPrefix + "qr-verification"
 			pdf.RegisterImageOptionsReader(qrAlias, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrBytes))
 			// Draw at bottom-left margin
 			pdf.ImageOptions(qrAlias, pageMargins.Left, 267, 15, 15, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
@@ -560,10 +645,4 @@ func GeneratePDF(request *RequestRecord, registrarName, directorName, schoolName
 			pdf.SetTextColor(0, 0, 0) // reset
 		}
 	}
-
-	var buf bytes.Buffer
-	if err := pdf.Output(&buf); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
 }
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+
+	exportBatchSize = 200
+	exportSheetName = "คำร้อง"
+)
+
+var ErrUnsupportedExportFormat = errors.New("format must be csv or xlsx")
+
+// ExportColumn is one column of a request export.
+type ExportColumn struct {
+	Key    string
+	Header string
+	Width  float64 // XLSX column width in characters
+	value  func(record *RequestRecord) string
+}
+
+var requestStatusLabels = map[models.RequestStatus]string{
+	models.RequestStatusSubmitted:                "ยื่นคำร้องแล้ว",
+	models.RequestStatusAwaitingStudentSignature: "รอนักเรียนลงนาม",
+	models.RequestStatusAwaitingRegistrar:        "รอนายทะเบียนพิจารณา",
+	models.RequestStatusAwaitingDirector:         "รอผู้อำนวยการพิจารณา",
+	models.RequestStatusAwaitingApproval:         "รอการพิจารณา",
+	models.RequestStatusApproved:                 "อนุมัติแล้ว",
+	models.RequestStatusRejected:                 "ไม่อนุมัติ",
+	models.RequestStatusIssued:                   "ออกเอกสารแล้ว",
+	models.RequestStatusCollected:                "รับเอกสารแล้ว",
+	models.RequestStatusCancelled:                "ยกเลิก",
+}
+
+// requestStatusLabel returns the Thai label of the record's current status,
+// resolving legacy stored values first.
+func requestStatusLabel(record *RequestRecord) string {
+	status := CurrentRequestStatus(record)
+	if label, ok := requestStatusLabels[status]; ok {
+		return label
+	}
+	return string(status)
+}
+
+// exportThaiDate formats t like the PDF does, leaving unset dates empty.
+func exportThaiDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return formatThaiShortDate(t)
+}
+
+func exportDecisionDate(record *RequestRecord, role models.SignRole) string {
+	if decision := record.Decisions.ForRole(role); decision != nil {
+		return exportThaiDate(decision.DecidedAt)
+	}
+	return ""
+}
+
+// RequestExportColumns lists every column an export can contain, in their
+// default order.
+var RequestExportColumns = []ExportColumn{
+	{Key: "created_at", Header: "วันที่ยื่นคำร้อง", Width: 14, value: func(r *RequestRecord) string { return exportThaiDate(r.CreatedAt) }},
+	{Key: "name", Header: "ชื่อ-นามสกุล", Width: 28, value: func(r *RequestRecord) string { return strings.TrimSpace(r.Prefix + r.Name) }},
+	{Key: "document_type", Header: "เอกสาร", Width: 10, value: func(r *RequestRecord) string { return r.DocumentType }},
+	{Key: "student_id", Header: "รหัสนักเรียน", Width: 12, value: func(r *RequestRecord) string { return r.StudentID }},
+	{Key: "id_card", Header: "เลขประจำตัวประชาชน", Width: 18, value: func(r *RequestRecord) string { return r.IDCard }},
+	{Key: "class", Header: "ชั้น/ห้อง", Width: 10, value: func(r *RequestRecord) string {
+		if r.Room == "" {
+			return r.Class
+		}
+		return r.Class + "/" + r.Room
+	}},
+	{Key: "academic_year", Header: "ปีการศึกษา", Width: 10, value: func(r *RequestRecord) string { return r.AcademicYear }},
+	{Key: "purpose", Header: "วัตถุประสงค์", Width: 24, value: func(r *RequestRecord) string { return r.Purpose }},
+	{Key: "status", Header: "สถานะ", Width: 18, value: requestStatusLabel},
+	{Key: "registrar_decided_at", Header: "วันที่นายทะเบียนพิจารณา", Width: 14, value: func(r *RequestRecord) string { return exportDecisionDate(r, models.SignRoleRegistrar) }},
+	{Key: "director_decided_at", Header: "วันที่ผู้อำนวยการพิจารณา", Width: 14, value: func(r *RequestRecord) string { return exportDecisionDate(r, models.SignRoleDirector) }},
+	{Key: "date_of_birth", Header: "วันเกิด", Width: 14, value: func(r *RequestRecord) string {
+		dob, err := time.Parse("2006-01-02", r.DateOfBirth)
+		if err != nil {
+			return r.DateOfBirth
+		}
+		return exportThaiDate(dob)
+	}},
+	{Key: "father_name", Header: "ชื่อบิดา", Width: 24, value: func(r *RequestRecord) string { return r.FatherName }},
+	{Key: "mother_name", Header: "ชื่อมารดา", Width: 24, value: func(r *RequestRecord) string { return r.MotherName }},
+	{Key: "requester_email", Header: "อีเมลผู้ยื่น", Width: 24, value: func(r *RequestRecord) string { return r.RequesterEmail }},
+	{Key: "updated_at", Header: "วันที่ปรับปรุงล่าสุด", Width: 14, value: func(r *RequestRecord) string { return exportThaiDate(r.UpdatedAt) }},
+	{Key: "id", Header: "รหัสคำร้อง", Width: 26, value: func(r *RequestRecord) string { return requestIDString(r.ID) }},
+}
+
+// DefaultRequestExportColumns are the logbook columns exported when none are asked for.
+var DefaultRequestExportColumns = []string{
+	"created_at", "name", "document_type", "student_id", "class", "academic_year", "purpose", "status",
+	"registrar_decided_at", "director_decided_at",
+}
+
+// ResolveExportColumns returns the export columns for keys, in the order given,
+// or the default columns when keys is empty.
+func ResolveExportColumns(keys []string) ([]ExportColumn, error) {
+	if len(keys) == 0 {
+		keys = DefaultRequestExportColumns
+	}
+	columns := make([]ExportColumn, 0, len(keys))
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		found := false
+		for _, column := range RequestExportColumns {
+			if column.Key == key {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unsupported column: %s", key)
+		}
+		seen[key] = true
+	}
+	return columns, nil
+}
+
+// requestRowWriter receives export rows one by one.
+type requestRowWriter interface {
+	WriteRow(cells []string) error
+	// Flush pushes buffered rows to the underlying writer where the format allows it.
+	Flush() error
+	Close() error
+}
+
+// ExportRequests writes every request matching query to w as CSV or XLSX,
+// ordered by created_at in the query's direction. Requests are read in
+// keyset-paginated batches, so memory use does not grow with the account. It
+// returns the number of requests written.
+func ExportRequests(ctx context.Context, store RequestStore, query RequestListQuery, columns []ExportColumn, format string, w io.Writer) (int, error) {
+	var rows requestRowWriter
+	switch format {
+	case ExportFormatCSV:
+		rows = newCSVRowWriter(w)
+	case ExportFormatXLSX:
+		xlsx, err := newXLSXRowWriter(w, columns)
+		if err != nil {
+			return 0, err
+		}
+		rows = xlsx
+	default:
+		return 0, ErrUnsupportedExportFormat
+	}
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	if err := rows.WriteRow(headers); err != nil {
+		return 0, err
+	}
+
+	query.Limit = exportBatchSize
+	query.Fields = nil
+	var after *PageCursor
+	written := 0
+	for {
+		batch, err := store.ListByCursor(ctx, query, after)
+		if err != nil {
+			return written, err
+		}
+		for i := range batch {
+			cells := make([]string, len(columns))
+			for j, column := range columns {
+				cells[j] = column.value(&batch[i])
+			}
+			if err := rows.WriteRow(cells); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if err := rows.Flush(); err != nil {
+			return written, err
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		last := batch[len(batch)-1]
+		id, err := ToObjectID(last.ID)
+		if err != nil {
+			return written, err
+		}
+		after = &PageCursor{At: last.CreatedAt, ID: id}
+	}
+	return written, rows.Close()
+}
+
+// ─── CSV ───────────────────────────────────────────────────────────────────────
+
+type csvRowWriter struct {
+	out     io.Writer
+	w       *csv.Writer
+	started bool
+}
+
+func newCSVRowWriter(w io.Writer) *csvRowWriter {
+	return &csvRowWriter{out: w, w: csv.NewWriter(w)}
+}
+
+func (c *csvRowWriter) WriteRow(cells []string) error {
+	if !c.started {
+		// Excel only reads a CSV file as UTF-8 when it starts with a byte order mark
+		if _, err := io.WriteString(c.out, "\ufeff"); err != nil {
+			return err
+		}
+		c.started = true
+	}
+	safe := make([]string, len(cells))
+	for i, cell := range cells {
+		safe[i] = csvSafeCell(cell)
+	}
+	return c.w.Write(safe)
+}
+
+func (c *csvRowWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRowWriter) Close() error {
+	return c.Flush()
+}
+
+// csvSafeCell stops spreadsheet apps from evaluating submitted text as a formula.
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// ─── XLSX ──────────────────────────────────────────────────────────────────────
+
+type xlsxRowWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	out    io.Writer
+	row    int
+	header int // style ID of the header row
+}
+
+func newXLSXRowWriter(w io.Writer, columns []ExportColumn) (*xlsxRowWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", exportSheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(exportSheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for i, column := range columns {
+		if err := stream.SetColWidth(i+1, i+1, column.Width); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	header, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxRowWriter{file: file, stream: stream, out: w, header: header}, nil
+}
+
+func (x *xlsxRowWriter) WriteRow(cells []string) error {
+	x.row++
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		if x.row == 1 {
+			values[i] = excelize.Cell{StyleID: x.header, Value: cell}
+		} else {
+			// every value is written as text so ID numbers keep their leading zeros
+			values[i] = cell
+		}
+	}
+	axis, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(axis, values)
+}
+
+// Flush is a no-op: an XLSX file is a ZIP archive that can only be written once complete.
+func (x *xlsxRowWriter) Flush() error {
+	return nil
+}
+
+func (x *xlsxRowWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.out)
+	return err
+}
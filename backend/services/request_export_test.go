@@ -0,0 +1,50 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestExportRequestsLabelsLegacyStatuses(t *testing.T) {
+	stores := NewMemoryStores()
+	created := time.Now()
+	for i, status := range []models.RequestStatus{"", "completed", "pending"} {
+		if _, err := stores.Requests.Insert(context.Background(), &RequestRecord{
+			AccountID: "acct-1",
+			Name:      "นักเรียน",
+			Status:    status,
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatalf("Insert returned error: %v", err)
+		}
+	}
+
+	columns, err := ResolveExportColumns([]string{"status"})
+	if err != nil {
+		t.Fatalf("ResolveExportColumns returned error: %v", err)
+	}
+	var out bytes.Buffer
+	query := RequestListQuery{AccountID: "acct-1", SortAsc: true}
+	if _, err := ExportRequests(context.Background(), stores.Requests, query, columns, ExportFormatCSV, &out); err != nil {
+		t.Fatalf("ExportRequests returned error: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv export: %v", err)
+	}
+	want := []string{"ยื่นคำร้องแล้ว", "อนุมัติแล้ว", "รอนักเรียนลงนาม"}
+	if len(rows) != len(want)+1 {
+		t.Fatalf("expected header and %d rows, got %q", len(want), rows)
+	}
+	for i, label := range want {
+		if rows[i+1][0] != label {
+			t.Fatalf("expected row %d to read %q, got %q", i+1, label, rows[i+1][0])
+		}
+	}
+}
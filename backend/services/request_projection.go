@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequestFields lists every request field GET /api/requests can return.
+var RequestFields = []string{
+	"id", "account_id", "prefix", "name", "document_type", "id_card", "student_id",
+	"date_of_birth", "class", "room", "academic_year", "father_name", "mother_name",
+	"purpose", "requester_email", "status", "signatures", "decisions", "rejection",
+	"approval_steps", "created_at", "updated_at",
+}
+
+// RequestSummaryFields are the columns GET /api/requests returns by default.
+// Heavy fields such as signatures and decisions are opt-in.
+var RequestSummaryFields = []string{
+	"id", "account_id", "prefix", "name", "document_type", "id_card", "student_id",
+	"class", "room", "academic_year", "purpose", "requester_email", "status",
+	"rejection", "created_at", "updated_at",
+}
+
+// IsValidRequestField reports whether field can be selected with fields= or include=.
+func IsValidRequestField(field string) bool {
+	for _, candidate := range RequestFields {
+		if candidate == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRequestFields returns the fields a listing should carry: fields when
+// given, the summary columns otherwise, plus include. The ID is always present.
+func ResolveRequestFields(fields, include []string) ([]string, error) {
+	base := fields
+	if len(base) == 0 {
+		base = RequestSummaryFields
+	}
+
+	seen := map[string]bool{"id": true}
+	resolved := []string{"id"}
+	for _, field := range append(append([]string(nil), base...), include...) {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !IsValidRequestField(field) {
+			return nil, fmt.Errorf("unsupported field: %s", field)
+		}
+		seen[field] = true
+		resolved = append(resolved, field)
+	}
+	return resolved, nil
+}
+
+// requestFieldBSON maps a request field name to its document key.
+func requestFieldBSON(field string) string {
+	if field == "id" {
+		return "_id"
+	}
+	return field
+}
+
+// ProjectRequests keeps only fields of each record, in a form that encodes to the
+// same JSON as the full record would for those fields.
+func ProjectRequests(records []RequestRecord, fields []string) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(records))
+	for i := range records {
+		record := &records[i]
+		item := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			value, omit := requestFieldValue(record, field)
+			if !omit {
+				item[field] = value
+			}
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// requestFieldValue returns the value of field and whether the full record would
+// omit it from JSON.
+func requestFieldValue(record *RequestRecord, field string) (interface{}, bool) {
+	switch field {
+	case "id":
+		return record.ID, false
+	case "account_id":
+		return record.AccountID, false
+	case "prefix":
+		return record.Prefix, false
+	case "name":
+		return record.Name, false
+	case "document_type":
+		return record.DocumentType, false
+	case "id_card":
+		return record.IDCard, false
+	case "student_id":
+		return record.StudentID, false
+	case "date_of_birth":
+		return record.DateOfBirth, false
+	case "class":
+		return record.Class, false
+	case "room":
+		return record.Room, false
+	case "academic_year":
+		return record.AcademicYear, false
+	case "father_name":
+		return record.FatherName, false
+	case "mother_name":
+		return record.MotherName, false
+	case "purpose":
+		return record.Purpose, false
+	case "requester_email":
+		return record.RequesterEmail, record.RequesterEmail == ""
+	case "status":
+		return CurrentRequestStatus(record), false
+	case "signatures":
+		return record.Signatures, false
+	case "decisions":
+		return record.Decisions, false
+	case "rejection":
+		return record.Rejection, record.Rejection == nil
+	case "approval_steps":
+		return record.ApprovalSteps, len(record.ApprovalSteps) == 0
+	case "created_at":
+		return record.CreatedAt, false
+	case "updated_at":
+		return record.UpdatedAt, false
+	}
+	return nil, true
+}
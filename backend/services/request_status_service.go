@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRequestStatus    = errors.New("invalid request status")
+	ErrIllegalStatusTransition = errors.New("illegal request status transition")
+	ErrRejectionReasonRequired = errors.New("rejection reason is required")
+	ErrRequestClosed           = errors.New("request no longer accepts decisions")
+)
+
+// awaitingStatuses are the in-progress statuses of the approval chain. Chains
+// are configurable per account, so any of them may follow another.
+var awaitingStatuses = []models.RequestStatus{
+	models.RequestStatusAwaitingRegistrar,
+	models.RequestStatusAwaitingDirector,
+	models.RequestStatusAwaitingApproval,
+}
+
+// requestTransitions lists, for each status, the statuses it may move to.
+// Statuses without an entry are terminal.
+var requestTransitions = map[models.RequestStatus][]models.RequestStatus{
+	models.RequestStatusSubmitted: append([]models.RequestStatus{
+		models.RequestStatusAwaitingStudentSignature,
+		models.RequestStatusCancelled,
+	}, awaitingStatuses...),
+	models.RequestStatusAwaitingStudentSignature: append([]models.RequestStatus{
+		models.RequestStatusCancelled,
+	}, awaitingStatuses...),
+	models.RequestStatusAwaitingRegistrar: awaitingTransitions(models.RequestStatusAwaitingRegistrar),
+	models.RequestStatusAwaitingDirector:  awaitingTransitions(models.RequestStatusAwaitingDirector),
+	models.RequestStatusAwaitingApproval:  awaitingTransitions(models.RequestStatusAwaitingApproval),
+	models.RequestStatusApproved: {
+		models.RequestStatusIssued,
+		models.RequestStatusCollected,
+		models.RequestStatusCancelled,
+	},
+	models.RequestStatusIssued: {
+		models.RequestStatusCollected,
+	},
+}
+
+func awaitingTransitions(from models.RequestStatus) []models.RequestStatus {
+	next := []models.RequestStatus{
+		models.RequestStatusApproved,
+		models.RequestStatusRejected,
+		models.RequestStatusCancelled,
+	}
+	for _, status := range awaitingStatuses {
+		if status != from {
+			next = append(next, status)
+		}
+	}
+	return next
+}
+
+// adminRequestStatuses are the statuses an admin may set by hand. The others
+// follow from signatures and decisions, so setting them would skip the chain.
+var adminRequestStatuses = []models.RequestStatus{
+	models.RequestStatusCancelled,
+	models.RequestStatusRejected,
+	models.RequestStatusIssued,
+	models.RequestStatusCollected,
+}
+
+// legacyCompletedStatus is the value older admin clients send to mark a request done.
+const legacyCompletedStatus models.RequestStatus = "completed"
+
+// acceptsDecisions reports whether officials may still sign a request in status.
+func acceptsDecisions(status models.RequestStatus) bool {
+	switch status {
+	case models.RequestStatusSubmitted, models.RequestStatusAwaitingStudentSignature:
+		return true
+	}
+	for _, awaiting := range awaitingStatuses {
+		if status == awaiting {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionRequestStatus reports whether the transition table allows from -> to.
+func CanTransitionRequestStatus(from, to models.RequestStatus) bool {
+	for _, next := range requestTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRequestStatuses returns the statuses a request in status from may move to.
+func NextRequestStatuses(from models.RequestStatus) []models.RequestStatus {
+	return append([]models.RequestStatus(nil), requestTransitions[from]...)
+}
+
+// legacyStatusValues returns the values stored before the state machine existed
+// that CurrentRequestStatus may map to status. A "pending" record resolves from
+// its signatures and decisions, so it is listed for every status it can reach.
+func legacyStatusValues(status models.RequestStatus) []string {
+	switch status {
+	case models.RequestStatusSubmitted:
+		return []string{""}
+	case models.RequestStatusApproved:
+		return []string{"completed", "pending"}
+	case models.RequestStatusAwaitingStudentSignature, models.RequestStatusAwaitingRegistrar,
+		models.RequestStatusAwaitingDirector, models.RequestStatusAwaitingApproval, models.RequestStatusRejected:
+		return []string{"pending"}
+	}
+	return nil
+}
+
+// CurrentRequestStatus returns the lifecycle status of a record, mapping
+// statuses stored before the state machine existed ("", "pending", "completed").
+func CurrentRequestStatus(record *RequestRecord) models.RequestStatus {
+	switch strings.TrimSpace(string(record.Status)) {
+	case "":
+		return models.RequestStatusSubmitted
+	case "completed":
+		return models.RequestStatusApproved
+	case "pending":
+		return ResolveStatusFromDecisions(models.RequestStatusSubmitted, hasStudentSignatureData(record), RequestApprovalSteps(record), record.Decisions)
+	default:
+		return record.Status
+	}
+}
+
+func hasStudentSignatureData(record *RequestRecord) bool {
+	return record.Signatures.Student.HasImage()
+}
+
+func hasDecision(decision *models.OfficialDecision, expected models.OfficialDecisionValue) bool {
+	return decision != nil && decision.Decision == expected
+}
+
+// awaitingStatusForRole maps the next approval step to the status shown for it.
+func awaitingStatusForRole(role models.SignRole) models.RequestStatus {
+	switch role {
+	case models.SignRoleRegistrar:
+		return models.RequestStatusAwaitingRegistrar
+	case models.SignRoleDirector:
+		return models.RequestStatusAwaitingDirector
+	default:
+		return models.RequestStatusAwaitingApproval
+	}
+}
+
+// ResolveStatusFromDecisions returns the status a request should be in given the
+// student signature, its approval chain and official decisions. Statuses that only
+// move by explicit action (approved onwards, rejected, cancelled) are returned unchanged.
+func ResolveStatusFromDecisions(currentStatus models.RequestStatus, studentSigned bool, steps []models.ApprovalStep, decisions models.RequestDecisions) models.RequestStatus {
+	switch currentStatus {
+	case models.RequestStatusApproved, models.RequestStatusRejected, models.RequestStatusIssued,
+		models.RequestStatusCollected, models.RequestStatusCancelled:
+		return currentStatus
+	}
+
+	if role, _ := rejectionFromDecisions(steps, decisions); role != "" {
+		return models.RequestStatusRejected
+	}
+
+	open := OpenApprovalSteps(steps, decisions)
+	if len(open) == 0 {
+		return models.RequestStatusApproved
+	}
+
+	if !studentSigned {
+		return models.RequestStatusAwaitingStudentSignature
+	}
+
+	return awaitingStatusForRole(open[0].Role)
+}
+
+// TransitionRequestStatus moves a request to status to if the transition table
+// allows it, and records the transition in the audit log. Moving to the current
+// status is a no-op.
+func TransitionRequestStatus(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, to models.RequestStatus, actor models.SignRole, ipAddress, userAgent string, accountID string) error {
+	if !models.IsValidRequestStatus(to) {
+		return fmt.Errorf("%w: %s", ErrInvalidRequestStatus, to)
+	}
+
+	record, err := GetRequestByID(ctx, requests, id, accountID)
+	if err != nil {
+		return err
+	}
+
+	from := CurrentRequestStatus(record)
+	if record.Status == to {
+		return nil
+	}
+	if from != to && !CanTransitionRequestStatus(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalStatusTransition, from, to)
+	}
+
+	// Compare-and-set on the stored value so concurrent transitions cannot both win.
+	if err := requests.UpdateStatus(ctx, id, accountID, record.Status, to, time.Now()); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: status changed concurrently", ErrIllegalStatusTransition)
+		}
+		return err
+	}
+
+	audit := models.AuditLog{
+		RequestID:    id,
+		Role:         actor,
+		Action:       "status_change",
+		DocumentHash: ComputeRequestHash(record),
+		FromStatus:   from,
+		ToStatus:     to,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Timestamp:    time.Now().UTC(),
+	}
+	if auditErr := RecordAuditLog(ctx, audits, audit); auditErr != nil {
+		log.Printf("[AUDIT] TransitionRequestStatus Failed: %v", auditErr)
+	}
+
+	return nil
+}
+
+// SetRequestStatusByAdmin applies a status change made by an admin. Only the
+// statuses in adminRequestStatuses may be set; a rejection needs a reason. The
+// legacy "completed" marks an approved request issued and an issued one
+// collected. It returns the status the request moved to.
+func SetRequestStatusByAdmin(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, to models.RequestStatus, reason string, ipAddress, userAgent string, accountID string) (models.RequestStatus, error) {
+	if to == legacyCompletedStatus {
+		record, err := GetRequestByID(ctx, requests, id, accountID)
+		if err != nil {
+			return "", err
+		}
+		switch from := CurrentRequestStatus(record); from {
+		case models.RequestStatusApproved:
+			to = models.RequestStatusIssued
+		case models.RequestStatusIssued:
+			to = models.RequestStatusCollected
+		default:
+			return "", fmt.Errorf("%w: %s -> %s", ErrIllegalStatusTransition, from, legacyCompletedStatus)
+		}
+	}
+	if !models.IsValidRequestStatus(to) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidRequestStatus, to)
+	}
+	allowed := false
+	for _, status := range adminRequestStatuses {
+		if status == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("%w: %s is set by the approval chain", ErrIllegalStatusTransition, to)
+	}
+
+	if to == models.RequestStatusRejected {
+		return to, RejectRequest(ctx, requests, audits, id, models.SignRoleAdmin, reason, ipAddress, userAgent, accountID)
+	}
+	return to, TransitionRequestStatus(ctx, requests, audits, id, to, models.SignRoleAdmin, ipAddress, userAgent, accountID)
+}
+
+// RejectRequest moves a request to the terminal rejected status and records
+// the rejecting role and reason on it.
+func RejectRequest(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, role models.SignRole, reason string, ipAddress, userAgent string, accountID string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrRejectionReasonRequired
+	}
+	return rejectRequest(ctx, requests, audits, id, role, reason, ipAddress, userAgent, accountID)
+}
+
+// rejectRequest skips the reason check so decisions stored before reasons were
+// required can still settle into the rejected status.
+func rejectRequest(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, role models.SignRole, reason string, ipAddress, userAgent string, accountID string) error {
+	if err := TransitionRequestStatus(ctx, requests, audits, id, models.RequestStatusRejected, role, ipAddress, userAgent, accountID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return requests.SetRejection(ctx, id, accountID, models.RequestRejection{
+		Role:       role,
+		Reason:     reason,
+		RejectedAt: now,
+	}, now)
+}
+
+// rejectionFromDecisions returns the first official rejection in chain order.
+func rejectionFromDecisions(steps []models.ApprovalStep, decisions models.RequestDecisions) (models.SignRole, *models.OfficialDecision) {
+	for _, step := range steps {
+		if decision := decisions.ForRole(step.Role); hasDecision(decision, models.OfficialDecisionReject) {
+			return step.Role, decision
+		}
+	}
+	return "", nil
+}
+
+// RecomputeRequestStatus derives the status from signatures and decisions and
+// persists it through TransitionRequestStatus.
+func RecomputeRequestStatus(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, actor models.SignRole, ipAddress, userAgent string, accountID string) (models.RequestStatus, error) {
+	record, err := GetRequestByID(ctx, requests, id, accountID)
+	if err != nil {
+		return "", err
+	}
+
+	current := CurrentRequestStatus(record)
+	steps := RequestApprovalSteps(record)
+	nextStatus := ResolveStatusFromDecisions(current, hasStudentSignatureData(record), steps, record.Decisions)
+	if nextStatus == record.Status {
+		return nextStatus, nil
+	}
+
+	if nextStatus == models.RequestStatusRejected {
+		role, decision := rejectionFromDecisions(steps, record.Decisions)
+		if err := rejectRequest(ctx, requests, audits, id, role, decision.Reason, ipAddress, userAgent, accountID); err != nil {
+			return "", err
+		}
+		return nextStatus, nil
+	}
+
+	if err := TransitionRequestStatus(ctx, requests, audits, id, nextStatus, actor, ipAddress, userAgent, accountID); err != nil {
+		return "", err
+	}
+	return nextStatus, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func saveTestRequest(t *testing.T, stores Stores) primitive.ObjectID {
+	t.Helper()
+	id, err := SaveStudent(context.Background(), stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		Prefix:       "นางสาว",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		DateOfBirth:  "2008-05-01",
+		Purpose:      "ศึกษาต่อ",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	return id
+}
+
+func TestResolveStatusFromDecisions(t *testing.T) {
+	approve := &models.OfficialDecision{Decision: models.OfficialDecisionApprove}
+	reject := &models.OfficialDecision{Decision: models.OfficialDecisionReject}
+
+	cases := []struct {
+		name          string
+		current       models.RequestStatus
+		studentSigned bool
+		decisions     models.RequestDecisions
+		want          models.RequestStatus
+	}{
+		{"unsigned", models.RequestStatusSubmitted, false, models.RequestDecisions{}, models.RequestStatusAwaitingStudentSignature},
+		{"signed", models.RequestStatusAwaitingStudentSignature, true, models.RequestDecisions{}, models.RequestStatusAwaitingRegistrar},
+		{"registrar approved", models.RequestStatusAwaitingRegistrar, true, models.RequestDecisions{Registrar: approve}, models.RequestStatusAwaitingDirector},
+		{"director approved first", models.RequestStatusAwaitingRegistrar, true, models.RequestDecisions{Director: approve}, models.RequestStatusAwaitingRegistrar},
+		{"both approved", models.RequestStatusAwaitingDirector, true, models.RequestDecisions{Registrar: approve, Director: approve}, models.RequestStatusApproved},
+		{"rejected", models.RequestStatusAwaitingDirector, true, models.RequestDecisions{Registrar: approve, Director: reject}, models.RequestStatusRejected},
+		{"cancelled stays", models.RequestStatusCancelled, true, models.RequestDecisions{Registrar: approve, Director: approve}, models.RequestStatusCancelled},
+	}
+	for _, tc := range cases {
+		if got := ResolveStatusFromDecisions(tc.current, tc.studentSigned, DefaultApprovalSteps(), tc.decisions); got != tc.want {
+			t.Fatalf("%s: status mismatch: got %q want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTransitionRequestStatusEnforcesTable(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	id := saveTestRequest(t, stores)
+
+	err := TransitionRequestStatus(ctx, stores.Requests, stores.Audit, id, models.RequestStatusIssued, models.SignRoleAdmin, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrIllegalStatusTransition) {
+		t.Fatalf("expected ErrIllegalStatusTransition, got %v", err)
+	}
+
+	err = TransitionRequestStatus(ctx, stores.Requests, stores.Audit, id, models.RequestStatus("pending"), models.SignRoleAdmin, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrInvalidRequestStatus) {
+		t.Fatalf("expected ErrInvalidRequestStatus, got %v", err)
+	}
+
+	if err := TransitionRequestStatus(ctx, stores.Requests, stores.Audit, id, models.RequestStatusCancelled, models.SignRoleAdmin, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("TransitionRequestStatus returned error: %v", err)
+	}
+	err = TransitionRequestStatus(ctx, stores.Requests, stores.Audit, id, models.RequestStatusAwaitingRegistrar, models.SignRoleAdmin, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrIllegalStatusTransition) {
+		t.Fatalf("expected cancelled to be terminal, got %v", err)
+	}
+
+	record, err := GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	logs, err := GetAuditLogsByHash(ctx, stores.Audit, ComputeRequestHash(record))
+	if err != nil {
+		t.Fatalf("GetAuditLogsByHash returned error: %v", err)
+	}
+	if len(logs) != 1 || logs[0].FromStatus != models.RequestStatusSubmitted || logs[0].ToStatus != models.RequestStatusCancelled {
+		t.Fatalf("unexpected audit logs: %#v", logs)
+	}
+}
+
+func TestCurrentRequestStatusMapsLegacyValues(t *testing.T) {
+	signed := models.RequestSignatures{Student: &models.SignatureBlock{DataBase64: "AA=="}}
+	cases := []struct {
+		record RequestRecord
+		want   models.RequestStatus
+	}{
+		{RequestRecord{Status: ""}, models.RequestStatusSubmitted},
+		{RequestRecord{Status: "pending"}, models.RequestStatusAwaitingStudentSignature},
+		{RequestRecord{Status: "pending", Signatures: signed}, models.RequestStatusAwaitingRegistrar},
+		{RequestRecord{Status: "completed"}, models.RequestStatusApproved},
+		{RequestRecord{Status: models.RequestStatusIssued}, models.RequestStatusIssued},
+	}
+	for _, tc := range cases {
+		if got := CurrentRequestStatus(&tc.record); got != tc.want {
+			t.Fatalf("status mismatch for %q: got %q want %q", tc.record.Status, got, tc.want)
+		}
+	}
+}
+
+func TestOfficialRejectionIsTerminalAndCarriesReason(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	id := saveTestRequest(t, stores)
+
+	sig := models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw", SignedVia: "web"}
+	if err := UpsertSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, sig, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertSignature returned error: %v", err)
+	}
+	if _, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+
+	reject := models.OfficialDecision{Decision: models.OfficialDecisionReject}
+	err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, sig, reject, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrRejectionReasonRequired) {
+		t.Fatalf("expected ErrRejectionReasonRequired, got %v", err)
+	}
+
+	reject.Reason = "  เอกสารไม่ครบ  "
+	if err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, sig, reject, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertOfficialDecisionAndSignature returned error: %v", err)
+	}
+	status, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, "127.0.0.1", "test", "acct-1")
+	if err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+	if status != models.RequestStatusRejected {
+		t.Fatalf("status mismatch: got %q want %q", status, models.RequestStatusRejected)
+	}
+
+	record, err := GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	if record.Rejection == nil || record.Rejection.Role != models.SignRoleRegistrar || record.Rejection.Reason != "เอกสารไม่ครบ" {
+		t.Fatalf("unexpected rejection: %#v", record.Rejection)
+	}
+
+	err = TransitionRequestStatus(ctx, stores.Requests, stores.Audit, id, models.RequestStatusCancelled, models.SignRoleAdmin, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrIllegalStatusTransition) {
+		t.Fatalf("expected rejected to be terminal, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSavedSignatureNotFound    = errors.New("saved signature not found")
+	ErrSavedSignatureUnreadable  = errors.New("saved signature cannot be decrypted")
+	ErrSavedSignatureUnconfirmed = errors.New("confirm_saved_signature is required to apply a saved signature")
+	ErrSavedSignatureNoRecipient = errors.New("sign link has no recipient email")
+	ErrSavedSignatureRole        = errors.New("saved signatures are for official roles only")
+	ErrSavedSignatureNotEmailed  = errors.New("saved signatures can only be used from a sign link delivered by email")
+	ErrSavedSignatureSignIn      = errors.New("sign in as the sign link's recipient to use a saved signature")
+	ErrSignatureKeyMissing       = errors.New("SIGNATURE_ENCRYPTION_KEY (or FORM_LINK_SECRET / JWT_SECRET) must be set in production")
+)
+
+// CheckSignatureEncryptionKey fails in production when saved signatures would be
+// encrypted with the built-in development secret.
+func CheckSignatureEncryptionKey() error {
+	if os.Getenv("GO_ENV") != "production" {
+		return nil
+	}
+	for _, key := range []string{"SIGNATURE_ENCRYPTION_KEY", "FORM_LINK_SECRET", "JWT_SECRET"} {
+		if strings.TrimSpace(os.Getenv(key)) != "" {
+			return nil
+		}
+	}
+	return ErrSignatureKeyMissing
+}
+
+// AuthorizeSavedSignatureLink checks that the signed-in official may read or
+// apply the saved signature of a sign link's recipient. Holding the link is not
+// enough, since the account that issued it could address it to anyone: the link
+// must have been emailed to the recipient, and officialEmails (the caller's
+// verified session emails) must include that recipient.
+func AuthorizeSavedSignatureLink(link *models.SignLink, officialEmails []string) error {
+	if link == nil || strings.TrimSpace(link.RecipientEmail) == "" {
+		return ErrSavedSignatureNoRecipient
+	}
+	if !models.IsValidApprovalRole(link.Role) {
+		return ErrSavedSignatureRole
+	}
+	if link.Channel != "email" || link.LastSentAt == nil {
+		return ErrSavedSignatureNotEmailed
+	}
+	if !matchesOfficialEmail(*link, officialEmails) {
+		return ErrSavedSignatureSignIn
+	}
+	return nil
+}
+
+// signatureEncryptionKey derives the AES-256 key for saved signatures from
+// SIGNATURE_ENCRYPTION_KEY, falling back to the form link secret. Changing the
+// secret makes existing saved signatures unreadable; officials then register again.
+// CheckSignatureEncryptionKey keeps production off the development fallback.
+func signatureEncryptionKey() []byte {
+	secret := strings.TrimSpace(os.Getenv("SIGNATURE_ENCRYPTION_KEY"))
+	if secret == "" {
+		secret = formLinkSecret()
+	}
+	sum := sha256.Sum256([]byte("saved-signature:" + secret))
+	return sum[:]
+}
+
+// savedSignatureAAD binds a ciphertext to its owner so it cannot be copied to
+// another official's record.
+func savedSignatureAAD(accountID, email string, role models.SignRole) []byte {
+	return []byte(accountID + "\n" + email + "\n" + string(role))
+}
+
+func normalizeSignerEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func savedSignatureCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(signatureEncryptionKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SaveOfficialSignature encrypts and stores sig as the official's signature for
+// the account and role, replacing any earlier one.
+func SaveOfficialSignature(ctx context.Context, store SavedSignatureStore, accountID, email string, role models.SignRole, sig models.SignatureBlock) (*models.SavedSignature, error) {
+	email = normalizeSignerEmail(email)
+	if email == "" {
+		return nil, ErrSavedSignatureNoRecipient
+	}
+	if !models.IsValidApprovalRole(role) {
+		return nil, ErrSavedSignatureRole
+	}
+
+	aead, err := savedSignatureCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.SavedSignature{
+		AccountID:  accountID,
+		Email:      email,
+		Role:       role,
+		Method:     sig.Method,
+		Ciphertext: aead.Seal(nil, nonce, []byte(sig.DataBase64), savedSignatureAAD(accountID, email, role)),
+		Nonce:      nonce,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := store.Upsert(ctx, record); err != nil {
+		return nil, err
+	}
+	record.Ciphertext = nil
+	record.Nonce = nil
+	return record, nil
+}
+
+// FindSavedSignature returns the official's saved signature metadata for the
+// account and role without decrypting it.
+func FindSavedSignature(ctx context.Context, store SavedSignatureStore, accountID, email string, role models.SignRole) (*models.SavedSignature, error) {
+	email = normalizeSignerEmail(email)
+	if email == "" {
+		return nil, ErrSavedSignatureNoRecipient
+	}
+	record, err := store.Find(ctx, accountID, email, role)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrSavedSignatureNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// OpenSavedSignature returns the official's saved signature with its decrypted
+// image data URL.
+func OpenSavedSignature(ctx context.Context, store SavedSignatureStore, accountID, email string, role models.SignRole) (*models.SavedSignature, string, error) {
+	record, err := FindSavedSignature(ctx, store, accountID, email, role)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := savedSignatureCipher()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(record.Nonce) != aead.NonceSize() {
+		return nil, "", ErrSavedSignatureUnreadable
+	}
+	plain, err := aead.Open(nil, record.Nonce, record.Ciphertext, savedSignatureAAD(record.AccountID, record.Email, record.Role))
+	if err != nil {
+		return nil, "", ErrSavedSignatureUnreadable
+	}
+	return record, string(plain), nil
+}
+
+// SavedSignatureBlock builds the signature block that applies the official's
+// saved signature. The official must have confirmed it; the block carries the
+// saved signature ID so the audit log records that a stored signature was used.
+func SavedSignatureBlock(ctx context.Context, store SavedSignatureStore, accountID, email string, role models.SignRole, signedVia string, confirmed bool) (models.SignatureBlock, error) {
+	if !confirmed {
+		return models.SignatureBlock{}, ErrSavedSignatureUnconfirmed
+	}
+	record, dataURL, err := OpenSavedSignature(ctx, store, accountID, email, role)
+	if err != nil {
+		return models.SignatureBlock{}, err
+	}
+	savedID := record.ID
+	return models.SignatureBlock{
+		DataBase64:       dataURL,
+		Method:           record.Method,
+		SignedVia:        signedVia,
+		SignedAt:         time.Now(),
+		SavedSignatureID: &savedID,
+	}, nil
+}
+
+// ListSavedSignatures returns the account's saved signatures without their images.
+func ListSavedSignatures(ctx context.Context, store SavedSignatureStore, accountID string) ([]models.SavedSignature, error) {
+	return store.ListByAccountID(ctx, accountID)
+}
+
+// DeleteSavedSignature removes one of the account's saved signatures.
+func DeleteSavedSignature(ctx context.Context, store SavedSignatureStore, id primitive.ObjectID, accountID string) error {
+	if err := store.Delete(ctx, id, accountID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrSavedSignatureNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteOfficialSavedSignature removes the official's own saved signature for the account and role.
+func DeleteOfficialSavedSignature(ctx context.Context, store SavedSignatureStore, accountID, email string, role models.SignRole) error {
+	record, err := FindSavedSignature(ctx, store, accountID, email, role)
+	if err != nil {
+		return err
+	}
+	return DeleteSavedSignature(ctx, store, record.ID, accountID)
+}
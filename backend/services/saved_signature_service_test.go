@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestCheckSignatureEncryptionKeyRequiresSecretInProduction(t *testing.T) {
+	t.Setenv("SIGNATURE_ENCRYPTION_KEY", "")
+	t.Setenv("FORM_LINK_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+
+	t.Setenv("GO_ENV", "development")
+	if err := CheckSignatureEncryptionKey(); err != nil {
+		t.Fatalf("expected the development fallback outside production, got %v", err)
+	}
+	t.Setenv("GO_ENV", "production")
+	if err := CheckSignatureEncryptionKey(); !errors.Is(err, ErrSignatureKeyMissing) {
+		t.Fatalf("expected ErrSignatureKeyMissing, got %v", err)
+	}
+	t.Setenv("FORM_LINK_SECRET", "a-real-secret")
+	if err := CheckSignatureEncryptionKey(); err != nil {
+		t.Fatalf("CheckSignatureEncryptionKey returned error: %v", err)
+	}
+}
+
+func TestAuthorizeSavedSignatureLinkRequiresDeliveredEmailAndRecipient(t *testing.T) {
+	sentAt := time.Now()
+	link := models.SignLink{Role: models.SignRoleDirector, Channel: "email", RecipientEmail: "Director@School.test", LastSentAt: &sentAt}
+	recipient := []string{"director@school.test"}
+
+	if err := AuthorizeSavedSignatureLink(&link, recipient); err != nil {
+		t.Fatalf("AuthorizeSavedSignatureLink returned error: %v", err)
+	}
+	if err := AuthorizeSavedSignatureLink(&link, []string{"owner@school.test"}); !errors.Is(err, ErrSavedSignatureSignIn) {
+		t.Fatalf("expected ErrSavedSignatureSignIn for another official, got %v", err)
+	}
+	if err := AuthorizeSavedSignatureLink(&link, nil); !errors.Is(err, ErrSavedSignatureSignIn) {
+		t.Fatalf("expected ErrSavedSignatureSignIn without a session, got %v", err)
+	}
+
+	copyLink := link
+	copyLink.Channel = "copy"
+	if err := AuthorizeSavedSignatureLink(&copyLink, recipient); !errors.Is(err, ErrSavedSignatureNotEmailed) {
+		t.Fatalf("expected ErrSavedSignatureNotEmailed for a copy link, got %v", err)
+	}
+	unsent := link
+	unsent.LastSentAt = nil
+	if err := AuthorizeSavedSignatureLink(&unsent, recipient); !errors.Is(err, ErrSavedSignatureNotEmailed) {
+		t.Fatalf("expected ErrSavedSignatureNotEmailed for an undelivered link, got %v", err)
+	}
+}
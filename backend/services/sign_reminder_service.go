@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultSignReminderIntervalHours = 48
+	defaultSignReminderMax           = 2
+	defaultSignReminderEscalateHours = 24
+	signReminderCheckInterval        = 15 * time.Minute
+)
+
+// SignReminderConfig controls the reminder scheduler for unsigned sign links.
+type SignReminderConfig struct {
+	// Interval is the wait after the last email before a reminder; 0 disables reminders.
+	Interval time.Duration
+	// MaxReminders caps the reminders sent for one link.
+	MaxReminders int
+	// EscalateBefore warns the account owner once a link is this close to expiring
+	// unsigned; 0 disables escalation.
+	EscalateBefore time.Duration
+	// PublicBaseURL is used for links created before their base URL was stored.
+	PublicBaseURL string
+}
+
+// SignReminderResult counts what one scheduler run sent.
+type SignReminderResult struct {
+	Reminded  int `json:"reminded"`
+	Escalated int `json:"escalated"`
+}
+
+// SignReminderConfigFromEnv reads the scheduler settings:
+// - SIGN_REMINDER_INTERVAL_HOURS : hours between reminders (default 48, 0 disables)
+// - SIGN_REMINDER_MAX            : reminders per link (default 2)
+// - SIGN_REMINDER_ESCALATE_HOURS : hours before expiry to warn the owner (default 24, 0 disables)
+// The fallback base URL is FRONTEND_URL or SIGN_PUBLIC_BASE_URL.
+func SignReminderConfigFromEnv() SignReminderConfig {
+	baseURL := strings.TrimSpace(os.Getenv("FRONTEND_URL"))
+	if baseURL == "" {
+		baseURL = strings.TrimSpace(os.Getenv("SIGN_PUBLIC_BASE_URL"))
+	}
+	return SignReminderConfig{
+		Interval:       time.Duration(envNonNegativeInt("SIGN_REMINDER_INTERVAL_HOURS", defaultSignReminderIntervalHours)) * time.Hour,
+		MaxReminders:   envNonNegativeInt("SIGN_REMINDER_MAX", defaultSignReminderMax),
+		EscalateBefore: time.Duration(envNonNegativeInt("SIGN_REMINDER_ESCALATE_HOURS", defaultSignReminderEscalateHours)) * time.Hour,
+		PublicBaseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func envNonNegativeInt(key string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		log.Printf("Warning: invalid %s %q, using %d", key, raw, fallback)
+		return fallback
+	}
+	return n
+}
+
+// RunSignReminders resends active, unused email sign links whose last email is
+// older than cfg.Interval, up to cfg.MaxReminders times per link, and warns the
+// account owner once per link when it is about to expire unsigned. Each reminder
+// and escalation is claimed in the store first, so concurrent schedulers never
+// send the same one twice.
+func RunSignReminders(ctx context.Context, requests RequestStore, signLinks SignLinkStore, officials OfficialStore, cfg SignReminderConfig, now time.Time) (SignReminderResult, error) {
+	var result SignReminderResult
+	links, err := signLinks.ListActive(ctx, now)
+	if err != nil {
+		return result, err
+	}
+
+	requestCache := map[primitive.ObjectID]*RequestRecord{}
+	brandingCache := map[string]models.EmailBranding{}
+	var firstErr error
+	for _, link := range links {
+		if link.Channel != "email" || strings.TrimSpace(link.RecipientEmail) == "" {
+			continue
+		}
+
+		request, ok := requestCache[link.RequestID]
+		if !ok {
+			request, err = requests.FindByIDUnscoped(ctx, link.RequestID)
+			if err != nil && !errors.Is(err, ErrNotFound) && firstErr == nil {
+				firstErr = err
+			}
+			requestCache[link.RequestID] = request
+		}
+		if request == nil || !acceptsDecisions(CurrentRequestStatus(request)) {
+			continue
+		}
+		branding, ok := brandingCache[request.AccountID]
+		if !ok {
+			branding = LoadEmailBranding(ctx, officials, request.AccountID)
+			brandingCache[request.AccountID] = branding
+		}
+		roleLabel := ApprovalStepLabel(request, link.Role)
+
+		if cfg.EscalateBefore > 0 && link.EscalatedAt == nil && link.ExpiresAt.Sub(now) <= cfg.EscalateBefore {
+			if err := signLinks.MarkEscalated(ctx, link.ID, now); err == nil {
+				if err := SendSignLinkExpiringNotification(ctx, branding, request, roleLabel, link.RecipientEmail, link.ExpiresAt); err != nil {
+					log.Printf("sign link expiry escalation failed for link %s: %v", link.ID.Hex(), err)
+				} else {
+					result.Escalated++
+				}
+			} else if !errors.Is(err, ErrNotFound) && firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		if !signReminderDue(link, cfg, now) {
+			continue
+		}
+		baseURL := link.PublicBaseURL
+		if baseURL == "" {
+			baseURL = cfg.PublicBaseURL
+		}
+		if baseURL == "" {
+			continue
+		}
+		// raw tokens are never stored, so each reminder carries a fresh token
+		// that replaces the one sent before
+		rawToken, err := generateRandomToken(24)
+		if err != nil {
+			continue
+		}
+		if err := signLinks.ClaimReminder(ctx, link.ID, link.ReminderCount, tokenHash(rawToken), now); err != nil {
+			if !errors.Is(err, ErrNotFound) && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err := SendOfficialSignLinkReminder(ctx, branding, request, roleLabel, link.RecipientEmail, SignLinkURL(baseURL, rawToken), link.ExpiresAt); err != nil {
+			log.Printf("sign link reminder failed for link %s: %v", link.ID.Hex(), err)
+			continue
+		}
+		result.Reminded++
+	}
+	return result, firstErr
+}
+
+// signReminderDue reports whether a link should get its next reminder at now.
+func signReminderDue(link models.SignLink, cfg SignReminderConfig, now time.Time) bool {
+	if cfg.Interval <= 0 || link.ReminderCount >= cfg.MaxReminders {
+		return false
+	}
+	lastSent := link.CreatedAt
+	if link.LastSentAt != nil {
+		lastSent = *link.LastSentAt
+	}
+	return now.Sub(lastSent) >= cfg.Interval
+}
+
+// StartSignReminderScheduler runs RunSignReminders every 15 minutes until ctx is cancelled.
+func StartSignReminderScheduler(ctx context.Context, requests RequestStore, signLinks SignLinkStore, officials OfficialStore, cfg SignReminderConfig) {
+	if cfg.Interval <= 0 && cfg.EscalateBefore <= 0 {
+		log.Println("sign link reminders disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(signReminderCheckInterval)
+		defer ticker.Stop()
+		for {
+			runCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			result, err := RunSignReminders(runCtx, requests, signLinks, officials, cfg, time.Now())
+			cancel()
+			if err != nil {
+				log.Printf("sign link reminder run failed: %v", err)
+			}
+			if result.Reminded > 0 || result.Escalated > 0 {
+				log.Printf("sign link reminders: %d reminded, %d escalated", result.Reminded, result.Escalated)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestSignRemindersResendLinkThenEscalateToOwner(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	SetMailer(NewQueueMailer(stores.EmailOutbox))
+	t.Cleanup(func() { SetMailer(nil) })
+
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{Name: "สมชาย ใจดี", DocumentType: "ปพ.1", AccountID: "owner@example.com"}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	link, rawToken, err := CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleRegistrar, "email", "registrar@example.com", "https://example.com/", models.SigningSettings{})
+	if err != nil {
+		t.Fatalf("CreateSignLink returned error: %v", err)
+	}
+
+	cfg := SignReminderConfig{Interval: 48 * time.Hour, MaxReminders: 2, EscalateBefore: 24 * time.Hour}
+	run := func(after time.Duration, wantReminded, wantEscalated int) {
+		t.Helper()
+		result, err := RunSignReminders(ctx, stores.Requests, stores.SignLinks, stores.Officials, cfg, link.CreatedAt.Add(after))
+		if err != nil {
+			t.Fatalf("RunSignReminders returned error: %v", err)
+		}
+		if result.Reminded != wantReminded || result.Escalated != wantEscalated {
+			t.Fatalf("after %v: got %+v, want %d reminded and %d escalated", after, result, wantReminded, wantEscalated)
+		}
+	}
+	run(time.Hour, 0, 0)
+	run(48*time.Hour, 1, 0)
+	run(49*time.Hour, 0, 0)
+	run(96*time.Hour, 1, 0)
+	run(144*time.Hour, 0, 1) // max reminders reached; 24h left on the link
+	run(150*time.Hour, 0, 0)
+
+	emails, err := ListRequestEmails(ctx, stores.EmailOutbox, id, "owner@example.com")
+	if err != nil {
+		t.Fatalf("ListRequestEmails returned error: %v", err)
+	}
+	if len(emails) != 3 {
+		t.Fatalf("expected 3 queued emails, got %d", len(emails))
+	}
+	var reminderTokens []string
+	for _, email := range emails {
+		switch email.Template {
+		case EmailTemplateOfficialSignLink:
+			if email.To != "registrar@example.com" || !strings.Contains(email.Subject, "แจ้งเตือน") {
+				t.Fatalf("unexpected reminder: %#v", email)
+			}
+			_, rest, ok := strings.Cut(email.TextBody, "https://example.com/sign/")
+			if !ok {
+				t.Fatalf("reminder misses the sign URL: %q", email.TextBody)
+			}
+			reminderTokens = append(reminderTokens, strings.Fields(rest)[0])
+		case EmailTemplateOwnerLinkExpiring:
+			if email.To != "owner@example.com" || !strings.Contains(email.TextBody, "registrar@example.com") {
+				t.Fatalf("unexpected escalation: %#v", email)
+			}
+		default:
+			t.Fatalf("unexpected template %q", email.Template)
+		}
+	}
+	if len(reminderTokens) != 2 {
+		t.Fatalf("expected 2 reminders, got %d", len(reminderTokens))
+	}
+
+	// each reminder carries a new token and only the latest one still resolves
+	if reminderTokens[0] == reminderTokens[1] || reminderTokens[0] == rawToken || reminderTokens[1] == rawToken {
+		t.Fatal("expected every reminder to carry a new token")
+	}
+	if _, err := GetSignLinkByRawToken(ctx, stores.SignLinks, rawToken); !errors.Is(err, ErrSignLinkNotFound) {
+		t.Fatalf("expected the original token to be replaced, got %v", err)
+	}
+	resolving := 0
+	for _, token := range reminderTokens {
+		resolved, err := GetSignLinkByRawToken(ctx, stores.SignLinks, token)
+		if errors.Is(err, ErrSignLinkNotFound) {
+			continue
+		}
+		if err != nil || resolved.ID != link.ID || resolved.ReminderCount != 2 {
+			t.Fatalf("GetSignLinkByRawToken returned %#v, %v", resolved, err)
+		}
+		resolving++
+	}
+	if resolving != 1 {
+		t.Fatalf("expected only the latest reminder token to resolve, %d do", resolving)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrSignatureImageNotFound = errors.New("signature image not found")
+
+const signatureMigrationBatch = 100
+
+// blobRequestStore keeps signature images out of request documents: every
+// signature written through it is moved to the blob store first, leaving only
+// the blob reference in the document. Reads return the references untouched;
+// LoadSignatureImages fills the images in when they are needed.
+type blobRequestStore struct {
+	RequestStore
+	blobs BlobStore
+}
+
+// NewBlobRequestStore wraps requests so signature images are stored in blobs.
+func NewBlobRequestStore(requests RequestStore, blobs BlobStore) RequestStore {
+	return &blobRequestStore{RequestStore: requests, blobs: blobs}
+}
+
+func (s *blobRequestStore) Insert(ctx context.Context, record *RequestRecord) (primitive.ObjectID, error) {
+	copied := *record
+	signatures, err := offloadRequestSignatures(ctx, s.blobs, record.Signatures)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	copied.Signatures = signatures
+	return s.RequestStore.Insert(ctx, &copied)
+}
+
+func (s *blobRequestStore) SetSignature(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, at time.Time) error {
+	if err := offloadSignatureImage(ctx, s.blobs, &sig); err != nil {
+		return err
+	}
+	return s.RequestStore.SetSignature(ctx, id, accountID, role, sig, at)
+}
+
+func (s *blobRequestStore) SetOfficialDecision(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecision, at time.Time) error {
+	if err := offloadSignatureImage(ctx, s.blobs, &sig); err != nil {
+		return err
+	}
+	return s.RequestStore.SetOfficialDecision(ctx, id, accountID, role, sig, decision, at)
+}
+
+func offloadRequestSignatures(ctx context.Context, blobs BlobStore, signatures models.RequestSignatures) (models.RequestSignatures, error) {
+	out := models.RequestSignatures{}
+	for _, slot := range []struct {
+		src *models.SignatureBlock
+		dst **models.SignatureBlock
+	}{
+		{signatures.Student, &out.Student},
+		{signatures.Registrar, &out.Registrar},
+		{signatures.Director, &out.Director},
+	} {
+		if slot.src == nil {
+			continue
+		}
+		copied := *slot.src
+		if err := offloadSignatureImage(ctx, blobs, &copied); err != nil {
+			return out, err
+		}
+		*slot.dst = &copied
+	}
+	if signatures.Officials != nil {
+		out.Officials = make(map[string]*models.SignatureBlock, len(signatures.Officials))
+		for role, sig := range signatures.Officials {
+			if sig == nil {
+				continue
+			}
+			copied := *sig
+			if err := offloadSignatureImage(ctx, blobs, &copied); err != nil {
+				return out, err
+			}
+			out.Officials[role] = &copied
+		}
+	}
+	return out, nil
+}
+
+// offloadSignatureImage moves the inline image of sig to blobs and replaces it
+// with the blob reference. Images that cannot be decoded stay inline.
+func offloadSignatureImage(ctx context.Context, blobs BlobStore, sig *models.SignatureBlock) error {
+	if sig == nil || strings.TrimSpace(sig.DataBase64) == "" {
+		return nil
+	}
+	data, imageType, err := decodeSignatureData(sig.DataBase64)
+	if err != nil {
+		log.Printf("keeping undecodable signature image inline: %v", err)
+		return nil
+	}
+	id, err := blobs.Put(ctx, data)
+	if err != nil {
+		return fmt.Errorf("store signature image: %w", err)
+	}
+	sig.BlobID = id
+	sig.ContentHash = BlobID(data)
+	sig.ContentType = "image/png"
+	if imageType == "JPG" {
+		sig.ContentType = "image/jpeg"
+	}
+	sig.DataBase64 = ""
+	return nil
+}
+
+// SignatureImage returns the image bytes and content type of sig, reading blob
+// references from blobs and checking them against their content hash.
+func SignatureImage(ctx context.Context, blobs BlobStore, sig *models.SignatureBlock) ([]byte, string, error) {
+	if sig == nil {
+		return nil, "", ErrSignatureImageNotFound
+	}
+	if sig.BlobID == "" {
+		if strings.TrimSpace(sig.DataBase64) == "" {
+			return nil, "", ErrSignatureImageNotFound
+		}
+		data, imageType, err := decodeSignatureData(sig.DataBase64)
+		if err != nil {
+			return nil, "", err
+		}
+		if imageType == "JPG" {
+			return data, "image/jpeg", nil
+		}
+		return data, "image/png", nil
+	}
+
+	data, err := blobs.Get(ctx, sig.BlobID)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, "", ErrSignatureImageNotFound
+		}
+		return nil, "", err
+	}
+	if sig.ContentHash != "" && BlobID(data) != sig.ContentHash {
+		return nil, "", fmt.Errorf("signature image %s does not match its content hash", sig.BlobID)
+	}
+	contentType := sig.ContentType
+	if contentType == "" {
+		contentType = "image/png"
+	}
+	return data, contentType, nil
+}
+
+// LoadSignatureImages fills DataBase64 of every blob-backed signature of record,
+// for rendering. A missing or unreadable image is logged and left empty.
+func LoadSignatureImages(ctx context.Context, blobs BlobStore, record *RequestRecord) {
+	if record == nil {
+		return
+	}
+	load := func(sig *models.SignatureBlock) {
+		if sig == nil || sig.BlobID == "" || sig.DataBase64 != "" {
+			return
+		}
+		data, contentType, err := SignatureImage(ctx, blobs, sig)
+		if err != nil {
+			log.Printf("failed to load signature image %s: %v", sig.BlobID, err)
+			return
+		}
+		sig.DataBase64 = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	load(record.Signatures.Student)
+	load(record.Signatures.Registrar)
+	load(record.Signatures.Director)
+	for _, sig := range record.Signatures.Officials {
+		load(sig)
+	}
+}
+
+// StripSignatureImages drops inline images from records so listings only carry
+// blob references.
+func StripSignatureImages(records []RequestRecord) {
+	for i := range records {
+		signatures := &records[i].Signatures
+		for _, sig := range []*models.SignatureBlock{signatures.Student, signatures.Registrar, signatures.Director} {
+			if sig != nil {
+				sig.DataBase64 = ""
+			}
+		}
+		for _, sig := range signatures.Officials {
+			if sig != nil {
+				sig.DataBase64 = ""
+			}
+		}
+	}
+}
+
+// MigrateInlineSignatures moves the inline signature images of every request to
+// blobs, in batches. It is safe to run repeatedly and alongside live traffic:
+// a signature replaced since it was read is skipped. It returns the number of
+// signatures moved.
+func MigrateInlineSignatures(ctx context.Context, requests RequestStore, blobs BlobStore) (int, error) {
+	moved := 0
+	afterID := primitive.NilObjectID
+	for {
+		batch, err := requests.ListAfter(ctx, afterID, signatureMigrationBatch)
+		if err != nil {
+			return moved, err
+		}
+		if len(batch) == 0 {
+			return moved, nil
+		}
+		for _, record := range batch {
+			id, err := ToObjectID(record.ID)
+			if err != nil {
+				return moved, err
+			}
+			afterID = id
+
+			roles := []models.SignRole{models.SignRoleStudent, models.SignRoleRegistrar, models.SignRoleDirector}
+			for role := range record.Signatures.Officials {
+				roles = append(roles, models.SignRole(role))
+			}
+			for _, role := range roles {
+				current := record.Signatures.ForRole(role)
+				if current == nil || current.BlobID != "" || strings.TrimSpace(current.DataBase64) == "" {
+					continue
+				}
+				sig := *current
+				if err := offloadSignatureImage(ctx, blobs, &sig); err != nil {
+					return moved, err
+				}
+				if sig.BlobID == "" {
+					continue
+				}
+				if err := requests.ReplaceInlineSignature(ctx, id, role, current.SignedAt, sig); err != nil {
+					if errors.Is(err, ErrNotFound) {
+						continue
+					}
+					return moved, err
+				}
+				moved++
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestMigrateInlineSignaturesMovesImagesToBlobs(t *testing.T) {
+	ctx := context.Background()
+	inline := newMemoryRequestStore()
+	blobs := newMemoryBlobStore()
+	image := "data:image/png;base64,iVBORw0KGgoAAAANSUhEUg=="
+	signedAt := time.Now().Add(-time.Hour)
+
+	// a document written before the blob store existed
+	id, err := inline.Insert(ctx, &RequestRecord{
+		AccountID: "acct-1",
+		Name:      "สมชาย ใจดี",
+		Signatures: models.RequestSignatures{
+			Student:   &models.SignatureBlock{DataBase64: image, Method: "draw", SignedAt: signedAt},
+			Officials: map[string]*models.SignatureBlock{"deputy": {DataBase64: image, Method: "upload", SignedAt: signedAt}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Insert returned error: %v", err)
+	}
+
+	requests := NewBlobRequestStore(inline, blobs)
+	for run, want := range []int{2, 0} {
+		moved, err := MigrateInlineSignatures(ctx, requests, blobs)
+		if err != nil {
+			t.Fatalf("MigrateInlineSignatures returned error: %v", err)
+		}
+		if moved != want {
+			t.Fatalf("run %d: expected %d moved images, got %d", run, want, moved)
+		}
+	}
+
+	record, err := requests.FindByID(ctx, id, "acct-1")
+	if err != nil {
+		t.Fatalf("FindByID returned error: %v", err)
+	}
+	for _, sig := range []*models.SignatureBlock{record.Signatures.Student, record.Signatures.ForRole("deputy")} {
+		if sig.DataBase64 != "" || sig.BlobID == "" || sig.ContentHash != sig.BlobID || !sig.HasImage() {
+			t.Fatalf("expected a blob reference, got %#v", sig)
+		}
+	}
+	if len(blobs.blobs) != 1 {
+		t.Fatalf("expected identical images to share one blob, got %d", len(blobs.blobs))
+	}
+
+	LoadSignatureImages(ctx, blobs, record)
+	if record.Signatures.Student.DataBase64 != image || record.Signatures.ForRole("deputy").DataBase64 != image {
+		t.Fatalf("expected images to load back from the blob store, got %q", record.Signatures.Student.DataBase64)
+	}
+
+	// new signatures never reach the document inline
+	if err := requests.SetSignature(ctx, id, "acct-1", models.SignRoleRegistrar, models.SignatureBlock{DataBase64: image, Method: "draw"}, time.Now()); err != nil {
+		t.Fatalf("SetSignature returned error: %v", err)
+	}
+	list, _, err := GetRequests(ctx, requests, RequestListQuery{AccountID: "acct-1", Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetRequests returned error: %v", err)
+	}
+	if len(list) != 1 || list[0].Signatures.Registrar == nil || list[0].Signatures.Registrar.DataBase64 != "" || list[0].Signatures.Registrar.BlobID == "" {
+		t.Fatalf("unexpected listing: %#v", list)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalBlobStore(t.TempDir())
+
+	id, err := store.Put(ctx, []byte("signature"))
+	if err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	again, err := store.Put(ctx, []byte("signature"))
+	if err != nil || again != id {
+		t.Fatalf("expected the same content to keep its ID, got %q, %v", again, err)
+	}
+	data, err := store.Get(ctx, id)
+	if err != nil || string(data) != "signature" {
+		t.Fatalf("Get returned %q, %v", data, err)
+	}
+	for _, missing := range []string{BlobID([]byte("other")), "../../etc/passwd"} {
+		if _, err := store.Get(ctx, missing); !errors.Is(err, ErrBlobNotFound) {
+			t.Fatalf("expected ErrBlobNotFound for %q, got %v", missing, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+var ErrInvalidSignatureImage = errors.New("invalid signature image")
+
+// Signature image limits. Ink is any pixel that is not transparent and not
+// near-white, so both transparent canvases and white JPEG scans work.
+const (
+	MaxSignatureImageWidth  = 4096
+	MaxSignatureImageHeight = 4096
+	minSignatureInkPixels   = 50
+	minSignatureInkSpan     = 16
+	signatureInkMinAlpha    = 32
+	signatureInkMaxLuma     = 230
+	signatureTrimPadding    = 4
+)
+
+// decodeSignatureBytes splits a data URL or bare base64 string into raw bytes.
+func decodeSignatureBytes(raw string) ([]byte, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, fmt.Errorf("%w: signature data is required", ErrInvalidSignatureImage)
+	}
+
+	encoded := trimmed
+	if strings.HasPrefix(trimmed, "data:") {
+		comma := strings.Index(trimmed, ",")
+		if comma < 0 {
+			return nil, fmt.Errorf("%w: invalid data url", ErrInvalidSignatureImage)
+		}
+		header := strings.ToLower(trimmed[:comma])
+		if !strings.Contains(header, ";base64") {
+			return nil, fmt.Errorf("%w: signature must be base64 encoded", ErrInvalidSignatureImage)
+		}
+		encoded = trimmed[comma+1:]
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid base64 data", ErrInvalidSignatureImage)
+		}
+	}
+	return decoded, nil
+}
+
+// NormalizeSignatureImage decodes a PNG or JPEG signature, rejects oversized,
+// blank and near-blank images, trims the empty margin around the ink and returns
+// the result as a canonical PNG data URL. The format is detected from the bytes,
+// not from the data URL header.
+func NormalizeSignatureImage(raw string) (string, error) {
+	data, err := decodeSignatureBytes(raw)
+	if err != nil {
+		return "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: signature must be a PNG or JPEG image", ErrInvalidSignatureImage)
+	}
+	if format != "png" && format != "jpeg" {
+		return "", fmt.Errorf("%w: unsupported signature image format", ErrInvalidSignatureImage)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > MaxSignatureImageWidth || config.Height > MaxSignatureImageHeight {
+		return "", fmt.Errorf("%w: signature image must be at most %dx%d pixels", ErrInvalidSignatureImage, MaxSignatureImageWidth, MaxSignatureImageHeight)
+	}
+
+	var decoded image.Image
+	if format == "png" {
+		decoded, err = png.Decode(bytes.NewReader(data))
+	} else {
+		decoded, err = jpeg.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: signature image is corrupt", ErrInvalidSignatureImage)
+	}
+
+	bounds := decoded.Bounds()
+	canvas := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), decoded, bounds.Min, draw.Src)
+
+	ink, inkPixels := signatureInkBounds(canvas)
+	if inkPixels < minSignatureInkPixels || (ink.Dx() < minSignatureInkSpan && ink.Dy() < minSignatureInkSpan) {
+		return "", fmt.Errorf("%w: signature is blank", ErrInvalidSignatureImage)
+	}
+
+	trimmed := ink.Inset(-signatureTrimPadding).Intersect(canvas.Bounds())
+	var out bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&out, canvas.SubImage(trimmed)); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(out.Bytes()), nil
+}
+
+// signatureInkBounds returns the bounding box of the ink pixels and their count.
+func signatureInkBounds(img *image.NRGBA) (image.Rectangle, int) {
+	bounds := img.Bounds()
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, bounds.Min.X-1, bounds.Min.Y-1
+	count := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := img.Pix[(y-bounds.Min.Y)*img.Stride:]
+		for x := 0; x < bounds.Dx(); x++ {
+			r, g, b, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+			if a < signatureInkMinAlpha {
+				continue
+			}
+			// Rec. 601 luma, on 0-255
+			luma := (299*int(r) + 587*int(g) + 114*int(b)) / 1000
+			if luma > signatureInkMaxLuma {
+				continue
+			}
+			count++
+			px := bounds.Min.X + x
+			if px < minX {
+				minX = px
+			}
+			if px > maxX {
+				maxX = px
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+	if count == 0 {
+		return image.Rectangle{}, 0
+	}
+	return image.Rect(minX, minY, maxX+1, maxY+1), count
+}
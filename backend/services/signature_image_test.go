@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodeTestImage(t *testing.T, img image.Image, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+	return "data:image/" + format + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestNormalizeSignatureImage(t *testing.T) {
+	stroke := func(background color.Color, w, h int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.Set(x, y, background)
+			}
+		}
+		for x := 100; x < 300; x++ {
+			for dy := 0; dy < 3; dy++ {
+				img.Set(x, 60+dy, color.NRGBA{B: 120, A: 255})
+			}
+		}
+		return img
+	}
+
+	for _, format := range []string{"png", "jpeg"} {
+		background := color.Color(color.NRGBA{})
+		if format == "jpeg" {
+			background = color.White
+		}
+		normalized, err := NormalizeSignatureImage(encodeTestImage(t, stroke(background, 400, 200), format))
+		if err != nil {
+			t.Fatalf("%s: NormalizeSignatureImage returned error: %v", format, err)
+		}
+		if !strings.HasPrefix(normalized, "data:image/png;base64,") {
+			t.Fatalf("%s: expected a PNG data URL, got %q", format, normalized[:30])
+		}
+		data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(normalized, "data:image/png;base64,"))
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: decode normalized image: %v", format, err)
+		}
+		// the margin around the 200x3 stroke is trimmed to the padding
+		if config.Width > 200+2*signatureTrimPadding+4 || config.Height > 3+2*signatureTrimPadding+4 {
+			t.Fatalf("%s: expected a trimmed image, got %dx%d", format, config.Width, config.Height)
+		}
+		again, err := NormalizeSignatureImage(normalized)
+		if err != nil || (format == "png" && again != normalized) {
+			t.Fatalf("%s: expected normalization to be stable, got %v", format, err)
+		}
+	}
+
+	blank := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	speck := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 5; x++ {
+		speck.Set(200+x, 100, color.Black)
+	}
+	rejected := map[string]string{
+		"garbage":    "data:image/png;base64,AA==",
+		"not base64": "data:image/png;base64,@@@",
+		"blank":      encodeTestImage(t, blank, "png"),
+		"white jpeg": encodeTestImage(t, stroke(color.White, 400, 200).SubImage(image.Rect(0, 0, 90, 50)), "jpeg"),
+		"near blank": encodeTestImage(t, speck, "png"),
+		"oversized":  encodeTestImage(t, image.NewNRGBA(image.Rect(0, 0, MaxSignatureImageWidth+1, 10)), "png"),
+	}
+	for name, input := range rejected {
+		if _, err := NormalizeSignatureImage(input); !errors.Is(err, ErrInvalidSignatureImage) {
+			t.Fatalf("%s: expected ErrInvalidSignatureImage, got %v", name, err)
+		}
+	}
+}
@@ -13,9 +13,7 @@ import (
 
 	"backend/models"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var (
@@ -40,7 +38,15 @@ func tokenHash(rawToken string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func CreateSignLink(ctx context.Context, coll *mongo.Collection, requestID primitive.ObjectID, role models.SignRole, channel, recipientEmail string, expiryDays int) (*models.SignLink, string, error) {
+// SignLinkURL returns the public URL of a sign link.
+func SignLinkURL(publicBaseURL, rawToken string) string {
+	return fmt.Sprintf("%s/sign/%s", strings.TrimRight(publicBaseURL, "/"), rawToken)
+}
+
+// CreateSignLink issues a sign link valid for the account's sign link expiry and
+// returns it with its raw token. publicBaseURL is remembered so reminders can
+// build the URL of the link's next token.
+func CreateSignLink(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID, role models.SignRole, channel, recipientEmail, publicBaseURL string, settings models.SigningSettings) (*models.SignLink, string, error) {
 	rawToken, err := generateRandomToken(24)
 	if err != nil {
 		return nil, "", err
@@ -48,36 +54,33 @@ func CreateSignLink(ctx context.Context, coll *mongo.Collection, requestID primi
 
 	now := time.Now()
 	record := models.SignLink{
+		ID:             primitive.NewObjectID(),
 		RequestID:      requestID,
 		Role:           role,
 		TokenHash:      tokenHash(rawToken),
-		ExpiresAt:      now.AddDate(0, 0, expiryDays),
+		ExpiresAt:      now.AddDate(0, 0, withSigningDefaults(settings).SignLinkExpiryDays),
 		Revoked:        false,
 		Channel:        channel,
 		RecipientEmail: recipientEmail,
 		CreatedAt:      now,
+		PublicBaseURL:  strings.TrimRight(strings.TrimSpace(publicBaseURL), "/"),
 	}
 
-	res, err := coll.InsertOne(ctx, record)
-	if err != nil {
+	if err := store.Insert(ctx, &record); err != nil {
 		return nil, "", err
 	}
-	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
-		record.ID = oid
-	}
 	return &record, rawToken, nil
 }
 
-func GetSignLinkByRawToken(ctx context.Context, coll *mongo.Collection, rawToken string) (*models.SignLink, error) {
-	var record models.SignLink
-	err := coll.FindOne(ctx, bson.M{"token_hash": tokenHash(rawToken)}).Decode(&record)
+func GetSignLinkByRawToken(ctx context.Context, store SignLinkStore, rawToken string) (*models.SignLink, error) {
+	record, err := store.FindByTokenHash(ctx, tokenHash(rawToken))
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, ErrNotFound) {
 			return nil, ErrSignLinkNotFound
 		}
 		return nil, err
 	}
-	return &record, nil
+	return record, nil
 }
 
 func ValidateSignLink(record *models.SignLink) error {
@@ -96,19 +99,127 @@ func ValidateSignLink(record *models.SignLink) error {
 	return nil
 }
 
-func MarkSignLinkUsed(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID) error {
-	now := time.Now()
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"used_at": now, "updated_at": now}})
-	return err
+// ListSignLinksByRequest returns every sign link issued for a request, oldest first.
+func ListSignLinksByRequest(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID) ([]models.SignLink, error) {
+	links, err := store.ListByRequestID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = make([]models.SignLink, 0)
+	}
+	return links, nil
+}
+
+// Sign link states reported to admins.
+const (
+	SignLinkStateActive  = "active"
+	SignLinkStateUsed    = "used"
+	SignLinkStateRevoked = "revoked"
+	SignLinkStateExpired = "expired"
+)
+
+// SignLinkSummary is a sign link with its derived state for the admin listing.
+type SignLinkSummary struct {
+	models.SignLink
+	State string `json:"state"`
+}
+
+// SignLinkState derives the state of a link from ValidateSignLink.
+func SignLinkState(link *models.SignLink) string {
+	switch ValidateSignLink(link) {
+	case nil:
+		return SignLinkStateActive
+	case ErrSignLinkUsed:
+		return SignLinkStateUsed
+	case ErrSignLinkRevoked:
+		return SignLinkStateRevoked
+	default:
+		return SignLinkStateExpired
+	}
+}
+
+// SummarizeSignLinks lists the links of a request, oldest first, with their state.
+func SummarizeSignLinks(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID) ([]SignLinkSummary, error) {
+	links, err := ListSignLinksByRequest(ctx, store, requestID)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]SignLinkSummary, 0, len(links))
+	for _, link := range links {
+		summaries = append(summaries, SignLinkSummary{SignLink: link, State: SignLinkState(&link)})
+	}
+	return summaries, nil
+}
+
+// RevokeSignLink revokes one link of a request. Revoking a revoked link is a no-op;
+// used links return ErrSignLinkUsed and links of other requests ErrSignLinkNotFound.
+func RevokeSignLink(ctx context.Context, store SignLinkStore, requestID, linkID primitive.ObjectID) (*SignLinkSummary, error) {
+	links, err := ListSignLinksByRequest(ctx, store, requestID)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if link.ID != linkID {
+			continue
+		}
+		switch SignLinkState(&link) {
+		case SignLinkStateUsed:
+			return nil, ErrSignLinkUsed
+		case SignLinkStateRevoked:
+		default:
+			now := time.Now()
+			if err := store.Revoke(ctx, link.ID, now); err != nil {
+				return nil, err
+			}
+			link.Revoked = true
+			link.RevokedAt = &now
+		}
+		return &SignLinkSummary{SignLink: link, State: SignLinkStateRevoked}, nil
+	}
+	return nil, ErrSignLinkNotFound
+}
+
+// RevokeActiveSignLinks revokes every unused, unexpired link of a request and
+// returns how many were revoked.
+func RevokeActiveSignLinks(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID) (int, error) {
+	return revokeActiveSignLinks(ctx, store, requestID, "", primitive.NilObjectID)
+}
+
+// RevokeActiveRoleSignLinks revokes the unused, unexpired links of one role other
+// than keep, the link that replaces them on reissue, and returns how many were revoked.
+func RevokeActiveRoleSignLinks(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID, role models.SignRole, keep primitive.ObjectID) (int, error) {
+	return revokeActiveSignLinks(ctx, store, requestID, role, keep)
 }
 
-func TouchSignLinkSent(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID) error {
+func revokeActiveSignLinks(ctx context.Context, store SignLinkStore, requestID primitive.ObjectID, role models.SignRole, keep primitive.ObjectID) (int, error) {
+	links, err := ListSignLinksByRequest(ctx, store, requestID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
 	now := time.Now()
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_sent_at": now, "updated_at": now}})
-	return err
+	for _, link := range links {
+		if link.ID == keep || ValidateSignLink(&link) != nil || (role != "" && link.Role != role) {
+			continue
+		}
+		if err := store.Revoke(ctx, link.ID, now); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+func MarkSignLinkUsed(ctx context.Context, store SignLinkStore, id primitive.ObjectID) error {
+	return store.MarkUsed(ctx, id, time.Now())
+}
+
+func TouchSignLinkSent(ctx context.Context, store SignLinkStore, id primitive.ObjectID) error {
+	return store.TouchSent(ctx, id, time.Now())
 }
 
-func CreateSignSession(ctx context.Context, coll *mongo.Collection, requestID primitive.ObjectID, role models.SignRole, signLinkID *primitive.ObjectID, ttl time.Duration) (*models.SignSession, error) {
+func insertSignSession(ctx context.Context, store SignSessionStore, requestID primitive.ObjectID, role models.SignRole, decision models.OfficialDecisionValue, signLinkID *primitive.ObjectID, ttl time.Duration) (*models.SignSession, error) {
 	sessionID, err := generateRandomToken(18)
 	if err != nil {
 		return nil, err
@@ -119,37 +230,33 @@ func CreateSignSession(ctx context.Context, coll *mongo.Collection, requestID pr
 		ID:         sessionID,
 		RequestID:  requestID,
 		Role:       role,
-		Decision:   "",
+		Decision:   decision,
 		SignLinkID: signLinkID,
 		Status:     "pending",
 		ExpiresAt:  now.Add(ttl),
 		CreatedAt:  now,
 	}
 
-	if _, err := coll.InsertOne(ctx, record); err != nil {
+	if err := store.Insert(ctx, &record); err != nil {
 		return nil, err
 	}
 	return &record, nil
 }
 
-func CreateDecisionSignSession(ctx context.Context, coll *mongo.Collection, requestID primitive.ObjectID, role models.SignRole, decision models.OfficialDecisionValue, signLinkID *primitive.ObjectID, ttl time.Duration) (*models.SignSession, error) {
-	if role != models.SignRoleRegistrar && role != models.SignRoleDirector {
+// CreateSignSession starts a QR handoff session valid for the account's sign session TTL.
+func CreateSignSession(ctx context.Context, store SignSessionStore, requestID primitive.ObjectID, role models.SignRole, signLinkID *primitive.ObjectID, settings models.SigningSettings) (*models.SignSession, error) {
+	return insertSignSession(ctx, store, requestID, role, "", signLinkID, SignSessionTTL(settings))
+}
+
+func CreateDecisionSignSession(ctx context.Context, store SignSessionStore, requestID primitive.ObjectID, role models.SignRole, decision models.OfficialDecisionValue, signLinkID *primitive.ObjectID, settings models.SigningSettings) (*models.SignSession, error) {
+	if !models.IsValidApprovalRole(role) {
 		return nil, fmt.Errorf("decision session is only valid for official roles")
 	}
 	if !models.IsValidOfficialDecision(decision) {
 		return nil, fmt.Errorf("invalid official decision")
 	}
 
-	session, err := CreateSignSession(ctx, coll, requestID, role, signLinkID, ttl)
-	if err != nil {
-		return nil, err
-	}
-
-	session.Decision = decision
-	if _, err := coll.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{"decision": decision}}); err != nil {
-		return nil, err
-	}
-	return session, nil
+	return insertSignSession(ctx, store, requestID, role, decision, signLinkID, SignSessionTTL(settings))
 }
 
 type OfficialSignLinkDelivery struct {
@@ -160,83 +267,98 @@ type OfficialSignLinkDelivery struct {
 	Warning        string          `json:"warning,omitempty"`
 }
 
-// CreateAndSendOfficialSignLinks creates and emails sign links for roles with configured emails.
-// It also propagates the accountID.
-func CreateAndSendOfficialSignLinks(ctx context.Context, signLinksColl *mongo.Collection, officialsColl *mongo.Collection, requestID primitive.ObjectID, publicBaseURL string, expiryDays int, accountID string) ([]OfficialSignLinkDelivery, error) {
+// CreateAndSendOfficialSignLinks creates and emails a sign link for each approval
+// step that may sign now. Steps waiting on an earlier step are left for a later
+// call, so a chain whose steps are not parallel is dispatched one step at a time.
+// Steps without a signer email or with an active link already are skipped.
+func CreateAndSendOfficialSignLinks(ctx context.Context, requests RequestStore, signLinks SignLinkStore, officials OfficialStore, requestID primitive.ObjectID, publicBaseURL string, accountID string) ([]OfficialSignLinkDelivery, error) {
 	baseURL := strings.TrimRight(strings.TrimSpace(publicBaseURL), "/")
 	if baseURL == "" {
 		return nil, fmt.Errorf("public base url is required")
 	}
 
-	registrarEmail, directorEmail, err := GetOfficialEmailsFromDB(ctx, officialsColl, accountID)
+	record, err := GetRequestByID(ctx, requests, requestID, accountID)
 	if err != nil {
 		return nil, err
 	}
 
-	targets := []struct {
-		Role  models.SignRole
-		Email string
-	}{
-		{Role: models.SignRoleRegistrar, Email: strings.TrimSpace(registrarEmail)},
-		{Role: models.SignRoleDirector, Email: strings.TrimSpace(directorEmail)},
+	if !acceptsDecisions(CurrentRequestStatus(record)) {
+		return nil, nil
 	}
 
-	results := make([]OfficialSignLinkDelivery, 0, len(targets))
-	for _, target := range targets {
-		if target.Email == "" {
-			continue
+	existing, err := ListSignLinksByRequest(ctx, signLinks, requestID)
+	if err != nil {
+		return nil, err
+	}
+	activeRoles := map[models.SignRole]bool{}
+	for _, link := range existing {
+		if ValidateSignLink(&link) == nil {
+			activeRoles[link.Role] = true
 		}
+	}
 
-		record, rawToken, createErr := CreateSignLink(ctx, signLinksColl, requestID, target.Role, "email", target.Email, expiryDays)
-		if createErr != nil {
-			results = append(results, OfficialSignLinkDelivery{
-				Role:           target.Role,
-				RecipientEmail: target.Email,
-				EmailSent:      false,
-				Warning:        createErr.Error(),
-			})
+	branding := LoadEmailBranding(ctx, officials, accountID)
+	settings := LoadSigningSettings(ctx, officials, accountID)
+	steps := OpenApprovalSteps(RequestApprovalSteps(record), record.Decisions)
+	results := make([]OfficialSignLinkDelivery, 0, len(steps))
+	for _, step := range steps {
+		if activeRoles[step.Role] {
 			continue
 		}
-
-		signURL := fmt.Sprintf("%s/sign/%s", baseURL, rawToken)
-		sendErr := SendOfficialSignLink(ctx, string(target.Role), target.Email, signURL, requestID.Hex())
-		delivery := OfficialSignLinkDelivery{
-			Role:           target.Role,
-			RecipientEmail: target.Email,
-			SignURL:        signURL,
-			EmailSent:      sendErr == nil,
-		}
-		if sendErr != nil {
-			delivery.Warning = sendErr.Error()
-		} else {
-			_ = TouchSignLinkSent(ctx, signLinksColl, record.ID)
+		email := ApprovalStepSignerEmail(ctx, officials, step, accountID)
+		if email == "" {
+			continue
 		}
 
-		results = append(results, delivery)
+		results = append(results, sendOfficialStepSignLink(ctx, signLinks, branding, settings, record, requestID, step, email, baseURL))
 	}
 
 	return results, nil
 }
 
-func GetSignSessionByID(ctx context.Context, coll *mongo.Collection, id string) (*models.SignSession, error) {
-	var record models.SignSession
-	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&record)
+func sendOfficialStepSignLink(ctx context.Context, signLinks SignLinkStore, branding models.EmailBranding, settings models.SigningSettings, request *RequestRecord, requestID primitive.ObjectID, step models.ApprovalStep, email, baseURL string) OfficialSignLinkDelivery {
+	record, rawToken, createErr := CreateSignLink(ctx, signLinks, requestID, step.Role, "email", email, baseURL, settings)
+	if createErr != nil {
+		return OfficialSignLinkDelivery{
+			Role:           step.Role,
+			RecipientEmail: email,
+			EmailSent:      false,
+			Warning:        createErr.Error(),
+		}
+	}
+
+	signURL := SignLinkURL(baseURL, rawToken)
+	sendErr := SendOfficialSignLink(ctx, branding, request, step.Label, email, signURL, record.ExpiresAt)
+	delivery := OfficialSignLinkDelivery{
+		Role:           step.Role,
+		RecipientEmail: email,
+		SignURL:        signURL,
+		EmailSent:      sendErr == nil,
+	}
+	if sendErr != nil {
+		delivery.Warning = sendErr.Error()
+	} else {
+		_ = TouchSignLinkSent(ctx, signLinks, record.ID)
+	}
+	return delivery
+}
+
+func GetSignSessionByID(ctx context.Context, store SignSessionStore, id string) (*models.SignSession, error) {
+	record, err := store.FindByID(ctx, id)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, ErrNotFound) {
 			return nil, ErrSignSessionNotFound
 		}
 		return nil, err
 	}
 
 	if record.Status == "pending" && time.Now().After(record.ExpiresAt) {
-		_, _ = coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": "expired"}})
+		_ = store.MarkExpired(ctx, id)
 		record.Status = "expired"
 	}
-	return &record, nil
+	return record, nil
 }
 
-func CompleteSignSession(ctx context.Context, coll *mongo.Collection, id string) error {
-	now := time.Now()
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": "completed", "completed_at": now}})
-	return err
+func CompleteSignSession(ctx context.Context, store SignSessionStore, id string) error {
+	return store.MarkCompleted(ctx, id, time.Now())
 }
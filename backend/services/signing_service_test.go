@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func activeLinkRoles(t *testing.T, store SignLinkStore, requestID primitive.ObjectID) []models.SignRole {
+	t.Helper()
+	links, err := ListSignLinksByRequest(context.Background(), store, requestID)
+	if err != nil {
+		t.Fatalf("ListSignLinksByRequest returned error: %v", err)
+	}
+	var roles []models.SignRole
+	for _, link := range links {
+		if ValidateSignLink(&link) == nil {
+			roles = append(roles, link.Role)
+		}
+	}
+	return roles
+}
+
+func TestSequentialChainDispatchesLinksStepByStep(t *testing.T) {
+	outbox := useOutboxMailer(t)
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	steps := []models.ApprovalStep{
+		{Role: models.SignRoleRegistrar, Label: "นายทะเบียน", SignerEmail: "registrar@example.com"},
+		{Role: models.SignRoleDirector, Label: "ผู้อำนวยการ", SignerEmail: "director@example.com"},
+	}
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{Name: "สมชาย ใจดี", DocumentType: "ปพ.1", AccountID: "acct-1"}, steps)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	sig := models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw", SignedVia: "web"}
+	if err := UpsertSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, sig, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertSignature returned error: %v", err)
+	}
+	if _, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+
+	dispatch := func() {
+		if _, err := CreateAndSendOfficialSignLinks(ctx, stores.Requests, stores.SignLinks, stores.Officials, id, "http://localhost", "acct-1"); err != nil {
+			t.Fatalf("CreateAndSendOfficialSignLinks returned error: %v", err)
+		}
+	}
+	dispatch()
+	dispatch()
+	if roles := activeLinkRoles(t, stores.SignLinks, id); len(roles) != 1 || roles[0] != models.SignRoleRegistrar {
+		t.Fatalf("expected a single registrar link, got %v", roles)
+	}
+
+	decision := models.OfficialDecision{Decision: models.OfficialDecisionApprove}
+	if err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, sig, decision, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertOfficialDecisionAndSignature returned error: %v", err)
+	}
+	if _, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+	dispatch()
+	roles := activeLinkRoles(t, stores.SignLinks, id)
+	if len(roles) != 2 || roles[1] != models.SignRoleDirector {
+		t.Fatalf("expected director link after registrar approval, got %v", roles)
+	}
+	if messages, err := outbox.Messages(); err != nil || len(messages) != 2 {
+		t.Fatalf("expected one email per step, got %d (%v)", len(messages), err)
+	}
+}
+
+func TestRejectionClosesChainAndRevokesLinks(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{Name: "สมชาย ใจดี", DocumentType: "ปพ.1", AccountID: "acct-1"}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+	if _, _, err := CreateSignLink(ctx, stores.SignLinks, id, models.SignRoleDirector, "email", "director@example.com", "https://example.com", models.SigningSettings{}); err != nil {
+		t.Fatalf("CreateSignLink returned error: %v", err)
+	}
+
+	sig := models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw", SignedVia: "web"}
+	if err := UpsertSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, sig, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertSignature returned error: %v", err)
+	}
+	if _, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+	reject := models.OfficialDecision{Decision: models.OfficialDecisionReject, Reason: "ข้อมูลไม่ครบ"}
+	if err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, sig, reject, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertOfficialDecisionAndSignature returned error: %v", err)
+	}
+	status, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleRegistrar, "127.0.0.1", "test", "acct-1")
+	if err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+	if status != models.RequestStatusRejected {
+		t.Fatalf("status mismatch: got %q want %q", status, models.RequestStatusRejected)
+	}
+
+	revoked, err := RevokeActiveSignLinks(ctx, stores.SignLinks, id)
+	if err != nil {
+		t.Fatalf("RevokeActiveSignLinks returned error: %v", err)
+	}
+	if revoked != 1 || len(activeLinkRoles(t, stores.SignLinks, id)) != 0 {
+		t.Fatalf("expected the director link to be revoked, revoked=%d", revoked)
+	}
+
+	approve := models.OfficialDecision{Decision: models.OfficialDecisionApprove}
+	err = UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleDirector, sig, approve, "127.0.0.1", "test", "acct-1")
+	if !errors.Is(err, ErrRequestClosed) {
+		t.Fatalf("expected ErrRequestClosed after rejection, got %v", err)
+	}
+}
+
+func TestCreateDecisionSignSessionAcceptsCustomApprovalRole(t *testing.T) {
+	stores := NewMemoryStores()
+	requestID := primitive.NewObjectID()
+
+	session, err := CreateDecisionSignSession(context.Background(), stores.SignSessions, requestID, "guidance", models.OfficialDecisionApprove, nil, models.SigningSettings{})
+	if err != nil {
+		t.Fatalf("CreateDecisionSignSession returned error: %v", err)
+	}
+	if session.Role != "guidance" || session.Decision != models.OfficialDecisionApprove {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+
+	if _, err := CreateDecisionSignSession(context.Background(), stores.SignSessions, requestID, models.SignRoleStudent, models.OfficialDecisionApprove, nil, models.SigningSettings{}); err == nil {
+		t.Fatal("expected a student decision session to be rejected")
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidSigningSettings = errors.New("invalid signing settings")
+
+// Sign link and sign session lifetimes. Accounts may choose any value in range;
+// unset values use the defaults.
+const (
+	DefaultSignLinkExpiryDays    = 7
+	MinSignLinkExpiryDays        = 1
+	MaxSignLinkExpiryDays        = 60
+	DefaultSignSessionTTLMinutes = 10
+	MinSignSessionTTLMinutes     = 2
+	MaxSignSessionTTLMinutes     = 60
+)
+
+// withSigningDefaults fills unset lifetimes with the defaults.
+func withSigningDefaults(settings models.SigningSettings) models.SigningSettings {
+	if settings.SignLinkExpiryDays <= 0 {
+		settings.SignLinkExpiryDays = DefaultSignLinkExpiryDays
+	}
+	if settings.SignSessionTTLMinutes <= 0 {
+		settings.SignSessionTTLMinutes = DefaultSignSessionTTLMinutes
+	}
+	return settings
+}
+
+// SignSessionTTL returns the sign session lifetime of effective settings.
+func SignSessionTTL(settings models.SigningSettings) time.Duration {
+	return time.Duration(withSigningDefaults(settings).SignSessionTTLMinutes) * time.Minute
+}
+
+// LoadSigningSettings returns the account's signing settings with defaults applied.
+// Missing settings yield the defaults.
+func LoadSigningSettings(ctx context.Context, officials OfficialStore, accountID string) models.SigningSettings {
+	if officials == nil {
+		return withSigningDefaults(models.SigningSettings{})
+	}
+	doc, err := officials.FindByAccountID(ctx, accountID)
+	if err != nil || doc.SigningSettings == nil {
+		return withSigningDefaults(models.SigningSettings{})
+	}
+	return withSigningDefaults(*doc.SigningSettings)
+}
+
+// SigningSettingsForRequest loads the signing settings of the account owning a
+// request. Public flows only know the request, so the lookup is unscoped; an
+// unknown request yields the defaults.
+func SigningSettingsForRequest(ctx context.Context, requests RequestStore, officials OfficialStore, requestID primitive.ObjectID) models.SigningSettings {
+	record, err := requests.FindByIDUnscoped(ctx, requestID)
+	if err != nil {
+		return withSigningDefaults(models.SigningSettings{})
+	}
+	return LoadSigningSettings(ctx, officials, record.AccountID)
+}
+
+// SaveSigningSettings validates and stores an account's signing settings.
+func SaveSigningSettings(ctx context.Context, officials OfficialStore, accountID string, settings models.SigningSettings) (models.SigningSettings, error) {
+	settings = withSigningDefaults(settings)
+	if settings.SignLinkExpiryDays < MinSignLinkExpiryDays || settings.SignLinkExpiryDays > MaxSignLinkExpiryDays {
+		return models.SigningSettings{}, fmt.Errorf("%w: sign_link_expiry_days must be between %d and %d", ErrInvalidSigningSettings, MinSignLinkExpiryDays, MaxSignLinkExpiryDays)
+	}
+	if settings.SignSessionTTLMinutes < MinSignSessionTTLMinutes || settings.SignSessionTTLMinutes > MaxSignSessionTTLMinutes {
+		return models.SigningSettings{}, fmt.Errorf("%w: sign_session_ttl_minutes must be between %d and %d", ErrInvalidSigningSettings, MinSignSessionTTLMinutes, MaxSignSessionTTLMinutes)
+	}
+
+	if err := officials.SetSigningSettings(ctx, accountID, settings); err != nil {
+		return models.SigningSettings{}, err
+	}
+	return settings, nil
+}
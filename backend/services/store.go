@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotFound is returned by store implementations when no record matches.
+var ErrNotFound = errors.New("record not found")
+
+// RequestFilter narrows a request listing. Empty fields are ignored and
+// non-empty fields are combined with AND.
+type RequestFilter struct {
+	Search       string // every term a case-insensitive substring of name, student_id, id_card or purpose
+	Name         string // case-insensitive substring
+	IDCard       string
+	StudentID    string
+	DocumentType string
+	Status       string // lifecycle status; matches legacy stored values mapped to it
+	AcademicYear string
+	CreatedFrom  *time.Time // inclusive
+	CreatedTo    *time.Time // exclusive
+}
+
+// RequestListQuery scopes, filters, sorts and pages a request listing.
+type RequestListQuery struct {
+	AccountID string
+	Filter    RequestFilter
+	SortField string // one of RequestSortFields; defaults to created_at
+	SortAsc   bool
+	Page      int
+	Limit     int
+	// Fields limits the request fields read, by RequestFields name. Empty reads
+	// every field; stores may return more than asked for.
+	Fields []string
+}
+
+// RequestStore persists student request documents.
+type RequestStore interface {
+	Insert(ctx context.Context, record *RequestRecord) (primitive.ObjectID, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, accountID string) (*RequestRecord, error)
+	// FindByIDUnscoped reads a request without account scoping. Only public
+	// token-based flows (sign links, sign sessions, verification) should use it.
+	FindByIDUnscoped(ctx context.Context, id primitive.ObjectID) (*RequestRecord, error)
+	// FindByTrackingTokenHash reads a request by its requester tracking token hash.
+	FindByTrackingTokenHash(ctx context.Context, hash string) (*RequestRecord, error)
+	List(ctx context.Context, query RequestListQuery) ([]RequestRecord, int64, error)
+	// ListByCursor returns up to query.Limit requests matching query, ordered by
+	// created_at and _id in the query's direction, starting after the cursor (nil
+	// for the first page). Page and SortField are ignored and nothing is counted.
+	ListByCursor(ctx context.Context, query RequestListQuery, after *PageCursor) ([]RequestRecord, error)
+	Stats(ctx context.Context, accountID string) (StatsResult, error)
+	// UpdateStatus moves a request from one status to another. It returns
+	// ErrNotFound when the request is missing or no longer in status from.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, accountID string, from, to models.RequestStatus, at time.Time) error
+	SetSignature(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, at time.Time) error
+	SetOfficialDecision(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecision, at time.Time) error
+	SetRejection(ctx context.Context, id primitive.ObjectID, accountID string, rejection models.RequestRejection, at time.Time) error
+	// SetTrackingToken stores the tracking token version and hash of a request.
+	SetTrackingToken(ctx context.Context, id primitive.ObjectID, accountID string, version int64, hash string, at time.Time) error
+	// ListAfter returns up to limit requests of every account with an ID above
+	// afterID, in ID order. It is meant for maintenance jobs such as migrations.
+	ListAfter(ctx context.Context, afterID primitive.ObjectID, limit int) ([]RequestRecord, error)
+	// ReplaceInlineSignature swaps the inline image of role's signature for sig,
+	// provided the stored signature still has an inline image signed at signedAt.
+	// It returns ErrNotFound otherwise.
+	ReplaceInlineSignature(ctx context.Context, id primitive.ObjectID, role models.SignRole, signedAt time.Time, sig models.SignatureBlock) error
+}
+
+// SignLinkStore persists official sign links.
+type SignLinkStore interface {
+	Insert(ctx context.Context, record *models.SignLink) error
+	FindByTokenHash(ctx context.Context, hash string) (*models.SignLink, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.SignLink, error)
+	// ListByRequestID returns every link of a request, oldest first.
+	ListByRequestID(ctx context.Context, requestID primitive.ObjectID) ([]models.SignLink, error)
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	TouchSent(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// ListActive returns every unused, unrevoked link that has not expired at now.
+	ListActive(ctx context.Context, now time.Time) ([]models.SignLink, error)
+	// ListActiveByRecipient returns the active links sent to any of emails,
+	// matching the addresses case-insensitively.
+	ListActiveByRecipient(ctx context.Context, emails []string, now time.Time) ([]models.SignLink, error)
+	// ClaimReminder records one more reminder for a link still at reminderCount and
+	// replaces its token hash with the one the reminder carries. It returns
+	// ErrNotFound when another scheduler already sent that reminder.
+	ClaimReminder(ctx context.Context, id primitive.ObjectID, reminderCount int, tokenHash string, at time.Time) error
+	// MarkEscalated records the owner escalation once; later calls return ErrNotFound.
+	MarkEscalated(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+// SignSessionStore persists QR handoff sessions.
+type SignSessionStore interface {
+	Insert(ctx context.Context, record *models.SignSession) error
+	FindByID(ctx context.Context, id string) (*models.SignSession, error)
+	MarkExpired(ctx context.Context, id string) error
+	MarkCompleted(ctx context.Context, id string, at time.Time) error
+}
+
+// FormLinkStore persists reusable public form links.
+type FormLinkStore interface {
+	Insert(ctx context.Context, record *models.FormLink) error
+	FindByAccountID(ctx context.Context, accountID string) (*models.FormLink, error)
+	FindByTokenHash(ctx context.Context, hash string) (*models.FormLink, error)
+	// SetToken stores a new token version/hash and clears the revoked flag.
+	SetToken(ctx context.Context, id primitive.ObjectID, version int64, hash string, at time.Time) error
+	RevokeByAccountID(ctx context.Context, accountID string, at time.Time) error
+	TouchUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+// AuditStore persists immutable audit log entries.
+type AuditStore interface {
+	Insert(ctx context.Context, entry models.AuditLog) error
+	FindByHash(ctx context.Context, hash string) ([]models.AuditLog, error)
+	// ListByRequestID returns up to limit entries of a request, oldest first by
+	// timestamp and _id, starting after the cursor (nil for the first page).
+	ListByRequestID(ctx context.Context, requestID primitive.ObjectID, after *PageCursor, limit int) ([]models.AuditLog, error)
+}
+
+// OfficialStore persists per-account official names, emails and school info.
+type OfficialStore interface {
+	FindByAccountID(ctx context.Context, accountID string) (*models.Official, error)
+	// Upsert saves names, emails and school info; EmailBranding and SigningSettings are left untouched.
+	Upsert(ctx context.Context, official models.Official) error
+	SetEmailBranding(ctx context.Context, accountID string, branding models.EmailBranding) error
+	SetSigningSettings(ctx context.Context, accountID string, settings models.SigningSettings) error
+}
+
+// ApprovalChainStore persists per-account approval chain definitions.
+type ApprovalChainStore interface {
+	FindByAccountID(ctx context.Context, accountID string) (*models.ApprovalChain, error)
+	Upsert(ctx context.Context, chain models.ApprovalChain) error
+}
+
+// EmailOutboxStore persists queued emails and their delivery state.
+type EmailOutboxStore interface {
+	Insert(ctx context.Context, entry *models.EmailOutboxEntry) error
+	FindByID(ctx context.Context, id primitive.ObjectID, accountID string) (*models.EmailOutboxEntry, error)
+	// ListByRequestID returns the emails of a request, newest first.
+	ListByRequestID(ctx context.Context, requestID string, accountID string) ([]models.EmailOutboxEntry, error)
+	// ClaimDue atomically leases the oldest entry due at now, including entries whose
+	// previous lease expired. It returns ErrNotFound when nothing is due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.EmailOutboxEntry, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID, attempts int, at time.Time) error
+	// MarkFailed records a failed attempt; status is EmailOutboxFailed or EmailOutboxDead.
+	MarkFailed(ctx context.Context, id primitive.ObjectID, status models.EmailOutboxStatus, attempts int, lastError string, nextAttemptAt time.Time, at time.Time) error
+	// Requeue resets a failed or dead entry to pending. It returns ErrNotFound otherwise.
+	Requeue(ctx context.Context, id primitive.ObjectID, accountID string, at time.Time) error
+}
+
+// SavedSignatureStore persists officials' encrypted saved signatures, one per
+// account, email and role.
+type SavedSignatureStore interface {
+	// Upsert replaces the signature for the record's account, email and role and sets its ID.
+	Upsert(ctx context.Context, record *models.SavedSignature) error
+	Find(ctx context.Context, accountID, email string, role models.SignRole) (*models.SavedSignature, error)
+	ListByAccountID(ctx context.Context, accountID string) ([]models.SavedSignature, error)
+	Delete(ctx context.Context, id primitive.ObjectID, accountID string) error
+}
+
+// LogoutHandleStore persists OIDC logout handles.
+type LogoutHandleStore interface {
+	Insert(ctx context.Context, record *models.LogoutHandle) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.LogoutHandle, error)
+	RevokeByID(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	RevokeBySID(ctx context.Context, sid string, at time.Time) error
+	// UpdateSessionVersionBySID raises the session version of every handle for sid
+	// whose stored version is lower. It returns ErrNotFound when nothing matched.
+	UpdateSessionVersionBySID(ctx context.Context, sid string, version int64, at time.Time) error
+}
+
+// Stores bundles every persistence backend used by handlers and services.
+type Stores struct {
+	Requests       RequestStore
+	SignLinks      SignLinkStore
+	SignSessions   SignSessionStore
+	FormLinks      FormLinkStore
+	Audit          AuditStore
+	Officials      OfficialStore
+	ApprovalChains ApprovalChainStore
+	LogoutHandles  LogoutHandleStore
+	EmailOutbox    EmailOutboxStore
+	Signatures     SavedSignatureStore
+	Blobs          BlobStore
+}
@@ -0,0 +1,1166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMemoryStores builds in-memory stores for tests and local development.
+// Data lives only for the lifetime of the process.
+func NewMemoryStores() Stores {
+	blobs := newMemoryBlobStore()
+	return Stores{
+		Requests:       NewBlobRequestStore(newMemoryRequestStore(), blobs),
+		SignLinks:      newMemorySignLinkStore(),
+		SignSessions:   newMemorySignSessionStore(),
+		FormLinks:      newMemoryFormLinkStore(),
+		Audit:          &memoryAuditStore{},
+		Officials:      newMemoryOfficialStore(),
+		ApprovalChains: newMemoryApprovalChainStore(),
+		LogoutHandles:  newMemoryLogoutHandleStore(),
+		EmailOutbox:    newMemoryEmailOutboxStore(),
+		Signatures:     newMemorySavedSignatureStore(),
+		Blobs:          blobs,
+	}
+}
+
+func cloneSignatureBlock(sig *models.SignatureBlock) *models.SignatureBlock {
+	if sig == nil {
+		return nil
+	}
+	copied := *sig
+	return &copied
+}
+
+func cloneOfficialDecision(decision *models.OfficialDecision) *models.OfficialDecision {
+	if decision == nil {
+		return nil
+	}
+	copied := *decision
+	return &copied
+}
+
+func cloneRequestRecord(record *RequestRecord) *RequestRecord {
+	copied := *record
+	copied.Signatures = models.RequestSignatures{
+		Student:   cloneSignatureBlock(record.Signatures.Student),
+		Registrar: cloneSignatureBlock(record.Signatures.Registrar),
+		Director:  cloneSignatureBlock(record.Signatures.Director),
+	}
+	if record.Signatures.Officials != nil {
+		copied.Signatures.Officials = make(map[string]*models.SignatureBlock, len(record.Signatures.Officials))
+		for role, sig := range record.Signatures.Officials {
+			copied.Signatures.Officials[role] = cloneSignatureBlock(sig)
+		}
+	}
+	copied.Decisions = models.RequestDecisions{
+		Registrar: cloneOfficialDecision(record.Decisions.Registrar),
+		Director:  cloneOfficialDecision(record.Decisions.Director),
+	}
+	if record.Decisions.Officials != nil {
+		copied.Decisions.Officials = make(map[string]*models.OfficialDecision, len(record.Decisions.Officials))
+		for role, decision := range record.Decisions.Officials {
+			copied.Decisions.Officials[role] = cloneOfficialDecision(decision)
+		}
+	}
+	copied.ApprovalSteps = append([]models.ApprovalStep(nil), record.ApprovalSteps...)
+	if record.Rejection != nil {
+		rejection := *record.Rejection
+		copied.Rejection = &rejection
+	}
+	return &copied
+}
+
+func cloneTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// ─── Requests ──────────────────────────────────────────────────────────────────
+
+type memoryRequestStore struct {
+	mu      sync.RWMutex
+	records map[primitive.ObjectID]*RequestRecord
+}
+
+func newMemoryRequestStore() *memoryRequestStore {
+	return &memoryRequestStore{records: make(map[primitive.ObjectID]*RequestRecord)}
+}
+
+func (s *memoryRequestStore) Insert(_ context.Context, record *RequestRecord) (primitive.ObjectID, error) {
+	id := primitive.NewObjectID()
+	stored := cloneRequestRecord(record)
+	stored.ID = id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[id] = stored
+	return id, nil
+}
+
+func (s *memoryRequestStore) FindByID(_ context.Context, id primitive.ObjectID, accountID string) (*RequestRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok || record.AccountID != accountID {
+		return nil, ErrNotFound
+	}
+	return cloneRequestRecord(record), nil
+}
+
+func (s *memoryRequestStore) FindByIDUnscoped(_ context.Context, id primitive.ObjectID) (*RequestRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneRequestRecord(record), nil
+}
+
+func (s *memoryRequestStore) FindByTrackingTokenHash(_ context.Context, hash string) (*RequestRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.records {
+		if hash != "" && record.TrackingTokenHash == hash {
+			return cloneRequestRecord(record), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// matchesRequestFilter mirrors buildRequestMatch. Status is matched on the
+// resolved status, which Mongo can only approximate for legacy "pending" records.
+func matchesRequestFilter(record *RequestRecord, accountID string, f RequestFilter) bool {
+	if record.AccountID != accountID {
+		return false
+	}
+	for _, term := range strings.Fields(strings.ToLower(f.Search)) {
+		found := false
+		for _, field := range []string{record.Name, record.StudentID, record.IDCard, record.Purpose} {
+			if strings.Contains(strings.ToLower(field), term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if name := strings.TrimSpace(f.Name); name != "" && !strings.Contains(strings.ToLower(record.Name), strings.ToLower(name)) {
+		return false
+	}
+	exact := [][2]string{
+		{f.IDCard, record.IDCard},
+		{f.StudentID, record.StudentID},
+		{f.DocumentType, record.DocumentType},
+		{f.Status, string(CurrentRequestStatus(record))},
+		{f.AcademicYear, record.AcademicYear},
+	}
+	for _, pair := range exact {
+		if want := strings.TrimSpace(pair[0]); want != "" && want != pair[1] {
+			return false
+		}
+	}
+	if f.CreatedFrom != nil && record.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !record.CreatedAt.Before(*f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+func requestSortValue(record *RequestRecord, field string) string {
+	switch field {
+	case "name":
+		return record.Name
+	case "id_card":
+		return record.IDCard
+	case "student_id":
+		return record.StudentID
+	case "document_type":
+		return record.DocumentType
+	case "status":
+		return string(record.Status)
+	case "academic_year":
+		return record.AcademicYear
+	default:
+		return ""
+	}
+}
+
+// lessRequest orders records by field, falling back to the ObjectID like the Mongo sort.
+func lessRequest(a, b *RequestRecord, field string) bool {
+	if field == "" || field == "created_at" {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	} else if av, bv := requestSortValue(a, field), requestSortValue(b, field); av != bv {
+		return av < bv
+	}
+	aID, _ := ToObjectID(a.ID)
+	bID, _ := ToObjectID(b.ID)
+	return aID.Hex() < bID.Hex()
+}
+
+func (s *memoryRequestStore) List(_ context.Context, query RequestListQuery) ([]RequestRecord, int64, error) {
+	s.mu.RLock()
+	matched := make([]RequestRecord, 0, len(s.records))
+	for _, record := range s.records {
+		if matchesRequestFilter(record, query.AccountID, query.Filter) {
+			matched = append(matched, *cloneRequestRecord(record))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if query.SortAsc {
+			return lessRequest(&matched[i], &matched[j], query.SortField)
+		}
+		return lessRequest(&matched[j], &matched[i], query.SortField)
+	})
+
+	total := int64(len(matched))
+	start := (query.Page - 1) * query.Limit
+	if start < 0 || start >= len(matched) {
+		return []RequestRecord{}, total, nil
+	}
+	end := start + query.Limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], total, nil
+}
+
+func (s *memoryRequestStore) ListByCursor(_ context.Context, query RequestListQuery, after *PageCursor) ([]RequestRecord, error) {
+	s.mu.RLock()
+	matched := make([]RequestRecord, 0, len(s.records))
+	for id, record := range s.records {
+		if !matchesRequestFilter(record, query.AccountID, query.Filter) {
+			continue
+		}
+		if after != nil && !after.follows(record.CreatedAt, id, query.SortAsc) {
+			continue
+		}
+		matched = append(matched, *cloneRequestRecord(record))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if query.SortAsc {
+			return lessRequest(&matched[i], &matched[j], "created_at")
+		}
+		return lessRequest(&matched[j], &matched[i], "created_at")
+	})
+	if len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+	return matched, nil
+}
+
+func (s *memoryRequestStore) Stats(_ context.Context, accountID string) (StatsResult, error) {
+	byYear := map[int32]int32{}
+	byMonth := map[[2]int32]int32{}
+	var out StatsResult
+
+	s.mu.RLock()
+	for _, record := range s.records {
+		if record.AccountID != accountID {
+			continue
+		}
+		out.Total++
+		created := record.CreatedAt.UTC()
+		byYear[int32(created.Year())]++
+		byMonth[[2]int32{int32(created.Year()), int32(created.Month())}]++
+	}
+	s.mu.RUnlock()
+
+	out.ByYear = make([]YearItem, 0, len(byYear))
+	for year, count := range byYear {
+		out.ByYear = append(out.ByYear, YearItem{Year: year, Count: count})
+	}
+	sort.Slice(out.ByYear, func(i, j int) bool { return out.ByYear[i].Year < out.ByYear[j].Year })
+
+	out.ByMonth = make([]MonthItem, 0, len(byMonth))
+	for key, count := range byMonth {
+		out.ByMonth = append(out.ByMonth, MonthItem{Year: key[0], Month: key[1], Count: count})
+	}
+	sort.Slice(out.ByMonth, func(i, j int) bool {
+		if out.ByMonth[i].Year != out.ByMonth[j].Year {
+			return out.ByMonth[i].Year < out.ByMonth[j].Year
+		}
+		return out.ByMonth[i].Month < out.ByMonth[j].Month
+	})
+
+	return out, nil
+}
+
+// update applies fn to the scoped record. Like UpdateOne, an unmatched filter is not an error.
+func (s *memoryRequestStore) update(id primitive.ObjectID, accountID string, fn func(record *RequestRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.AccountID != accountID {
+		return
+	}
+	fn(record)
+}
+
+func (s *memoryRequestStore) UpdateStatus(_ context.Context, id primitive.ObjectID, accountID string, from, to models.RequestStatus, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.AccountID != accountID || record.Status != from {
+		return ErrNotFound
+	}
+	record.Status = to
+	record.UpdatedAt = at
+	return nil
+}
+
+func setSignatureForRole(record *RequestRecord, role models.SignRole, sig models.SignatureBlock) {
+	switch role {
+	case models.SignRoleStudent:
+		record.Signatures.Student = &sig
+	case models.SignRoleRegistrar:
+		record.Signatures.Registrar = &sig
+	case models.SignRoleDirector:
+		record.Signatures.Director = &sig
+	default:
+		if record.Signatures.Officials == nil {
+			record.Signatures.Officials = make(map[string]*models.SignatureBlock)
+		}
+		record.Signatures.Officials[string(role)] = &sig
+	}
+}
+
+func setDecisionForRole(record *RequestRecord, role models.SignRole, decision models.OfficialDecision) {
+	switch role {
+	case models.SignRoleRegistrar:
+		record.Decisions.Registrar = &decision
+	case models.SignRoleDirector:
+		record.Decisions.Director = &decision
+	default:
+		if record.Decisions.Officials == nil {
+			record.Decisions.Officials = make(map[string]*models.OfficialDecision)
+		}
+		record.Decisions.Officials[string(role)] = &decision
+	}
+}
+
+func (s *memoryRequestStore) SetSignature(_ context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, at time.Time) error {
+	if _, err := signaturePathByRole(role); err != nil {
+		return err
+	}
+	s.update(id, accountID, func(record *RequestRecord) {
+		setSignatureForRole(record, role, sig)
+		record.UpdatedAt = at
+	})
+	return nil
+}
+
+func (s *memoryRequestStore) SetOfficialDecision(_ context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecision, at time.Time) error {
+	if _, err := decisionPathByRole(role); err != nil {
+		return err
+	}
+	s.update(id, accountID, func(record *RequestRecord) {
+		setSignatureForRole(record, role, sig)
+		setDecisionForRole(record, role, decision)
+		record.UpdatedAt = at
+	})
+	return nil
+}
+
+func (s *memoryRequestStore) ListAfter(_ context.Context, afterID primitive.ObjectID, limit int) ([]RequestRecord, error) {
+	s.mu.RLock()
+	ids := make([]primitive.ObjectID, 0, len(s.records))
+	for id := range s.records {
+		if bytes.Compare(id[:], afterID[:]) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	records := make([]RequestRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, *cloneRequestRecord(s.records[id]))
+	}
+	s.mu.RUnlock()
+	return records, nil
+}
+
+func (s *memoryRequestStore) ReplaceInlineSignature(_ context.Context, id primitive.ObjectID, role models.SignRole, signedAt time.Time, sig models.SignatureBlock) error {
+	if _, err := signaturePathByRole(role); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	current := record.Signatures.ForRole(role)
+	if current == nil || current.DataBase64 == "" || !current.SignedAt.Equal(signedAt) {
+		return ErrNotFound
+	}
+	setSignatureForRole(record, role, sig)
+	return nil
+}
+
+func (s *memoryRequestStore) SetRejection(_ context.Context, id primitive.ObjectID, accountID string, rejection models.RequestRejection, at time.Time) error {
+	s.update(id, accountID, func(record *RequestRecord) {
+		record.Rejection = &rejection
+		record.UpdatedAt = at
+	})
+	return nil
+}
+
+func (s *memoryRequestStore) SetTrackingToken(_ context.Context, id primitive.ObjectID, accountID string, version int64, hash string, at time.Time) error {
+	s.update(id, accountID, func(record *RequestRecord) {
+		record.TrackingTokenVersion = version
+		record.TrackingTokenHash = hash
+		record.UpdatedAt = at
+	})
+	return nil
+}
+
+// ─── Sign links ────────────────────────────────────────────────────────────────
+
+type memorySignLinkStore struct {
+	mu      sync.RWMutex
+	records map[primitive.ObjectID]*models.SignLink
+}
+
+func newMemorySignLinkStore() *memorySignLinkStore {
+	return &memorySignLinkStore{records: make(map[primitive.ObjectID]*models.SignLink)}
+}
+
+func cloneSignLink(record *models.SignLink) *models.SignLink {
+	copied := *record
+	copied.UsedAt = cloneTimePtr(record.UsedAt)
+	copied.LastSentAt = cloneTimePtr(record.LastSentAt)
+	copied.RevokedAt = cloneTimePtr(record.RevokedAt)
+	copied.EscalatedAt = cloneTimePtr(record.EscalatedAt)
+	return &copied
+}
+
+func (s *memorySignLinkStore) Insert(_ context.Context, record *models.SignLink) error {
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.ID] = cloneSignLink(record)
+	return nil
+}
+
+func (s *memorySignLinkStore) FindByTokenHash(_ context.Context, hash string) (*models.SignLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.records {
+		if record.TokenHash == hash {
+			return cloneSignLink(record), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memorySignLinkStore) FindByID(_ context.Context, id primitive.ObjectID) (*models.SignLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneSignLink(record), nil
+}
+
+func (s *memorySignLinkStore) ListByRequestID(_ context.Context, requestID primitive.ObjectID) ([]models.SignLink, error) {
+	s.mu.RLock()
+	links := make([]models.SignLink, 0)
+	for _, record := range s.records {
+		if record.RequestID == requestID {
+			links = append(links, *cloneSignLink(record))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CreatedAt.Before(links[j].CreatedAt)
+		}
+		return links[i].ID.Hex() < links[j].ID.Hex()
+	})
+	return links, nil
+}
+
+func (s *memorySignLinkStore) MarkUsed(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.UsedAt = &at
+	}
+	return nil
+}
+
+func (s *memorySignLinkStore) TouchSent(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.LastSentAt = &at
+	}
+	return nil
+}
+
+func (s *memorySignLinkStore) Revoke(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	record.Revoked = true
+	record.RevokedAt = &at
+	return nil
+}
+
+func (s *memorySignLinkStore) ListActive(_ context.Context, now time.Time) ([]models.SignLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	links := make([]models.SignLink, 0)
+	for _, record := range s.records {
+		if !record.Revoked && record.UsedAt == nil && record.ExpiresAt.After(now) {
+			links = append(links, *cloneSignLink(record))
+		}
+	}
+	return links, nil
+}
+
+func (s *memorySignLinkStore) ListActiveByRecipient(_ context.Context, emails []string, now time.Time) ([]models.SignLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	links := make([]models.SignLink, 0)
+	for _, record := range s.records {
+		if !record.Revoked && record.UsedAt == nil && record.ExpiresAt.After(now) && matchesOfficialEmail(*record, emails) {
+			links = append(links, *cloneSignLink(record))
+		}
+	}
+	return links, nil
+}
+
+func (s *memorySignLinkStore) ClaimReminder(_ context.Context, id primitive.ObjectID, reminderCount int, tokenHash string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.ReminderCount != reminderCount {
+		return ErrNotFound
+	}
+	record.ReminderCount++
+	record.TokenHash = tokenHash
+	record.LastSentAt = &at
+	return nil
+}
+
+func (s *memorySignLinkStore) MarkEscalated(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.EscalatedAt != nil {
+		return ErrNotFound
+	}
+	record.EscalatedAt = &at
+	return nil
+}
+
+// ─── Sign sessions ─────────────────────────────────────────────────────────────
+
+type memorySignSessionStore struct {
+	mu      sync.RWMutex
+	records map[string]*models.SignSession
+}
+
+func newMemorySignSessionStore() *memorySignSessionStore {
+	return &memorySignSessionStore{records: make(map[string]*models.SignSession)}
+}
+
+func cloneSignSession(record *models.SignSession) *models.SignSession {
+	copied := *record
+	copied.CompletedAt = cloneTimePtr(record.CompletedAt)
+	if record.SignLinkID != nil {
+		linkID := *record.SignLinkID
+		copied.SignLinkID = &linkID
+	}
+	return &copied
+}
+
+func (s *memorySignSessionStore) Insert(_ context.Context, record *models.SignSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.records[record.ID]; exists {
+		return fmt.Errorf("duplicate sign session id: %s", record.ID)
+	}
+	s.records[record.ID] = cloneSignSession(record)
+	return nil
+}
+
+func (s *memorySignSessionStore) FindByID(_ context.Context, id string) (*models.SignSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneSignSession(record), nil
+}
+
+func (s *memorySignSessionStore) MarkExpired(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.Status = "expired"
+	}
+	return nil
+}
+
+func (s *memorySignSessionStore) MarkCompleted(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.Status = "completed"
+		record.CompletedAt = &at
+	}
+	return nil
+}
+
+// ─── Form links ────────────────────────────────────────────────────────────────
+
+type memoryFormLinkStore struct {
+	mu      sync.RWMutex
+	records map[primitive.ObjectID]*models.FormLink
+}
+
+func newMemoryFormLinkStore() *memoryFormLinkStore {
+	return &memoryFormLinkStore{records: make(map[primitive.ObjectID]*models.FormLink)}
+}
+
+func cloneFormLink(record *models.FormLink) *models.FormLink {
+	copied := *record
+	copied.LastUsedAt = cloneTimePtr(record.LastUsedAt)
+	return &copied
+}
+
+func (s *memoryFormLinkStore) Insert(_ context.Context, record *models.FormLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// mirror the unique indexes created in main.go
+	for _, existing := range s.records {
+		if existing.AccountID == record.AccountID || existing.TokenHash == record.TokenHash {
+			return fmt.Errorf("duplicate form link for account %q", record.AccountID)
+		}
+	}
+	record.ID = primitive.NewObjectID()
+	s.records[record.ID] = cloneFormLink(record)
+	return nil
+}
+
+func (s *memoryFormLinkStore) find(match func(record *models.FormLink) bool) (*models.FormLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.records {
+		if match(record) {
+			return cloneFormLink(record), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryFormLinkStore) FindByAccountID(_ context.Context, accountID string) (*models.FormLink, error) {
+	return s.find(func(record *models.FormLink) bool { return record.AccountID == accountID })
+}
+
+func (s *memoryFormLinkStore) FindByTokenHash(_ context.Context, hash string) (*models.FormLink, error) {
+	return s.find(func(record *models.FormLink) bool { return record.TokenHash == hash })
+}
+
+func (s *memoryFormLinkStore) SetToken(_ context.Context, id primitive.ObjectID, version int64, hash string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.TokenVersion = version
+		record.TokenHash = hash
+		record.Revoked = false
+		record.UpdatedAt = at
+	}
+	return nil
+}
+
+func (s *memoryFormLinkStore) RevokeByAccountID(_ context.Context, accountID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range s.records {
+		if record.AccountID == accountID {
+			record.Revoked = true
+			record.UpdatedAt = at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *memoryFormLinkStore) TouchUsed(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[id]; ok {
+		record.LastUsedAt = &at
+		record.UpdatedAt = at
+	}
+	return nil
+}
+
+// ─── Audit logs ────────────────────────────────────────────────────────────────
+
+type memoryAuditStore struct {
+	mu      sync.RWMutex
+	entries []models.AuditLog
+}
+
+func (s *memoryAuditStore) Insert(_ context.Context, entry models.AuditLog) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memoryAuditStore) ListByRequestID(_ context.Context, requestID primitive.ObjectID, after *PageCursor, limit int) ([]models.AuditLog, error) {
+	s.mu.RLock()
+	logs := make([]models.AuditLog, 0)
+	for _, entry := range s.entries {
+		if entry.RequestID != requestID {
+			continue
+		}
+		if after != nil && !after.follows(entry.Timestamp, entry.ID, true) {
+			continue
+		}
+		logs = append(logs, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].Timestamp.Equal(logs[j].Timestamp) {
+			return logs[i].Timestamp.Before(logs[j].Timestamp)
+		}
+		return bytes.Compare(logs[i].ID[:], logs[j].ID[:]) < 0
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (s *memoryAuditStore) FindByHash(_ context.Context, hash string) ([]models.AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var logs []models.AuditLog
+	for _, entry := range s.entries {
+		if entry.DocumentHash == hash {
+			logs = append(logs, entry)
+		}
+	}
+	return logs, nil
+}
+
+// ─── Officials ─────────────────────────────────────────────────────────────────
+
+type memoryOfficialStore struct {
+	mu      sync.RWMutex
+	records map[string]models.Official
+}
+
+func newMemoryOfficialStore() *memoryOfficialStore {
+	return &memoryOfficialStore{records: make(map[string]models.Official)}
+}
+
+func (s *memoryOfficialStore) FindByAccountID(_ context.Context, accountID string) (*models.Official, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[accountID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if record.EmailBranding != nil {
+		branding := *record.EmailBranding
+		record.EmailBranding = &branding
+	}
+	if record.SigningSettings != nil {
+		settings := *record.SigningSettings
+		record.SigningSettings = &settings
+	}
+	return &record, nil
+}
+
+func (s *memoryOfficialStore) Upsert(_ context.Context, official models.Official) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	official.EmailBranding = s.records[official.AccountID].EmailBranding
+	official.SigningSettings = s.records[official.AccountID].SigningSettings
+	s.records[official.AccountID] = official
+	return nil
+}
+
+func (s *memoryOfficialStore) SetEmailBranding(_ context.Context, accountID string, branding models.EmailBranding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[accountID]
+	record.AccountID = accountID
+	record.EmailBranding = &branding
+	s.records[accountID] = record
+	return nil
+}
+
+func (s *memoryOfficialStore) SetSigningSettings(_ context.Context, accountID string, settings models.SigningSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[accountID]
+	record.AccountID = accountID
+	record.SigningSettings = &settings
+	s.records[accountID] = record
+	return nil
+}
+
+// ─── Approval chains ───────────────────────────────────────────────────────────
+
+type memoryApprovalChainStore struct {
+	mu      sync.RWMutex
+	records map[string]models.ApprovalChain
+}
+
+func newMemoryApprovalChainStore() *memoryApprovalChainStore {
+	return &memoryApprovalChainStore{records: make(map[string]models.ApprovalChain)}
+}
+
+func (s *memoryApprovalChainStore) FindByAccountID(_ context.Context, accountID string) (*models.ApprovalChain, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[accountID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	record.Steps = append([]models.ApprovalStep(nil), record.Steps...)
+	return &record, nil
+}
+
+func (s *memoryApprovalChainStore) Upsert(_ context.Context, chain models.ApprovalChain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chain.Steps = append([]models.ApprovalStep(nil), chain.Steps...)
+	s.records[chain.AccountID] = chain
+	return nil
+}
+
+// ─── Logout handles ────────────────────────────────────────────────────────────
+
+type memoryLogoutHandleStore struct {
+	mu      sync.RWMutex
+	records map[primitive.ObjectID]*models.LogoutHandle
+}
+
+func newMemoryLogoutHandleStore() *memoryLogoutHandleStore {
+	return &memoryLogoutHandleStore{records: make(map[primitive.ObjectID]*models.LogoutHandle)}
+}
+
+func cloneLogoutHandle(record *models.LogoutHandle) *models.LogoutHandle {
+	copied := *record
+	copied.RevokedAt = cloneTimePtr(record.RevokedAt)
+	return &copied
+}
+
+func (s *memoryLogoutHandleStore) Insert(_ context.Context, record *models.LogoutHandle) error {
+	record.ID = primitive.NewObjectID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.ID] = cloneLogoutHandle(record)
+	return nil
+}
+
+func (s *memoryLogoutHandleStore) FindByID(_ context.Context, id primitive.ObjectID) (*models.LogoutHandle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneLogoutHandle(record), nil
+}
+
+func (s *memoryLogoutHandleStore) RevokeByID(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	record.RevokedAt = &at
+	record.UpdatedAt = at
+	return nil
+}
+
+func (s *memoryLogoutHandleStore) RevokeBySID(_ context.Context, sid string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := false
+	for _, record := range s.records {
+		if record.SID == sid {
+			revokedAt := at
+			record.RevokedAt = &revokedAt
+			record.UpdatedAt = at
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *memoryLogoutHandleStore) UpdateSessionVersionBySID(_ context.Context, sid string, version int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := false
+	for _, record := range s.records {
+		if record.SID == sid && record.SessionVersion < version {
+			record.SessionVersion = version
+			record.UpdatedAt = at
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ─── Email outbox ──────────────────────────────────────────────────────────────
+
+type memoryEmailOutboxStore struct {
+	mu      sync.Mutex
+	records map[primitive.ObjectID]*models.EmailOutboxEntry
+}
+
+func newMemoryEmailOutboxStore() *memoryEmailOutboxStore {
+	return &memoryEmailOutboxStore{records: make(map[primitive.ObjectID]*models.EmailOutboxEntry)}
+}
+
+func cloneEmailOutboxEntry(entry *models.EmailOutboxEntry) *models.EmailOutboxEntry {
+	copied := *entry
+	copied.LockedUntil = cloneTimePtr(entry.LockedUntil)
+	copied.SentAt = cloneTimePtr(entry.SentAt)
+	return &copied
+}
+
+func (s *memoryEmailOutboxStore) Insert(_ context.Context, entry *models.EmailOutboxEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	s.records[entry.ID] = cloneEmailOutboxEntry(entry)
+	return nil
+}
+
+func (s *memoryEmailOutboxStore) FindByID(_ context.Context, id primitive.ObjectID, accountID string) (*models.EmailOutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.records[id]
+	if !ok || entry.AccountID != accountID {
+		return nil, ErrNotFound
+	}
+	return cloneEmailOutboxEntry(entry), nil
+}
+
+func (s *memoryEmailOutboxStore) ListByRequestID(_ context.Context, requestID string, accountID string) ([]models.EmailOutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []models.EmailOutboxEntry
+	for _, entry := range s.records {
+		if entry.RequestID == requestID && entry.AccountID == accountID {
+			entries = append(entries, *cloneEmailOutboxEntry(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].ID.Hex() > entries[j].ID.Hex()
+	})
+	return entries, nil
+}
+
+func (s *memoryEmailOutboxStore) ClaimDue(_ context.Context, now time.Time, lease time.Duration) (*models.EmailOutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due *models.EmailOutboxEntry
+	for _, entry := range s.records {
+		ready := false
+		switch entry.Status {
+		case models.EmailOutboxPending, models.EmailOutboxFailed:
+			ready = !entry.NextAttemptAt.After(now)
+		case models.EmailOutboxSending:
+			ready = entry.LockedUntil != nil && entry.LockedUntil.Before(now)
+		}
+		if ready && (due == nil || entry.NextAttemptAt.Before(due.NextAttemptAt)) {
+			due = entry
+		}
+	}
+	if due == nil {
+		return nil, ErrNotFound
+	}
+	lockedUntil := now.Add(lease)
+	due.Status = models.EmailOutboxSending
+	due.LockedUntil = &lockedUntil
+	due.UpdatedAt = now
+	return cloneEmailOutboxEntry(due), nil
+}
+
+func (s *memoryEmailOutboxStore) MarkSent(_ context.Context, id primitive.ObjectID, attempts int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	sentAt := at
+	entry.Status = models.EmailOutboxSent
+	entry.Attempts = attempts
+	entry.SentAt = &sentAt
+	entry.LockedUntil = nil
+	entry.LastError = ""
+	entry.UpdatedAt = at
+	return nil
+}
+
+func (s *memoryEmailOutboxStore) MarkFailed(_ context.Context, id primitive.ObjectID, status models.EmailOutboxStatus, attempts int, lastError string, nextAttemptAt time.Time, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	entry.Status = status
+	entry.Attempts = attempts
+	entry.LastError = lastError
+	entry.NextAttemptAt = nextAttemptAt
+	entry.LockedUntil = nil
+	entry.UpdatedAt = at
+	return nil
+}
+
+func (s *memoryEmailOutboxStore) Requeue(_ context.Context, id primitive.ObjectID, accountID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.records[id]
+	if !ok || entry.AccountID != accountID || (entry.Status != models.EmailOutboxFailed && entry.Status != models.EmailOutboxDead) {
+		return ErrNotFound
+	}
+	entry.Status = models.EmailOutboxPending
+	entry.Attempts = 0
+	entry.NextAttemptAt = at
+	entry.UpdatedAt = at
+	return nil
+}
+
+// ─── Saved signatures ──────────────────────────────────────────────────────────
+
+type memorySavedSignatureStore struct {
+	mu      sync.RWMutex
+	records map[primitive.ObjectID]*models.SavedSignature
+}
+
+func newMemorySavedSignatureStore() *memorySavedSignatureStore {
+	return &memorySavedSignatureStore{records: make(map[primitive.ObjectID]*models.SavedSignature)}
+}
+
+func cloneSavedSignature(record *models.SavedSignature) *models.SavedSignature {
+	copied := *record
+	copied.Ciphertext = append([]byte(nil), record.Ciphertext...)
+	copied.Nonce = append([]byte(nil), record.Nonce...)
+	return &copied
+}
+
+func (s *memorySavedSignatureStore) Upsert(_ context.Context, record *models.SavedSignature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.records {
+		if existing.AccountID == record.AccountID && existing.Email == record.Email && existing.Role == record.Role {
+			record.ID = existing.ID
+			record.CreatedAt = existing.CreatedAt
+			break
+		}
+	}
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	s.records[record.ID] = cloneSavedSignature(record)
+	return nil
+}
+
+func (s *memorySavedSignatureStore) Find(_ context.Context, accountID, email string, role models.SignRole) (*models.SavedSignature, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.records {
+		if record.AccountID == accountID && record.Email == email && record.Role == role {
+			return cloneSavedSignature(record), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memorySavedSignatureStore) ListByAccountID(_ context.Context, accountID string) ([]models.SavedSignature, error) {
+	s.mu.RLock()
+	records := make([]models.SavedSignature, 0)
+	for _, record := range s.records {
+		if record.AccountID == accountID {
+			copied := *record
+			copied.Ciphertext = nil
+			copied.Nonce = nil
+			records = append(records, copied)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Email != records[j].Email {
+			return records[i].Email < records[j].Email
+		}
+		return records[i].Role < records[j].Role
+	})
+	return records, nil
+}
+
+func (s *memorySavedSignatureStore) Delete(_ context.Context, id primitive.ObjectID, accountID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.AccountID != accountID {
+		return ErrNotFound
+	}
+	delete(s.records, id)
+	return nil
+}
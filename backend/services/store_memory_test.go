@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestMemoryStoresSigningWorkflow(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมหญิง ใจงาม",
+		Prefix:       "นางสาว",
+		DocumentType: "ปพ.1",
+		IDCard:       "1234567890123",
+		DateOfBirth:  "2008-05-01",
+		Purpose:      "ศึกษาต่อ",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	if _, err := GetRequestByID(ctx, stores.Requests, id, "acct-2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for foreign account, got %v", err)
+	}
+
+	sig := models.SignatureBlock{DataBase64: "data:image/png;base64,AA==", Method: "draw", SignedVia: "web", SignedAt: time.Now()}
+	if err := UpsertSignature(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, sig, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("UpsertSignature returned error: %v", err)
+	}
+	if _, err := RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, models.SignRoleStudent, "127.0.0.1", "test", "acct-1"); err != nil {
+		t.Fatalf("RecomputeRequestStatus returned error: %v", err)
+	}
+
+	var status models.RequestStatus
+	for _, role := range []models.SignRole{models.SignRoleRegistrar, models.SignRoleDirector} {
+		if err := UpsertOfficialDecisionAndSignature(ctx, stores.Requests, stores.Audit, id, role, sig, models.OfficialDecision{Decision: models.OfficialDecisionApprove}, "127.0.0.1", "test", "acct-1"); err != nil {
+			t.Fatalf("UpsertOfficialDecisionAndSignature(%s) returned error: %v", role, err)
+		}
+		status, err = RecomputeRequestStatus(ctx, stores.Requests, stores.Audit, id, role, "127.0.0.1", "test", "acct-1")
+		if err != nil {
+			t.Fatalf("RecomputeRequestStatus(%s) returned error: %v", role, err)
+		}
+	}
+	if status != models.RequestStatusApproved {
+		t.Fatalf("status mismatch: got %q want %q", status, models.RequestStatusApproved)
+	}
+
+	record, err := GetRequestByID(ctx, stores.Requests, id, "acct-1")
+	if err != nil {
+		t.Fatalf("GetRequestByID returned error: %v", err)
+	}
+	if record.Signatures.Student == nil || record.Signatures.Registrar == nil || record.Signatures.Director == nil {
+		t.Fatalf("expected all signatures to be stored, got %#v", record.Signatures)
+	}
+
+	logs, err := GetAuditLogsByHash(ctx, stores.Audit, ComputeRequestHash(record))
+	if err != nil {
+		t.Fatalf("GetAuditLogsByHash returned error: %v", err)
+	}
+	// three signatures plus three status transitions
+	if len(logs) != 6 {
+		t.Fatalf("expected 6 audit logs, got %d", len(logs))
+	}
+}
+
+func TestMemoryStoresFormLinkRotation(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+
+	first, firstToken, err := GetOrCreateActiveFormLink(ctx, stores.FormLinks, "acct-1")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveFormLink returned error: %v", err)
+	}
+	_, againToken, err := GetOrCreateActiveFormLink(ctx, stores.FormLinks, "acct-1")
+	if err != nil {
+		t.Fatalf("GetOrCreateActiveFormLink returned error: %v", err)
+	}
+	if againToken != firstToken {
+		t.Fatalf("expected stable token, got %q and %q", firstToken, againToken)
+	}
+
+	rotated, rotatedToken, err := RotateFormLink(ctx, stores.FormLinks, "acct-1")
+	if err != nil {
+		t.Fatalf("RotateFormLink returned error: %v", err)
+	}
+	if rotated.ID != first.ID || rotatedToken == firstToken {
+		t.Fatalf("expected rotation to keep the record and change the token")
+	}
+	if _, err := GetFormLinkByRawToken(ctx, stores.FormLinks, firstToken); !errors.Is(err, ErrFormLinkNotFound) {
+		t.Fatalf("expected old token to be rejected, got %v", err)
+	}
+
+	if err := RevokeFormLink(ctx, stores.FormLinks, "acct-1"); err != nil {
+		t.Fatalf("RevokeFormLink returned error: %v", err)
+	}
+	if _, err := GetFormLinkByRawToken(ctx, stores.FormLinks, rotatedToken); !errors.Is(err, ErrFormLinkRevoked) {
+		t.Fatalf("expected revoked token, got %v", err)
+	}
+	if err := RevokeFormLink(ctx, stores.FormLinks, "acct-2"); !errors.Is(err, ErrFormLinkNotFound) {
+		t.Fatalf("expected ErrFormLinkNotFound for unknown account, got %v", err)
+	}
+}
@@ -0,0 +1,1084 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMongoStores builds MongoDB-backed stores using the standard collection names.
+// Signature images of requests are kept in blobs.
+func NewMongoStores(db *mongo.Database, blobs BlobStore) Stores {
+	return Stores{
+		Requests:       NewBlobRequestStore(&mongoRequestStore{coll: db.Collection("students")}, blobs),
+		SignLinks:      &mongoSignLinkStore{coll: db.Collection("sign_links")},
+		SignSessions:   &mongoSignSessionStore{coll: db.Collection("sign_sessions")},
+		FormLinks:      &mongoFormLinkStore{coll: db.Collection("form_links")},
+		Audit:          &mongoAuditStore{coll: db.Collection("audit_logs")},
+		Officials:      &mongoOfficialStore{coll: db.Collection("officials")},
+		ApprovalChains: &mongoApprovalChainStore{coll: db.Collection("approval_chains")},
+		LogoutHandles:  &mongoLogoutHandleStore{coll: db.Collection("logout_handles")},
+		EmailOutbox:    &mongoEmailOutboxStore{coll: db.Collection("email_outbox")},
+		Signatures:     &mongoSavedSignatureStore{coll: db.Collection("saved_signatures")},
+		Blobs:          blobs,
+	}
+}
+
+func mapMongoNotFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func requireMatched(res *mongo.UpdateResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ─── Requests ──────────────────────────────────────────────────────────────────
+
+type mongoRequestStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoRequestStore) Insert(ctx context.Context, record *RequestRecord) (primitive.ObjectID, error) {
+	res, err := s.coll.InsertOne(ctx, bson.M{
+		"account_id":      record.AccountID,
+		"prefix":          record.Prefix,
+		"document_type":   record.DocumentType,
+		"name":            record.Name,
+		"id_card":         record.IDCard,
+		"student_id":      record.StudentID,
+		"class":           record.Class,
+		"room":            record.Room,
+		"academic_year":   record.AcademicYear,
+		"date_of_birth":   record.DateOfBirth,
+		"father_name":     record.FatherName,
+		"mother_name":     record.MotherName,
+		"purpose":         record.Purpose,
+		"requester_email": record.RequesterEmail,
+		"status":          record.Status,
+		"signatures":      record.Signatures,
+		"decisions":       record.Decisions,
+		"approval_steps":  record.ApprovalSteps,
+		"created_at":      record.CreatedAt,
+		"updated_at":      record.UpdatedAt,
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("unexpected inserted id type: %T", res.InsertedID)
+	}
+	return oid, nil
+}
+
+func (s *mongoRequestStore) findOne(ctx context.Context, filter bson.M) (*RequestRecord, error) {
+	var record RequestRecord
+	if err := s.coll.FindOne(ctx, filter).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoRequestStore) FindByID(ctx context.Context, id primitive.ObjectID, accountID string) (*RequestRecord, error) {
+	return s.findOne(ctx, bson.M{"_id": id, "account_id": accountID})
+}
+
+func (s *mongoRequestStore) FindByIDUnscoped(ctx context.Context, id primitive.ObjectID) (*RequestRecord, error) {
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+func (s *mongoRequestStore) FindByTrackingTokenHash(ctx context.Context, hash string) (*RequestRecord, error) {
+	return s.findOne(ctx, bson.M{"tracking_token_hash": hash})
+}
+
+// buildRequestMatch translates a listing query into a Mongo filter. Every field
+// is covered by one of the students indexes created in main.go. Search matches
+// substrings rather than using a text index, since Thai is written without
+// spaces between words and a text index would only find whole names.
+func buildRequestMatch(accountID string, f RequestFilter) bson.M {
+	filter := bson.M{"account_id": accountID}
+	if terms := strings.Fields(f.Search); len(terms) > 0 {
+		all := bson.A{}
+		for _, term := range terms {
+			pattern := primitive.Regex{Pattern: regexp.QuoteMeta(term), Options: "i"}
+			any := bson.A{}
+			for _, field := range []string{"name", "student_id", "id_card", "purpose"} {
+				any = append(any, bson.M{field: pattern})
+			}
+			all = append(all, bson.M{"$or": any})
+		}
+		filter["$and"] = all
+	}
+	if name := strings.TrimSpace(f.Name); name != "" {
+		filter["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}
+	}
+	if status := strings.TrimSpace(f.Status); status != "" {
+		values := bson.A{status}
+		for _, legacy := range legacyStatusValues(models.RequestStatus(status)) {
+			values = append(values, legacy)
+			if legacy == "" {
+				// documents from before statuses were stored have no status at all
+				values = append(values, nil)
+			}
+		}
+		filter["status"] = bson.M{"$in": values}
+	}
+	exact := map[string]string{
+		"id_card":       f.IDCard,
+		"student_id":    f.StudentID,
+		"document_type": f.DocumentType,
+		"academic_year": f.AcademicYear,
+	}
+	for key, value := range exact {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			filter[key] = trimmed
+		}
+	}
+	if f.CreatedFrom != nil || f.CreatedTo != nil {
+		createdRange := bson.M{}
+		if f.CreatedFrom != nil {
+			createdRange["$gte"] = *f.CreatedFrom
+		}
+		if f.CreatedTo != nil {
+			createdRange["$lt"] = *f.CreatedTo
+		}
+		filter["created_at"] = createdRange
+	}
+	return filter
+}
+
+func (s *mongoRequestStore) List(ctx context.Context, query RequestListQuery) ([]RequestRecord, int64, error) {
+	// Calculate skip value for pagination
+	skip := (query.Page - 1) * query.Limit
+	filter := buildRequestMatch(query.AccountID, query.Filter)
+
+	// Get total count
+	total, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results, sorted by the requested column (newest first by default)
+	// with _id as a tie-breaker so pages are stable.
+	sortField := query.SortField
+	if sortField == "" {
+		sortField = "created_at"
+	}
+	direction := -1
+	if query.SortAsc {
+		direction = 1
+	}
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}}},
+		bson.D{{Key: "$skip", Value: skip}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	}
+	pipeline = append(pipeline, requestListProjection(query.Fields)...)
+
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var requests []RequestRecord
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+func (s *mongoRequestStore) ListByCursor(ctx context.Context, query RequestListQuery, after *PageCursor) ([]RequestRecord, error) {
+	match := buildRequestMatch(query.AccountID, query.Filter)
+	if after != nil {
+		match = bson.M{"$and": bson.A{match, keysetMatch("created_at", after, query.SortAsc)}}
+	}
+	direction := -1
+	if query.SortAsc {
+		direction = 1
+	}
+	// no $skip and no count: the (account_id, created_at, _id) index serves the page directly
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	}
+	pipeline = append(pipeline, requestListProjection(withCursorFields(query.Fields))...)
+
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	requests := make([]RequestRecord, 0)
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// withCursorFields adds the keyset fields to a field selection, since the next
+// page cursor is built from the created_at and _id of the last record.
+// ProjectRequests drops them again from the response when they were not asked for.
+func withCursorFields(fields []string) []string {
+	if len(fields) == 0 {
+		return fields
+	}
+	out := append([]string{}, fields...)
+	for _, required := range []string{"id", "created_at"} {
+		found := false
+		for _, field := range fields {
+			if field == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, required)
+		}
+	}
+	return out
+}
+
+// statusSourceFields are the fields CurrentRequestStatus reads to map legacy
+// statuses, loaded whenever a listing selects status.
+var statusSourceFields = []string{"signatures", "decisions", "approval_steps"}
+
+// requestListProjection returns the projection stages of a request listing: only
+// fields when given, and never inline signature images of documents not yet
+// migrated to the blob store, for the fixed roles and custom approval steps alike.
+func requestListProjection(fields []string) []bson.D {
+	var stages []bson.D
+	if len(fields) > 0 {
+		projection := bson.D{}
+		seen := map[string]bool{}
+		add := func(field string) {
+			if !seen[field] {
+				seen[field] = true
+				projection = append(projection, bson.E{Key: requestFieldBSON(field), Value: 1})
+			}
+		}
+		for _, field := range fields {
+			add(field)
+			if field == "status" {
+				for _, source := range statusSourceFields {
+					add(source)
+				}
+			}
+		}
+		stages = append(stages, bson.D{{Key: "$project", Value: projection}})
+	}
+	return append(stages,
+		bson.D{{Key: "$project", Value: bson.M{
+			"signatures.student.data_base64":   0,
+			"signatures.registrar.data_base64": 0,
+			"signatures.director.data_base64":  0,
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"signatures": withoutOfficialImages()}}},
+	)
+}
+
+// withoutOfficialImages is an expression for the signatures field with
+// data_base64 removed from every signatures.officials entry. Officials is keyed
+// by role, so the keys cannot be listed in an exclusion projection.
+func withoutOfficialImages() bson.M {
+	withoutImage := bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$$official.v"}, "object"}},
+		bson.M{"$arrayToObject": bson.M{"$filter": bson.M{
+			"input": bson.M{"$objectToArray": "$$official.v"},
+			"as":    "field",
+			"cond":  bson.M{"$ne": bson.A{"$$field.k", "data_base64"}},
+		}}},
+		"$$official.v",
+	}}
+	officials := bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$signatures.officials"}, "object"}},
+		bson.M{"$arrayToObject": bson.M{"$map": bson.M{
+			"input": bson.M{"$objectToArray": "$signatures.officials"},
+			"as":    "official",
+			"in":    bson.M{"k": "$$official.k", "v": withoutImage},
+		}}},
+		"$$REMOVE",
+	}}
+	// documents without signatures, or listings that did not select them, stay without
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$signatures"}, "object"}},
+		bson.M{"$mergeObjects": bson.A{"$signatures", bson.M{"officials": officials}}},
+		"$$REMOVE",
+	}}
+}
+
+func (s *mongoRequestStore) Stats(ctx context.Context, accountID string) (StatsResult, error) {
+	var out StatsResult
+
+	filter := bson.M{"account_id": accountID}
+	total, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return out, err
+	}
+	out.Total = total
+
+	// aggregation by year
+	type yearAgg struct {
+		ID    int32 `bson:"_id"`
+		Count int32 `bson:"count"`
+	}
+	yearPipe := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$year", Value: "$created_at"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	yearCur, err := s.coll.Aggregate(ctx, yearPipe)
+	if err != nil {
+		return out, err
+	}
+	var yearRes []yearAgg
+	if err := yearCur.All(ctx, &yearRes); err != nil {
+		return out, err
+	}
+	out.ByYear = make([]YearItem, 0, len(yearRes))
+	for _, it := range yearRes {
+		out.ByYear = append(out.ByYear, YearItem{Year: it.ID, Count: it.Count})
+	}
+
+	// aggregation by month (year + month)
+	type monthAgg struct {
+		ID struct {
+			Y int32 `bson:"y"`
+			M int32 `bson:"m"`
+		} `bson:"_id"`
+		Count int32 `bson:"count"`
+	}
+	monthPipe := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "y", Value: bson.D{{Key: "$year", Value: "$created_at"}}},
+				{Key: "m", Value: bson.D{{Key: "$month", Value: "$created_at"}}},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id.y", Value: 1}, {Key: "_id.m", Value: 1}}}},
+	}
+	monthCur, err := s.coll.Aggregate(ctx, monthPipe)
+	if err != nil {
+		return out, err
+	}
+	var monthRes []monthAgg
+	if err := monthCur.All(ctx, &monthRes); err != nil {
+		return out, err
+	}
+	out.ByMonth = make([]MonthItem, 0, len(monthRes))
+	for _, it := range monthRes {
+		out.ByMonth = append(out.ByMonth, MonthItem{Year: it.ID.Y, Month: it.ID.M, Count: it.Count})
+	}
+
+	return out, nil
+}
+
+func (s *mongoRequestStore) UpdateStatus(ctx context.Context, id primitive.ObjectID, accountID string, from, to models.RequestStatus, at time.Time) error {
+	filter := bson.M{"_id": id, "account_id": accountID, "status": from}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     to,
+			"updated_at": at,
+		},
+	}
+	return requireMatched(s.coll.UpdateOne(ctx, filter, update))
+}
+
+func (s *mongoRequestStore) SetSignature(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, at time.Time) error {
+	path, err := signaturePathByRole(role)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": id, "account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			path:         sig,
+			"updated_at": at,
+		},
+	}
+	_, err = s.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (s *mongoRequestStore) ListAfter(ctx context.Context, afterID primitive.ObjectID, limit int) ([]RequestRecord, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := s.coll.Find(ctx, bson.M{"_id": bson.M{"$gt": afterID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]RequestRecord, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *mongoRequestStore) ReplaceInlineSignature(ctx context.Context, id primitive.ObjectID, role models.SignRole, signedAt time.Time, sig models.SignatureBlock) error {
+	path, err := signaturePathByRole(role)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{
+		"_id":                 id,
+		path + ".signed_at":   signedAt,
+		path + ".data_base64": bson.M{"$nin": bson.A{"", nil}},
+	}
+	return requireMatched(s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{path: sig}}))
+}
+
+func (s *mongoRequestStore) SetOfficialDecision(ctx context.Context, id primitive.ObjectID, accountID string, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecision, at time.Time) error {
+	sigPath, err := signaturePathByRole(role)
+	if err != nil {
+		return err
+	}
+	decisionPath, err := decisionPathByRole(role)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": id, "account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			sigPath:      sig,
+			decisionPath: decision,
+			"updated_at": at,
+		},
+	}
+	_, err = s.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (s *mongoRequestStore) SetRejection(ctx context.Context, id primitive.ObjectID, accountID string, rejection models.RequestRejection, at time.Time) error {
+	filter := bson.M{"_id": id, "account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			"rejection":  rejection,
+			"updated_at": at,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (s *mongoRequestStore) SetTrackingToken(ctx context.Context, id primitive.ObjectID, accountID string, version int64, hash string, at time.Time) error {
+	filter := bson.M{"_id": id, "account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			"tracking_token_version": version,
+			"tracking_token_hash":    hash,
+			"updated_at":             at,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// ─── Sign links ────────────────────────────────────────────────────────────────
+
+type mongoSignLinkStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoSignLinkStore) Insert(ctx context.Context, record *models.SignLink) error {
+	res, err := s.coll.InsertOne(ctx, record)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		record.ID = oid
+	}
+	return nil
+}
+
+func (s *mongoSignLinkStore) FindByTokenHash(ctx context.Context, hash string) (*models.SignLink, error) {
+	var record models.SignLink
+	if err := s.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoSignLinkStore) FindByID(ctx context.Context, id primitive.ObjectID) (*models.SignLink, error) {
+	var record models.SignLink
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoSignLinkStore) ListByRequestID(ctx context.Context, requestID primitive.ObjectID) ([]models.SignLink, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"request_id": requestID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []models.SignLink
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (s *mongoSignLinkStore) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"used_at": at, "updated_at": at}})
+	return err
+}
+
+func (s *mongoSignLinkStore) TouchSent(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_sent_at": at, "updated_at": at}})
+	return err
+}
+
+func (s *mongoSignLinkStore) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"revoked": true, "revoked_at": at, "updated_at": at}}))
+}
+
+func (s *mongoSignLinkStore) ListActive(ctx context.Context, now time.Time) ([]models.SignLink, error) {
+	cursor, err := s.coll.Find(ctx, bson.M{
+		"revoked":    false,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []models.SignLink
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// SignLinkRecipientCollation compares recipient emails case-insensitively. The
+// recipient_email index must be built with it for ListActiveByRecipient to use it.
+var SignLinkRecipientCollation = &options.Collation{Locale: "en", Strength: 2}
+
+func (s *mongoSignLinkStore) ListActiveByRecipient(ctx context.Context, emails []string, now time.Time) ([]models.SignLink, error) {
+	recipients := make(bson.A, 0, len(emails))
+	for _, email := range emails {
+		if email = strings.TrimSpace(email); email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	if len(recipients) == 0 {
+		return []models.SignLink{}, nil
+	}
+	cursor, err := s.coll.Find(ctx, bson.M{
+		"recipient_email": bson.M{"$in": recipients},
+		"revoked":         false,
+		"expires_at":      bson.M{"$gt": now},
+		"used_at":         bson.M{"$exists": false},
+	}, options.Find().SetCollation(SignLinkRecipientCollation))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	links := []models.SignLink{}
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (s *mongoSignLinkStore) ClaimReminder(ctx context.Context, id primitive.ObjectID, reminderCount int, tokenHash string, at time.Time) error {
+	filter := bson.M{"_id": id, "reminder_count": reminderCount}
+	if reminderCount == 0 {
+		// links issued before reminders existed have no reminder_count yet
+		filter["reminder_count"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	return requireMatched(s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"reminder_count": reminderCount + 1, "token_hash": tokenHash, "last_sent_at": at}}))
+}
+
+func (s *mongoSignLinkStore) MarkEscalated(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "escalated_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"escalated_at": at}},
+	))
+}
+
+// ─── Sign sessions ─────────────────────────────────────────────────────────────
+
+type mongoSignSessionStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoSignSessionStore) Insert(ctx context.Context, record *models.SignSession) error {
+	_, err := s.coll.InsertOne(ctx, record)
+	return err
+}
+
+func (s *mongoSignSessionStore) FindByID(ctx context.Context, id string) (*models.SignSession, error) {
+	var record models.SignSession
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoSignSessionStore) MarkExpired(ctx context.Context, id string) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": "expired"}})
+	return err
+}
+
+func (s *mongoSignSessionStore) MarkCompleted(ctx context.Context, id string, at time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": "completed", "completed_at": at}})
+	return err
+}
+
+// ─── Form links ────────────────────────────────────────────────────────────────
+
+type mongoFormLinkStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoFormLinkStore) Insert(ctx context.Context, record *models.FormLink) error {
+	res, err := s.coll.InsertOne(ctx, record)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		record.ID = oid
+	}
+	return nil
+}
+
+func (s *mongoFormLinkStore) FindByAccountID(ctx context.Context, accountID string) (*models.FormLink, error) {
+	var record models.FormLink
+	if err := s.coll.FindOne(ctx, bson.M{"account_id": accountID}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoFormLinkStore) FindByTokenHash(ctx context.Context, hash string) (*models.FormLink, error) {
+	var record models.FormLink
+	if err := s.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoFormLinkStore) SetToken(ctx context.Context, id primitive.ObjectID, version int64, hash string, at time.Time) error {
+	_, err := s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"token_version": version,
+			"token_hash":    hash,
+			"revoked":       false,
+			"updated_at":    at,
+		}},
+	)
+	return err
+}
+
+func (s *mongoFormLinkStore) RevokeByAccountID(ctx context.Context, accountID string, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{"account_id": accountID},
+		bson.M{"$set": bson.M{"revoked": true, "updated_at": at}},
+	))
+}
+
+func (s *mongoFormLinkStore) TouchUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_used_at": at, "updated_at": at}},
+	)
+	return err
+}
+
+// ─── Audit logs ────────────────────────────────────────────────────────────────
+
+type mongoAuditStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoAuditStore) Insert(ctx context.Context, entry models.AuditLog) error {
+	_, err := s.coll.InsertOne(ctx, entry)
+	return err
+}
+
+func (s *mongoAuditStore) ListByRequestID(ctx context.Context, requestID primitive.ObjectID, after *PageCursor, limit int) ([]models.AuditLog, error) {
+	filter := bson.M{"request_id": requestID}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetMatch("timestamp", after, true)}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := make([]models.AuditLog, 0)
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (s *mongoAuditStore) FindByHash(ctx context.Context, hash string) ([]models.AuditLog, error) {
+	cursor, err := s.coll.Find(ctx, bson.M{"document_hash": hash})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []models.AuditLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// ─── Officials ─────────────────────────────────────────────────────────────────
+
+type mongoOfficialStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoOfficialStore) FindByAccountID(ctx context.Context, accountID string) (*models.Official, error) {
+	var doc models.Official
+	if err := s.coll.FindOne(ctx, bson.M{"account_id": accountID}).Decode(&doc); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &doc, nil
+}
+
+func (s *mongoOfficialStore) Upsert(ctx context.Context, official models.Official) error {
+	filter := bson.M{"account_id": official.AccountID}
+	update := bson.M{
+		"$set": bson.M{
+			"account_id":      official.AccountID,
+			"registrar_name":  official.RegistrarName,
+			"director_name":   official.DirectorName,
+			"registrar_email": official.RegistrarEmail,
+			"director_email":  official.DirectorEmail,
+			"school_name":     official.SchoolName,
+			"school_address":  official.SchoolAddress,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *mongoOfficialStore) SetEmailBranding(ctx context.Context, accountID string, branding models.EmailBranding) error {
+	filter := bson.M{"account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			"account_id":     accountID,
+			"email_branding": branding,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *mongoOfficialStore) SetSigningSettings(ctx context.Context, accountID string, settings models.SigningSettings) error {
+	filter := bson.M{"account_id": accountID}
+	update := bson.M{
+		"$set": bson.M{
+			"account_id":       accountID,
+			"signing_settings": settings,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ─── Approval chains ───────────────────────────────────────────────────────────
+
+type mongoApprovalChainStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoApprovalChainStore) FindByAccountID(ctx context.Context, accountID string) (*models.ApprovalChain, error) {
+	var doc models.ApprovalChain
+	if err := s.coll.FindOne(ctx, bson.M{"account_id": accountID}).Decode(&doc); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &doc, nil
+}
+
+func (s *mongoApprovalChainStore) Upsert(ctx context.Context, chain models.ApprovalChain) error {
+	filter := bson.M{"account_id": chain.AccountID}
+	update := bson.M{
+		"$set": bson.M{
+			"account_id": chain.AccountID,
+			"steps":      chain.Steps,
+			"updated_at": chain.UpdatedAt,
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ─── Logout handles ────────────────────────────────────────────────────────────
+
+type mongoLogoutHandleStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoLogoutHandleStore) Insert(ctx context.Context, record *models.LogoutHandle) error {
+	res, err := s.coll.InsertOne(ctx, record)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		record.ID = oid
+	}
+	return nil
+}
+
+func (s *mongoLogoutHandleStore) FindByID(ctx context.Context, id primitive.ObjectID) (*models.LogoutHandle, error) {
+	var record models.LogoutHandle
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoLogoutHandleStore) RevokeByID(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"revoked_at": at, "updated_at": at}},
+	))
+}
+
+func (s *mongoLogoutHandleStore) RevokeBySID(ctx context.Context, sid string, at time.Time) error {
+	return requireMatched(s.coll.UpdateMany(
+		ctx,
+		bson.M{"sid": sid},
+		bson.M{"$set": bson.M{"revoked_at": at, "updated_at": at}},
+	))
+}
+
+func (s *mongoLogoutHandleStore) UpdateSessionVersionBySID(ctx context.Context, sid string, version int64, at time.Time) error {
+	return requireMatched(s.coll.UpdateMany(
+		ctx,
+		bson.M{
+			"sid": sid,
+			"$or": []bson.M{
+				{"session_version": bson.M{"$lt": version}},
+				{"session_version": bson.M{"$exists": false}},
+			},
+		},
+		bson.M{"$set": bson.M{"session_version": version, "updated_at": at}},
+	))
+}
+
+// ─── Email outbox ──────────────────────────────────────────────────────────────
+
+type mongoEmailOutboxStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoEmailOutboxStore) Insert(ctx context.Context, entry *models.EmailOutboxEntry) error {
+	res, err := s.coll.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		entry.ID = oid
+	}
+	return nil
+}
+
+func (s *mongoEmailOutboxStore) FindByID(ctx context.Context, id primitive.ObjectID, accountID string) (*models.EmailOutboxEntry, error) {
+	var entry models.EmailOutboxEntry
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id, "account_id": accountID}).Decode(&entry); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &entry, nil
+}
+
+func (s *mongoEmailOutboxStore) ListByRequestID(ctx context.Context, requestID string, accountID string) ([]models.EmailOutboxEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"request_id": requestID, "account_id": accountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.EmailOutboxEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *mongoEmailOutboxStore) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.EmailOutboxEntry, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{
+				"status":          bson.M{"$in": []models.EmailOutboxStatus{models.EmailOutboxPending, models.EmailOutboxFailed}},
+				"next_attempt_at": bson.M{"$lte": now},
+			},
+			{
+				"status":       models.EmailOutboxSending,
+				"locked_until": bson.M{"$lt": now},
+			},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       models.EmailOutboxSending,
+		"locked_until": now.Add(lease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var entry models.EmailOutboxEntry
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&entry); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &entry, nil
+}
+
+func (s *mongoEmailOutboxStore) MarkSent(ctx context.Context, id primitive.ObjectID, attempts int, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"status": models.EmailOutboxSent, "attempts": attempts, "sent_at": at, "updated_at": at},
+			"$unset": bson.M{"locked_until": "", "last_error": ""},
+		},
+	))
+}
+
+func (s *mongoEmailOutboxStore) MarkFailed(ctx context.Context, id primitive.ObjectID, status models.EmailOutboxStatus, attempts int, lastError string, nextAttemptAt time.Time, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"status":          status,
+				"attempts":        attempts,
+				"last_error":      lastError,
+				"next_attempt_at": nextAttemptAt,
+				"updated_at":      at,
+			},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	))
+}
+
+func (s *mongoEmailOutboxStore) Requeue(ctx context.Context, id primitive.ObjectID, accountID string, at time.Time) error {
+	return requireMatched(s.coll.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":        id,
+			"account_id": accountID,
+			"status":     bson.M{"$in": []models.EmailOutboxStatus{models.EmailOutboxFailed, models.EmailOutboxDead}},
+		},
+		bson.M{"$set": bson.M{
+			"status":          models.EmailOutboxPending,
+			"attempts":        0,
+			"next_attempt_at": at,
+			"updated_at":      at,
+		}},
+	))
+}
+
+// ─── Saved signatures ──────────────────────────────────────────────────────────
+
+type mongoSavedSignatureStore struct {
+	coll *mongo.Collection
+}
+
+func (s *mongoSavedSignatureStore) Upsert(ctx context.Context, record *models.SavedSignature) error {
+	filter := bson.M{"account_id": record.AccountID, "email": record.Email, "role": record.Role}
+	update := bson.M{
+		"$set": bson.M{
+			"method":     record.Method,
+			"ciphertext": record.Ciphertext,
+			"nonce":      record.Nonce,
+			"updated_at": record.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": record.CreatedAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.SavedSignature
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return err
+	}
+	record.ID = saved.ID
+	record.CreatedAt = saved.CreatedAt
+	return nil
+}
+
+func (s *mongoSavedSignatureStore) Find(ctx context.Context, accountID, email string, role models.SignRole) (*models.SavedSignature, error) {
+	var record models.SavedSignature
+	if err := s.coll.FindOne(ctx, bson.M{"account_id": accountID, "email": email, "role": role}).Decode(&record); err != nil {
+		return nil, mapMongoNotFound(err)
+	}
+	return &record, nil
+}
+
+func (s *mongoSavedSignatureStore) ListByAccountID(ctx context.Context, accountID string) ([]models.SavedSignature, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "email", Value: 1}, {Key: "role", Value: 1}}).
+		SetProjection(bson.M{"ciphertext": 0, "nonce": 0})
+	cursor, err := s.coll.Find(ctx, bson.M{"account_id": accountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]models.SavedSignature, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *mongoSavedSignatureStore) Delete(ctx context.Context, id primitive.ObjectID, accountID string) error {
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": id, "account_id": accountID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
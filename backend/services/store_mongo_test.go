@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// insertedDocument returns the first document of the insert command mt just sent.
+func insertedDocument(mt *mtest.T) bson.Raw {
+	mt.Helper()
+	started := mt.GetStartedEvent()
+	if started == nil || started.CommandName != "insert" {
+		mt.Fatalf("expected an insert command, got %v", started)
+	}
+	docs, err := started.Command.Lookup("documents").Array().Values()
+	if err != nil || len(docs) != 1 {
+		mt.Fatalf("expected one inserted document, got %d (%v)", len(docs), err)
+	}
+	return docs[0].Document()
+}
+
+func TestMongoRequestStoreInsertRoundTripsRecord(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("insert and find", func(mt *mtest.T) {
+		ctx := context.Background()
+		store := &mongoRequestStore{coll: mt.Coll}
+		steps := []models.ApprovalStep{
+			{Role: "homeroom_teacher", Label: "ครูประจำชั้น", SignerEmail: "teacher@example.com"},
+			{Role: models.SignRoleDirector, Label: "ผู้อำนวยการ"},
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		id, err := store.Insert(ctx, &RequestRecord{
+			AccountID:      "acct-1",
+			Name:           "สมชาย ดีมาก",
+			Status:         models.RequestStatusSubmitted,
+			RequesterEmail: "parent@example.com",
+			ApprovalSteps:  steps,
+		})
+		if err != nil {
+			mt.Fatalf("Insert returned error: %v", err)
+		}
+		doc := insertedDocument(mt)
+
+		// serve the stored document back to FindByID
+		var stored bson.D
+		if err := bson.Unmarshal(doc, &stored); err != nil {
+			mt.Fatalf("Unmarshal returned error: %v", err)
+		}
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, stored))
+		record, err := store.FindByID(ctx, id, "acct-1")
+		if err != nil {
+			mt.Fatalf("FindByID returned error: %v", err)
+		}
+		if len(record.ApprovalSteps) != 2 || record.ApprovalSteps[0].Role != "homeroom_teacher" || record.ApprovalSteps[0].SignerEmail != "teacher@example.com" {
+			mt.Fatalf("expected the approval chain snapshot to be stored, got %#v", record.ApprovalSteps)
+		}
+		if record.RequesterEmail != "parent@example.com" {
+			mt.Fatalf("expected the requester email to be stored, got %q", record.RequesterEmail)
+		}
+	})
+}
+
+func TestMongoRequestStoreListByCursorProjectsCursorFields(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("fields without created_at", func(mt *mtest.T) {
+		store := &mongoRequestStore{coll: mt.Coll}
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+		if _, err := store.ListByCursor(context.Background(), RequestListQuery{AccountID: "acct-1", Limit: 2, Fields: []string{"id", "name"}}, nil); err != nil {
+			mt.Fatalf("ListByCursor returned error: %v", err)
+		}
+
+		started := mt.GetStartedEvent()
+		if started == nil || started.CommandName != "aggregate" {
+			mt.Fatalf("expected an aggregate command, got %v", started)
+		}
+		stages, err := started.Command.Lookup("pipeline").Array().Values()
+		if err != nil {
+			mt.Fatalf("read pipeline: %v", err)
+		}
+		for _, stage := range stages {
+			project, ok := stage.Document().Lookup("$project").DocumentOK()
+			if !ok || project.Lookup("name").IsZero() {
+				continue
+			}
+			// the next cursor is built from these, so they must survive the projection
+			if project.Lookup("created_at").IsZero() || project.Lookup("_id").IsZero() {
+				mt.Fatalf("expected created_at and _id in the projection, got %v", project)
+			}
+			return
+		}
+		mt.Fatal("expected a field projection stage")
+	})
+}
+
+func TestRequestListProjectionLoadsStatusSources(t *testing.T) {
+	stages := requestListProjection([]string{"id", "status"})
+	if len(stages) != 3 {
+		t.Fatalf("expected a field projection and two image stages, got %v", stages)
+	}
+	projection := stages[0][0].Value.(bson.D).Map()
+	// legacy statuses are mapped from the signatures, decisions and chain
+	for _, key := range []string{"_id", "status", "signatures", "decisions", "approval_steps"} {
+		if projection[key] != 1 {
+			t.Fatalf("expected %s in the projection, got %v", key, projection)
+		}
+	}
+}
+
+func TestBuildRequestMatchExpandsLegacyStatusesAndSearchTerms(t *testing.T) {
+	filter := buildRequestMatch("acct-1", RequestFilter{Status: "approved", Search: "ใจ 650"})
+	status, _ := filter["status"].(bson.M)
+	values, _ := status["$in"].(bson.A)
+	if len(values) != 3 || values[0] != "approved" || values[1] != "completed" || values[2] != "pending" {
+		t.Fatalf("expected approved to match its legacy values, got %#v", filter["status"])
+	}
+	if _, ok := filter["$text"]; ok {
+		t.Fatal("expected search not to rely on a text index")
+	}
+	terms, _ := filter["$and"].(bson.A)
+	if len(terms) != 2 {
+		t.Fatalf("expected one clause per search term, got %#v", filter["$and"])
+	}
+
+	filter = buildRequestMatch("acct-1", RequestFilter{Status: "submitted"})
+	values, _ = filter["status"].(bson.M)["$in"].(bson.A)
+	if len(values) != 3 || values[1] != "" || values[2] != nil {
+		t.Fatalf("expected submitted to match records without a status, got %#v", filter["status"])
+	}
+}
+
+func TestRequestListProjectionDropsOfficialImages(t *testing.T) {
+	for _, fields := range [][]string{nil, {"id", "signatures"}} {
+		stages := requestListProjection(fields)
+		last := stages[len(stages)-1]
+		if last[0].Key != "$addFields" {
+			t.Fatalf("expected the listing to end with an $addFields stage, got %v", last)
+		}
+		expr, err := bson.MarshalExtJSON(last, false, false)
+		if err != nil {
+			t.Fatalf("MarshalExtJSON returned error: %v", err)
+		}
+		// custom steps are keyed by role, so the images are filtered by expression
+		for _, want := range []string{`"$signatures.officials"`, `"data_base64"`, `"$$REMOVE"`} {
+			if !strings.Contains(string(expr), want) {
+				t.Fatalf("expected %s in %s", want, expr)
+			}
+		}
+	}
+}
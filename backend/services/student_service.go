@@ -4,43 +4,42 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"backend/models"
 	"crypto/sha256"
 	"encoding/hex"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // SaveStudent inserts a student document and returns the inserted ID.
-func SaveStudent(ctx context.Context, coll *mongo.Collection, payload models.StudentData) (interface{}, error) {
-	res, err := coll.InsertOne(ctx, bson.M{
-		"account_id":    payload.AccountID,
-		"prefix":        payload.Prefix,
-		"document_type": payload.DocumentType,
-		"name":          payload.Name,
-		"id_card":       payload.IDCard,
-		"student_id":    payload.StudentID,
-		"class":         payload.Class,
-		"room":          payload.Room,
-		"academic_year": payload.AcademicYear,
-		"date_of_birth": payload.DateOfBirth,
-		"father_name":   payload.FatherName,
-		"mother_name":   payload.MotherName,
-		"purpose":       payload.Purpose,
-		"status":        "pending", // Default status is pending
-		"signatures":    payload.Signatures,
-		"decisions":     payload.Decisions,
-		"created_at":    time.Now(),
-		"updated_at":    time.Now(),
+// steps is the approval chain to capture on the request; nil means the default chain.
+func SaveStudent(ctx context.Context, store RequestStore, payload models.StudentData, steps []models.ApprovalStep) (primitive.ObjectID, error) {
+	now := time.Now()
+	return store.Insert(ctx, &RequestRecord{
+		AccountID:      payload.AccountID,
+		Prefix:         payload.Prefix,
+		DocumentType:   payload.DocumentType,
+		Name:           payload.Name,
+		IDCard:         payload.IDCard,
+		StudentID:      payload.StudentID,
+		Class:          payload.Class,
+		Room:           payload.Room,
+		AcademicYear:   payload.AcademicYear,
+		DateOfBirth:    payload.DateOfBirth,
+		FatherName:     payload.FatherName,
+		MotherName:     payload.MotherName,
+		Purpose:        payload.Purpose,
+		RequesterEmail: payload.RequesterEmail,
+		Status:         models.RequestStatusSubmitted,
+		Signatures:     payload.Signatures,
+		Decisions:      payload.Decisions,
+		ApprovalSteps:  steps,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return res.InsertedID, nil
 }
 
 type YearItem struct {
@@ -61,200 +60,124 @@ type StatsResult struct {
 }
 
 // GetStats computes total, yearly and monthly counts from the collection.
-func GetStats(ctx context.Context, coll *mongo.Collection, accountID string) (StatsResult, error) {
-	var out StatsResult
-
-	filter := bson.M{"account_id": accountID}
-	total, err := coll.CountDocuments(ctx, filter)
-	if err != nil {
-		return out, err
-	}
-	out.Total = total
-
-	// aggregation by year
-	type yearAgg struct {
-		ID    int32 `bson:"_id"`
-		Count int32 `bson:"count"`
-	}
-	yearPipe := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: filter}},
-		bson.D{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{{Key: "$year", Value: "$created_at"}}},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
-	}
-	yearCur, err := coll.Aggregate(ctx, yearPipe)
-	if err != nil {
-		return out, err
-	}
-	var yearRes []yearAgg
-	if err := yearCur.All(ctx, &yearRes); err != nil {
-		return out, err
-	}
-	out.ByYear = make([]YearItem, 0, len(yearRes))
-	for _, it := range yearRes {
-		out.ByYear = append(out.ByYear, YearItem{Year: it.ID, Count: it.Count})
-	}
-
-	// aggregation by month (year + month)
-	type monthAgg struct {
-		ID struct {
-			Y int32 `bson:"y"`
-			M int32 `bson:"m"`
-		} `bson:"_id"`
-		Count int32 `bson:"count"`
-	}
-	monthPipe := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: filter}},
-		bson.D{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "y", Value: bson.D{{Key: "$year", Value: "$created_at"}}},
-				{Key: "m", Value: bson.D{{Key: "$month", Value: "$created_at"}}},
-			}},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id.y", Value: 1}, {Key: "_id.m", Value: 1}}}},
-	}
-	monthCur, err := coll.Aggregate(ctx, monthPipe)
-	if err != nil {
-		return out, err
-	}
-	var monthRes []monthAgg
-	if err := monthCur.All(ctx, &monthRes); err != nil {
-		return out, err
-	}
-	out.ByMonth = make([]MonthItem, 0, len(monthRes))
-	for _, it := range monthRes {
-		out.ByMonth = append(out.ByMonth, MonthItem{Year: it.ID.Y, Month: it.ID.M, Count: it.Count})
-	}
-
-	return out, nil
+func GetStats(ctx context.Context, store RequestStore, accountID string) (StatsResult, error) {
+	return store.Stats(ctx, accountID)
 }
 
 // RequestRecord represents a student request/application document with metadata
 type RequestRecord struct {
-	ID           interface{}              `json:"id" bson:"_id"`
-	AccountID    string                   `json:"account_id" bson:"account_id"`
-	Prefix       string                   `json:"prefix" bson:"prefix"`
-	Name         string                   `json:"name" bson:"name"`
-	DocumentType string                   `json:"document_type" bson:"document_type"`
-	IDCard       string                   `json:"id_card" bson:"id_card"`
-	StudentID    string                   `json:"student_id" bson:"student_id"`
-	DateOfBirth  string                   `json:"date_of_birth" bson:"date_of_birth"`
-	Class        string                   `json:"class" bson:"class"`
-	Room         string                   `json:"room" bson:"room"`
-	AcademicYear string                   `json:"academic_year" bson:"academic_year"`
-	FatherName   string                   `json:"father_name" bson:"father_name"`
-	MotherName   string                   `json:"mother_name" bson:"mother_name"`
-	Purpose      string                   `json:"purpose" bson:"purpose"`
-	Status       string                   `json:"status" bson:"status"` // pending, completed, cancelled
-	Signatures   models.RequestSignatures `json:"signatures" bson:"signatures"`
-	Decisions    models.RequestDecisions  `json:"decisions,omitempty" bson:"decisions,omitempty"`
-	CreatedAt    time.Time                `json:"created_at" bson:"created_at"`
+	ID             interface{}              `json:"id" bson:"_id"`
+	AccountID      string                   `json:"account_id" bson:"account_id"`
+	Prefix         string                   `json:"prefix" bson:"prefix"`
+	Name           string                   `json:"name" bson:"name"`
+	DocumentType   string                   `json:"document_type" bson:"document_type"`
+	IDCard         string                   `json:"id_card" bson:"id_card"`
+	StudentID      string                   `json:"student_id" bson:"student_id"`
+	DateOfBirth    string                   `json:"date_of_birth" bson:"date_of_birth"`
+	Class          string                   `json:"class" bson:"class"`
+	Room           string                   `json:"room" bson:"room"`
+	AcademicYear   string                   `json:"academic_year" bson:"academic_year"`
+	FatherName     string                   `json:"father_name" bson:"father_name"`
+	MotherName     string                   `json:"mother_name" bson:"mother_name"`
+	Purpose        string                   `json:"purpose" bson:"purpose"`
+	RequesterEmail string                   `json:"requester_email,omitempty" bson:"requester_email,omitempty"`
+	Status         models.RequestStatus     `json:"status" bson:"status"`
+	Signatures     models.RequestSignatures `json:"signatures" bson:"signatures"`
+	Decisions      models.RequestDecisions  `json:"decisions,omitempty" bson:"decisions,omitempty"`
+	Rejection      *models.RequestRejection `json:"rejection,omitempty" bson:"rejection,omitempty"`
+	// ApprovalSteps is the account's approval chain captured at submission so later
+	// chain edits do not affect requests in flight. Empty means DefaultApprovalSteps.
+	ApprovalSteps []models.ApprovalStep `json:"approval_steps,omitempty" bson:"approval_steps,omitempty"`
+	// TrackingTokenVersion and TrackingTokenHash identify the requester's tracking token,
+	// which is derived from the request ID and version like form link tokens.
+	TrackingTokenVersion int64     `json:"-" bson:"tracking_token_version,omitempty"`
+	TrackingTokenHash    string    `json:"-" bson:"tracking_token_hash,omitempty"`
+	CreatedAt            time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at" bson:"updated_at"`
 }
 
-// GetRequests retrieves all student requests with pagination
-func GetRequests(ctx context.Context, coll *mongo.Collection, page, limit int, accountID string) ([]RequestRecord, int64, error) {
-	// Calculate skip value for pagination
-	skip := (page - 1) * limit
-	filter := bson.M{"account_id": accountID}
+// RequestSortFields lists the columns GET /api/requests may be sorted by.
+var RequestSortFields = []string{"created_at", "name", "id_card", "student_id", "document_type", "status", "academic_year"}
 
-	// Get total count
-	total, err := coll.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
+// IsValidRequestSortField reports whether field can be used as a request sort key.
+func IsValidRequestSortField(field string) bool {
+	for _, candidate := range RequestSortFields {
+		if candidate == field {
+			return true
+		}
 	}
+	return false
+}
 
-	// Get paginated results, sorted by created_at descending (newest first)
-	pipeline := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: filter}},
-		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}},
-		bson.D{{Key: "$skip", Value: skip}},
-		bson.D{{Key: "$limit", Value: limit}},
+// GetRequests retrieves student requests matching the query with pagination
+func GetRequests(ctx context.Context, store RequestStore, query RequestListQuery) ([]RequestRecord, int64, error) {
+	if query.SortField == "" {
+		query.SortField = "created_at"
 	}
-
-	cursor, err := coll.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, 0, err
+	if !IsValidRequestSortField(query.SortField) {
+		return nil, 0, fmt.Errorf("unsupported sort field: %s", query.SortField)
 	}
-	defer cursor.Close(ctx)
 
-	var requests []RequestRecord
-	if err := cursor.All(ctx, &requests); err != nil {
+	requests, total, err := store.List(ctx, query)
+	if err != nil {
 		return nil, 0, err
 	}
 	// Ensure we return an empty slice instead of nil so JSON encodes as [] not null
 	if requests == nil {
 		requests = make([]RequestRecord, 0)
 	}
+	// listings carry blob references only; images not yet migrated are dropped too
+	StripSignatureImages(requests)
 
 	return requests, total, nil
 }
 
-// GetRequestByID retrieves a single request by its ID
-func GetRequestByID(ctx context.Context, coll *mongo.Collection, id interface{}, accountID string) (*RequestRecord, error) {
-	var request RequestRecord
-
-	filter := bson.M{"_id": id, "account_id": accountID}
-	err := coll.FindOne(ctx, filter).Decode(&request)
-	if err != nil {
-		return nil, err
+// GetRequestsByCursor lists requests with keyset pagination on (created_at, _id),
+// starting after cursor (empty for the first page). It returns the cursor of
+// the next page, or "" when this page is the last. Only created_at ordering is
+// supported.
+func GetRequestsByCursor(ctx context.Context, store RequestStore, query RequestListQuery, cursor string) ([]RequestRecord, string, error) {
+	if query.SortField != "" && query.SortField != "created_at" {
+		return nil, "", ErrCursorSort
 	}
-
-	return &request, nil
-}
-
-// UpdateRequestStatus updates the status of a request
-func UpdateRequestStatus(ctx context.Context, coll *mongo.Collection, id interface{}, status string, accountID string) error {
-	filter := bson.M{"_id": id, "account_id": accountID}
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
-	}
-
-	_, err := coll.UpdateOne(ctx, filter, update)
-	return err
-}
-
-// UpdateRequestStatusWithAudit updates status and records an audit log for administrative actions.
-func UpdateRequestStatusWithAudit(ctx context.Context, mongoColl *mongo.Collection, auditColl *mongo.Collection, id interface{}, status string, ipAddress, userAgent string, accountID string) error {
-	// Fetch current state to compute hash
-	record, err := GetRequestByID(ctx, mongoColl, id, accountID)
+	after, err := DecodePageCursor(cursor)
 	if err != nil {
-		return err
-	}
-	hash := ComputeRequestHash(record)
-
-	// Perform the update
-	if err := UpdateRequestStatus(ctx, mongoColl, id, status, accountID); err != nil {
-		return err
+		return nil, "", err
 	}
 
-	// Record Audit Log
-	objID, decodeErr := ToObjectID(record.ID)
-	if decodeErr == nil {
-		audit := models.AuditLog{
-			RequestID:    objID,
-			Role:         models.SignRoleAdmin,
-			Action:       status, // "completed", "cancelled", etc.
-			DocumentHash: hash,
-			IPAddress:    ipAddress,
-			UserAgent:    userAgent,
-			Timestamp:    time.Now().UTC(),
-		}
-		if auditErr := RecordAuditLog(ctx, auditColl, audit); auditErr != nil {
-			log.Printf("[AUDIT] UpdateRequestStatusWithAudit Failed: %v", auditErr)
+	// read one extra record to learn whether another page follows
+	limit := query.Limit
+	query.Limit = limit + 1
+	requests, err := store.ListByCursor(ctx, query, after)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(requests) > limit {
+		requests = requests[:limit]
+		last := requests[limit-1]
+		id, err := ToObjectID(last.ID)
+		if err != nil {
+			return nil, "", err
 		}
-	} else {
-		log.Printf("[AUDIT] ID Conversion Error: %v", decodeErr)
+		next = PageCursor{At: last.CreatedAt, ID: id}.Encode()
 	}
+	StripSignatureImages(requests)
+	return requests, next, nil
+}
 
-	return nil
+// GetRequestByID retrieves a single request by its ID
+func GetRequestByID(ctx context.Context, store RequestStore, id primitive.ObjectID, accountID string) (*RequestRecord, error) {
+	return store.FindByID(ctx, id, accountID)
 }
 
+// GetRequestByIDUnscoped retrieves a request without account scoping for public token flows.
+func GetRequestByIDUnscoped(ctx context.Context, store RequestStore, id primitive.ObjectID) (*RequestRecord, error) {
+	return store.FindByIDUnscoped(ctx, id)
+}
+
+// signaturePathByRole maps a role to its field path. Registrar and director keep
+// their original fields; custom approval roles live under signatures.officials.
 func signaturePathByRole(role models.SignRole) (string, error) {
 	switch role {
 	case models.SignRoleStudent:
@@ -264,7 +187,10 @@ func signaturePathByRole(role models.SignRole) (string, error) {
 	case models.SignRoleDirector:
 		return "signatures.director", nil
 	default:
-		return "", fmt.Errorf("unsupported sign role: %s", role)
+		if !models.IsValidApprovalRole(role) {
+			return "", fmt.Errorf("unsupported sign role: %s", role)
+		}
+		return "signatures.officials." + string(role), nil
 	}
 }
 
@@ -275,7 +201,10 @@ func decisionPathByRole(role models.SignRole) (string, error) {
 	case models.SignRoleDirector:
 		return "decisions.director", nil
 	default:
-		return "", fmt.Errorf("unsupported decision role: %s", role)
+		if !models.IsValidApprovalRole(role) {
+			return "", fmt.Errorf("unsupported decision role: %s", role)
+		}
+		return "decisions.officials." + string(role), nil
 	}
 }
 
@@ -317,29 +246,20 @@ func ToObjectID(id interface{}) (primitive.ObjectID, error) {
 }
 
 // UpsertSignature stores one signature block for a given request and role, and records an audit log.
-func UpsertSignature(ctx context.Context, mongoColl *mongo.Collection, auditColl *mongo.Collection, id interface{}, role models.SignRole, sig models.SignatureBlock, ipAddress, userAgent string, accountID string) error {
-	path, err := signaturePathByRole(role)
-	if err != nil {
+func UpsertSignature(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, role models.SignRole, sig models.SignatureBlock, ipAddress, userAgent string, accountID string) error {
+	if _, err := signaturePathByRole(role); err != nil {
 		return err
 	}
 
 	// Fetch current state to compute hash
-	record, err := GetRequestByID(ctx, mongoColl, id, accountID)
+	record, err := GetRequestByID(ctx, requests, id, accountID)
 	if err != nil {
 		return err
 	}
 	hash := ComputeRequestHash(record)
 	sig.DocumentHash = hash
 
-	filter := bson.M{"_id": id, "account_id": accountID}
-	update := bson.M{
-		"$set": bson.M{
-			path:         sig,
-			"updated_at": time.Now(),
-		},
-	}
-
-	if _, err = mongoColl.UpdateOne(ctx, filter, update); err != nil {
+	if err := requests.SetSignature(ctx, id, accountID, role, sig, time.Now()); err != nil {
 		return err
 	}
 
@@ -355,7 +275,7 @@ func UpsertSignature(ctx context.Context, mongoColl *mongo.Collection, auditColl
 			UserAgent:    userAgent,
 			Timestamp:    time.Now().UTC(),
 		}
-		if auditErr := RecordAuditLog(ctx, auditColl, audit); auditErr != nil {
+		if auditErr := RecordAuditLog(ctx, audits, audit); auditErr != nil {
 			log.Printf("[AUDIT] UpsertSignature Failed: %v", auditErr)
 		}
 	} else {
@@ -366,45 +286,43 @@ func UpsertSignature(ctx context.Context, mongoColl *mongo.Collection, auditColl
 }
 
 // UpsertOfficialDecisionAndSignature stores one official signature and decision for a given role, and records an audit log.
-func UpsertOfficialDecisionAndSignature(ctx context.Context, mongoColl *mongo.Collection, auditColl *mongo.Collection, id interface{}, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecisionValue, ipAddress, userAgent string, accountID string) error {
-	if !models.IsValidOfficialDecision(decision) {
-		return fmt.Errorf("invalid official decision: %s", decision)
+// DecidedAt and DocumentHash of decision are filled in here.
+func UpsertOfficialDecisionAndSignature(ctx context.Context, requests RequestStore, audits AuditStore, id primitive.ObjectID, role models.SignRole, sig models.SignatureBlock, decision models.OfficialDecision, ipAddress, userAgent string, accountID string) error {
+	if !models.IsValidOfficialDecision(decision.Decision) {
+		return fmt.Errorf("invalid official decision: %s", decision.Decision)
+	}
+	decision.Reason = strings.TrimSpace(decision.Reason)
+	decision.Comment = strings.TrimSpace(decision.Comment)
+	if decision.Decision == models.OfficialDecisionReject && decision.Reason == "" {
+		return ErrRejectionReasonRequired
+	}
+	if decision.Decision == models.OfficialDecisionApprove {
+		decision.Reason = ""
 	}
 
-	sigPath, err := signaturePathByRole(role)
-	if err != nil {
+	if _, err := signaturePathByRole(role); err != nil {
 		return err
 	}
-	decisionPath, err := decisionPathByRole(role)
-	if err != nil {
+	if _, err := decisionPathByRole(role); err != nil {
 		return err
 	}
 
 	// Fetch current state to compute hash
-	record, err := GetRequestByID(ctx, mongoColl, id, accountID)
+	record, err := GetRequestByID(ctx, requests, id, accountID)
 	if err != nil {
 		return err
 	}
+	if err := ensureApprovalStepOpen(record, role); err != nil {
+		return err
+	}
 	hash := ComputeRequestHash(record)
 	sig.DocumentHash = hash
 
 	now := time.Now()
-	decisionRecord := models.OfficialDecision{
-		Decision:     decision,
-		DecidedAt:    now,
-		DocumentHash: hash,
-	}
-
-	filter := bson.M{"_id": id, "account_id": accountID}
-	update := bson.M{
-		"$set": bson.M{
-			sigPath:      sig,
-			decisionPath: decisionRecord,
-			"updated_at": now,
-		},
-	}
+	decision.DecidedAt = now
+	decision.DocumentHash = hash
 
-	if _, err = mongoColl.UpdateOne(ctx, filter, update); err != nil {
+	if err := requests.SetOfficialDecision(ctx, id, accountID, role, sig, decision, now); err != nil {
 		return err
 	}
 
@@ -412,15 +330,16 @@ func UpsertOfficialDecisionAndSignature(ctx context.Context, mongoColl *mongo.Co
 	objID, decodeErr := ToObjectID(record.ID)
 	if decodeErr == nil {
 		audit := models.AuditLog{
-			RequestID:    objID,
-			Role:         role,
-			Action:       string(decision),
-			DocumentHash: hash,
-			IPAddress:    ipAddress,
-			UserAgent:    userAgent,
-			Timestamp:    time.Now().UTC(),
+			RequestID:        objID,
+			Role:             role,
+			Action:           string(decision.Decision),
+			DocumentHash:     hash,
+			IPAddress:        ipAddress,
+			UserAgent:        userAgent,
+			Timestamp:        time.Now().UTC(),
+			SavedSignatureID: sig.SavedSignatureID,
 		}
-		if auditErr := RecordAuditLog(ctx, auditColl, audit); auditErr != nil {
+		if auditErr := RecordAuditLog(ctx, audits, audit); auditErr != nil {
 			log.Printf("[AUDIT] UpsertOfficialDecisionAndSignature Failed: %v", auditErr)
 		}
 	} else {
@@ -429,42 +348,3 @@ func UpsertOfficialDecisionAndSignature(ctx context.Context, mongoColl *mongo.Co
 
 	return nil
 }
-
-func hasDecision(decision *models.OfficialDecision, expected models.OfficialDecisionValue) bool {
-	return decision != nil && decision.Decision == expected
-}
-
-// ResolveStatusFromDecisions returns the request status derived from official decisions.
-func ResolveStatusFromDecisions(currentStatus string, decisions models.RequestDecisions) string {
-	if currentStatus == "cancelled" {
-		return currentStatus
-	}
-
-	if hasDecision(decisions.Registrar, models.OfficialDecisionReject) || hasDecision(decisions.Director, models.OfficialDecisionReject) {
-		return "pending"
-	}
-
-	if hasDecision(decisions.Registrar, models.OfficialDecisionApprove) && hasDecision(decisions.Director, models.OfficialDecisionApprove) {
-		return "completed"
-	}
-
-	return "pending"
-}
-
-// RecomputeStatusFromOfficialDecisions recalculates and persists status using decision fields.
-func RecomputeStatusFromOfficialDecisions(ctx context.Context, coll *mongo.Collection, id interface{}, accountID string) (string, error) {
-	record, err := GetRequestByID(ctx, coll, id, accountID)
-	if err != nil {
-		return "", err
-	}
-
-	nextStatus := ResolveStatusFromDecisions(record.Status, record.Decisions)
-	if nextStatus == record.Status {
-		return nextStatus, nil
-	}
-
-	if err := UpdateRequestStatus(ctx, coll, id, nextStatus, accountID); err != nil {
-		return "", err
-	}
-	return nextStatus, nil
-}
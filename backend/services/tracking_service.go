@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrTrackingTokenNotFound = errors.New("tracking token not found")
+
+// TrackingStep reports the progress of one approval step to the requester.
+type TrackingStep struct {
+	Role      models.SignRole              `json:"role"`
+	Label     string                       `json:"label"`
+	Signed    bool                         `json:"signed"`
+	SignedAt  *time.Time                   `json:"signed_at,omitempty"`
+	Decision  models.OfficialDecisionValue `json:"decision,omitempty"`
+	DecidedAt *time.Time                   `json:"decided_at,omitempty"`
+	Comment   string                       `json:"comment,omitempty"`
+}
+
+// TrackingView is the public projection of a request shown on the tracking page.
+// It deliberately leaves out id_card, student_id, parents and signature images.
+type TrackingView struct {
+	Prefix          string                   `json:"prefix"`
+	Name            string                   `json:"name"`
+	DocumentType    string                   `json:"document_type"`
+	Status          models.RequestStatus     `json:"status"`
+	StudentSigned   bool                     `json:"student_signed"`
+	StudentSignedAt *time.Time               `json:"student_signed_at,omitempty"`
+	Steps           []TrackingStep           `json:"steps"`
+	Rejection       *models.RequestRejection `json:"rejection,omitempty"`
+	PDFAvailable    bool                     `json:"pdf_available"`
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+}
+
+func buildTrackingToken(requestID primitive.ObjectID, version int64) (string, error) {
+	if requestID.IsZero() {
+		return "", fmt.Errorf("request id is required")
+	}
+	if version <= 0 {
+		return "", fmt.Errorf("invalid token version")
+	}
+
+	mac := hmac.New(sha256.New, []byte(formLinkSecret()))
+	if _, err := mac.Write([]byte(fmt.Sprintf("track:%s:%d", requestID.Hex(), version))); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:24]), nil
+}
+
+// CreateTrackingToken issues a new tracking token for a request and returns the raw value.
+// Issuing again replaces the previous token.
+func CreateTrackingToken(ctx context.Context, requests RequestStore, id primitive.ObjectID, accountID string) (string, error) {
+	version := nextTokenVersion(0)
+	rawToken, err := buildTrackingToken(id, version)
+	if err != nil {
+		return "", err
+	}
+	if err := requests.SetTrackingToken(ctx, id, accountID, version, tokenHash(rawToken), time.Now()); err != nil {
+		return "", err
+	}
+	return rawToken, nil
+}
+
+// TrackingTokenForRequest rebuilds the raw tracking token of a request, issuing one
+// first for requests submitted before tracking existed.
+func TrackingTokenForRequest(ctx context.Context, requests RequestStore, record *RequestRecord) (string, error) {
+	id, err := ToObjectID(record.ID)
+	if err != nil {
+		return "", err
+	}
+	if record.TrackingTokenVersion <= 0 {
+		return CreateTrackingToken(ctx, requests, id, record.AccountID)
+	}
+	return buildTrackingToken(id, record.TrackingTokenVersion)
+}
+
+// TrackingURL returns the public status page URL for a raw tracking token.
+func TrackingURL(publicBaseURL, rawToken string) string {
+	return fmt.Sprintf("%s/track/%s", publicBaseURL, rawToken)
+}
+
+// GetRequestByTrackingToken resolves a raw tracking token to its request.
+func GetRequestByTrackingToken(ctx context.Context, requests RequestStore, rawToken string) (*RequestRecord, error) {
+	if rawToken == "" {
+		return nil, ErrTrackingTokenNotFound
+	}
+	record, err := requests.FindByTrackingTokenHash(ctx, tokenHash(rawToken))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrTrackingTokenNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// IsFinalPDFAvailable reports whether the signed document may be downloaded by the requester.
+func IsFinalPDFAvailable(status models.RequestStatus) bool {
+	switch status {
+	case models.RequestStatusApproved, models.RequestStatusIssued, models.RequestStatusCollected:
+		return true
+	}
+	return false
+}
+
+func signedAt(sig *models.SignatureBlock) *time.Time {
+	if !sig.HasImage() {
+		return nil
+	}
+	at := sig.SignedAt
+	return &at
+}
+
+// BuildTrackingView projects a request onto the fields a requester may see.
+func BuildTrackingView(record *RequestRecord) TrackingView {
+	status := CurrentRequestStatus(record)
+	view := TrackingView{
+		Prefix:          record.Prefix,
+		Name:            record.Name,
+		DocumentType:    record.DocumentType,
+		Status:          status,
+		StudentSigned:   hasStudentSignatureData(record),
+		StudentSignedAt: signedAt(record.Signatures.Student),
+		Rejection:       record.Rejection,
+		PDFAvailable:    IsFinalPDFAvailable(status),
+		CreatedAt:       record.CreatedAt,
+		UpdatedAt:       record.UpdatedAt,
+	}
+
+	steps := RequestApprovalSteps(record)
+	view.Steps = make([]TrackingStep, 0, len(steps))
+	for _, step := range steps {
+		item := TrackingStep{Role: step.Role, Label: step.Label}
+		if at := signedAt(record.Signatures.ForRole(step.Role)); at != nil {
+			item.Signed = true
+			item.SignedAt = at
+		}
+		if decision := record.Decisions.ForRole(step.Role); decision != nil {
+			item.Decision = decision.Decision
+			item.Comment = decision.Comment
+			if !decision.DecidedAt.IsZero() {
+				decidedAt := decision.DecidedAt
+				item.DecidedAt = &decidedAt
+			}
+		}
+		view.Steps = append(view.Steps, item)
+	}
+	return view
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestBuildTrackingViewReportsBlobBackedSignatures(t *testing.T) {
+	ctx := context.Background()
+	stores := NewMemoryStores()
+	id, err := SaveStudent(ctx, stores.Requests, models.StudentData{
+		Name:         "สมชาย ใจดี",
+		DocumentType: "ปพ.1",
+		AccountID:    "acct-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("SaveStudent returned error: %v", err)
+	}
+
+	image := "data:image/png;base64,iVBORw0KGgoAAAANSUhEUg=="
+	studentAt := time.Now().Add(-2 * time.Hour).Truncate(time.Millisecond)
+	registrarAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for role, at := range map[models.SignRole]time.Time{models.SignRoleStudent: studentAt, models.SignRoleRegistrar: registrarAt} {
+		sig := models.SignatureBlock{DataBase64: image, Method: "draw", SignedAt: at}
+		if err := stores.Requests.SetSignature(ctx, id, "acct-1", role, sig, at); err != nil {
+			t.Fatalf("SetSignature returned error: %v", err)
+		}
+	}
+
+	record, err := stores.Requests.FindByID(ctx, id, "acct-1")
+	if err != nil {
+		t.Fatalf("FindByID returned error: %v", err)
+	}
+	if record.Signatures.Student.DataBase64 != "" || record.Signatures.Student.BlobID == "" {
+		t.Fatal("expected the signature image to live in the blob store")
+	}
+
+	view := BuildTrackingView(record)
+	if !view.StudentSigned || view.StudentSignedAt == nil || !view.StudentSignedAt.Equal(studentAt) {
+		t.Fatalf("unexpected student signature in view: signed=%v at=%v", view.StudentSigned, view.StudentSignedAt)
+	}
+	for _, step := range view.Steps {
+		switch step.Role {
+		case models.SignRoleRegistrar:
+			if !step.Signed || step.SignedAt == nil || !step.SignedAt.Equal(registrarAt) {
+				t.Fatalf("expected the registrar step to be signed at %v, got %#v", registrarAt, step)
+			}
+		default:
+			if step.Signed || step.SignedAt != nil {
+				t.Fatalf("expected %s to be unsigned, got %#v", step.Role, step)
+			}
+		}
+	}
+}
@@ -106,8 +106,8 @@ function isApiErrorResponse(value: unknown): value is ApiErrorResponse {
 function isCreateSignLinkResponse(value: unknown): value is CreateSignLinkResponse {
   return (
     isRecord(value) &&
-    typeof value.sign_url === "string" &&
-    typeof value.token === "string" &&
+    (value.sign_url === undefined || typeof value.sign_url === "string") &&
+    (value.token === undefined || typeof value.token === "string") &&
     typeof value.role === "string" &&
     typeof value.channel === "string" &&
     typeof value.expires_at === "string" &&
@@ -207,7 +207,8 @@ export default function RequestsClient() {
 
       // Fetch request data from Next.js server-side proxy
       try {
-        const res = await fetch(`/api/requests?page=${page}&limit=${limit}`, { cache: "no-store" });
+        // the audit modal reads document hashes from signatures and decisions, which the summary omits
+        const res = await fetch(`/api/requests?page=${page}&limit=${limit}&include=signatures,decisions`, { cache: "no-store" });
         const responseData: unknown = await res.json().catch(() => null);
 
         if (!res.ok) {
@@ -407,7 +408,7 @@ export default function RequestsClient() {
   };
 
   const copyLinkBundle = async (links: Record<OfficialRole, CreateSignLinkResponse | null>) => {
-    if (!links.registrar || !links.director) return;
+    if (!links.registrar?.sign_url || !links.director?.sign_url) return;
     const text = `ลิงก์นายทะเบียน: ${links.registrar.sign_url}\nลิงก์ผู้อำนวยการ: ${links.director.sign_url}`;
     const copied = await copyText(text);
     setLinksModalCopyMessage(copied ? "คัดลอกลิงก์ทั้งสองบทบาทให้อัตโนมัติแล้ว" : "คัดลอกอัตโนมัติไม่สำเร็จ กรุณากดคัดลอกด้วยตนเอง");
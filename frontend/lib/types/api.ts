@@ -108,8 +108,9 @@ export type CreateSignLinkRequestBody = {
 
 export type CreateSignLinkResponse = {
   message: string;
-  sign_url: string;
-  token: string;
+  // Only copy links return the URL; email links are delivered to the recipient alone.
+  sign_url?: string;
+  token?: string;
   role: Extract<SignRole, "registrar" | "director">;
   channel: "email" | "copy";
   recipient_email?: string;